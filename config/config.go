@@ -0,0 +1,105 @@
+// Package config 提供应用程序配置的持久化
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// FilterConfig 定义用户自定义的过滤器
+type FilterConfig struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// AdvancedConfig 定义高级连接设置，应用于之后新建的LDAP客户端
+type AdvancedConfig struct {
+	TimeoutSeconds  int  `json:"timeoutSeconds"`  // 连接超时（秒）
+	MaxRetries      int  `json:"maxRetries"`      // 绑定和修改操作遇到网络错误时的最大尝试次数
+	RetryDelayMs    int  `json:"retryDelayMs"`    // 重试间隔（毫秒），第N次重试前等待该值乘以N-1
+	FollowReferrals bool `json:"followReferrals"` // 搜索返回引用时是否跟随
+}
+
+// DNTemplates 定义填写服务器地址后自动生成的默认DN模板，{domainDN}会替换为域名对应的DC组件
+type DNTemplates struct {
+	AdminDN     string `json:"adminDN"`
+	SearchDN    string `json:"searchDN"`
+	LdapUserDN  string `json:"ldapUserDN"`
+	LdapGroupDN string `json:"ldapGroupDN"`
+}
+
+// AppConfig 定义保存在配置文件中的应用程序配置
+type AppConfig struct {
+	CustomFilters []FilterConfig  `json:"customFilters"`
+	TextSize      string          `json:"textSize,omitempty"`    // 状态区等文字的字号
+	FontPath      string          `json:"fontPath,omitempty"`    // 中文字体路径，为空时按平台自动查找
+	JSONLogFile   string          `json:"jsonLogFile,omitempty"` // 结构化日志文件，设置后每条日志以JSON行追加写入
+	Advanced      *AdvancedConfig `json:"advanced,omitempty"`    // 高级设置，为nil时使用默认值
+	DNTemplates   *DNTemplates    `json:"dnTemplates,omitempty"` // 默认DN模板，为nil时使用内置模板
+
+	path string
+}
+
+// DefaultPath 返回默认配置文件路径
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "LdapTest", "config.json"), nil
+}
+
+// Load 从默认路径加载配置，文件不存在时返回空配置
+func Load() (*AppConfig, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return &AppConfig{}, err
+	}
+	return LoadFrom(path)
+}
+
+// LoadFrom 从指定路径加载配置，文件不存在时返回空配置
+func LoadFrom(path string) (*AppConfig, error) {
+	cfg := &AppConfig{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return cfg, errors.New("解析配置文件失败: " + err.Error())
+	}
+	return cfg, nil
+}
+
+// Save 保存配置到文件
+func (c *AppConfig) Save() error {
+	if c.path == "" {
+		path, err := DefaultPath()
+		if err != nil {
+			return err
+		}
+		c.path = path
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return errors.New("创建配置目录失败: " + err.Error())
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o600)
+}
+
+// Path 返回配置文件路径
+func (c *AppConfig) Path() string {
+	return c.path
+}
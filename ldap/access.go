@@ -0,0 +1,89 @@
+package ldap
+
+import (
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ReadAccessResult 绑定账号对搜索基准的读取权限检查结果
+type ReadAccessResult struct {
+	BaseDN      string
+	BaseErr     error // 读取基准条目失败的原因，成功时为nil，失败时不再枚举子条目
+	HasChildren bool  // 单层搜索是否返回了子条目
+	ChildrenErr error // 枚举子条目失败的原因，成功时为nil
+}
+
+// Readable 返回基准条目和子条目是否都可读取
+func (result *ReadAccessResult) Readable() bool {
+	return result.BaseErr == nil && result.ChildrenErr == nil
+}
+
+// describeAccessError 区分基准不存在和权限不足
+func describeAccessError(err error) string {
+	switch {
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject):
+		return "基准DN不存在或不可见（AD对无读取权限的对象也会返回NoSuchObject）：" + ParseLDAPError(err)
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultInsufficientAccessRights):
+		return "权限不足：" + ParseLDAPError(err)
+	}
+	return ParseLDAPError(err)
+}
+
+// Summary 返回检查结果的可读描述，每项一行
+func (result *ReadAccessResult) Summary() []string {
+	lines := []string{"搜索基准：" + result.BaseDN}
+	if result.BaseErr != nil {
+		lines = append(lines, "[失败] 读取基准条目："+describeAccessError(result.BaseErr))
+	} else {
+		lines = append(lines, "[成功] 读取基准条目")
+	}
+	switch {
+	case result.BaseErr != nil:
+		lines = append(lines, "[跳过] 枚举子条目：基准条目不可读")
+	case result.ChildrenErr != nil:
+		lines = append(lines, "[失败] 枚举子条目："+describeAccessError(result.ChildrenErr))
+	case result.HasChildren:
+		lines = append(lines, "[成功] 枚举子条目")
+	default:
+		lines = append(lines, "[成功] 枚举子条目：没有可见的子条目（可能为空容器或无权读取子条目）")
+	}
+	return lines
+}
+
+// CheckReadAccess 使用当前绑定账号读取baseDN条目，并以单层范围搜索确认可以枚举子条目
+func (client *LDAPClient) CheckReadAccess(baseDN string) (*ReadAccessResult, error) {
+	client.Debug("检查读取权限：%s", baseDN)
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	result := &ReadAccessResult{BaseDN: baseDN}
+	if _, err := readEntry(conn, baseDN, []string{"objectClass"}); err != nil {
+		result.BaseErr = err
+		return result, nil
+	}
+
+	// 只需确认能否返回子条目，限制为1条
+	searchRequest := ldap.NewSearchRequest(
+		baseDN,
+		ldap.ScopeSingleLevel,
+		ldap.NeverDerefAliases,
+		1, 0, false,
+		"(objectClass=*)",
+		[]string{"1.1"},
+		nil,
+	)
+	sr, err := conn.Search(searchRequest)
+	switch {
+	case isSizeLimitExceeded(err):
+		result.HasChildren = true
+	case err != nil:
+		result.ChildrenErr = err
+	default:
+		result.HasChildren = len(sr.Entries) > 0
+	}
+	return result, nil
+}
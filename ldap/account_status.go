@@ -0,0 +1,157 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// accountStatusAttributes 读取账户状态需要的属性
+var accountStatusAttributes = []string{
+	"userAccountControl",
+	"lockoutTime",
+	"badPwdCount",
+	"pwdLastSet",
+	"accountExpires",
+}
+
+// AccountStatus AD账户状态
+type AccountStatus struct {
+	DN                 string
+	UserAccountControl uint32
+	Disabled           bool      // 账户已停用
+	Locked             bool      // 账户已锁定（lockoutTime不为0）
+	Expired            bool      // 账户已过期
+	MustChangePassword bool      // 下次登录必须修改密码（pwdLastSet为0）
+	BadPwdCount        int       // 错误密码次数
+	LockoutTime        time.Time // 锁定时间，未锁定时为零值
+	PasswordLastSet    time.Time // 上次设置密码时间，未设置时为零值
+	AccountExpires     time.Time // 账户过期时间，永不过期时为零值
+}
+
+// GetAccountStatus 读取用户的账户状态（仅AD）
+func (client *LDAPClient) GetAccountStatus(userDN string) (AccountStatus, error) {
+	status := AccountStatus{DN: userDN}
+	if !client.isActiveDirectory() {
+		return status, errors.New("账户状态仅适用于Active Directory")
+	}
+	client.Debug("正在读取账户状态：%s", userDN)
+
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return status, errors.New("获取连接失败: " + err.Error())
+	}
+	defer release()
+
+	searchRequest := ldap.NewSearchRequest(
+		userDN,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0, 0, false,
+		"(objectClass=*)",
+		accountStatusAttributes,
+		nil,
+	)
+	sr, err := conn.Search(searchRequest)
+	if err != nil {
+		return status, errors.New("读取账户状态失败: " + ParseLDAPError(err))
+	}
+	if len(sr.Entries) == 0 {
+		return status, errors.New("未找到用户: " + userDN)
+	}
+	entry := sr.Entries[0]
+
+	if value := entry.GetAttributeValue("userAccountControl"); value != "" {
+		uac, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return status, errors.New("无效的userAccountControl值: " + value)
+		}
+		status.UserAccountControl = uint32(uac)
+	}
+	if value := entry.GetAttributeValue("badPwdCount"); value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return status, errors.New("无效的badPwdCount值: " + value)
+		}
+		status.BadPwdCount = count
+	}
+
+	// 属性缺失时视为未设置
+	for _, field := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"lockoutTime", &status.LockoutTime},
+		{"pwdLastSet", &status.PasswordLastSet},
+		{"accountExpires", &status.AccountExpires},
+	} {
+		value := entry.GetAttributeValue(field.name)
+		if value == "" {
+			continue
+		}
+		t, ok := DecodeADTime(value)
+		if !ok {
+			return status, fmt.Errorf("无效的%s值: %s", field.name, value)
+		}
+		*field.target = t
+	}
+
+	status.Disabled = status.UserAccountControl&UAC_ACCOUNTDISABLE != 0
+	status.Locked = !status.LockoutTime.IsZero()
+	status.Expired = !status.AccountExpires.IsZero() && status.AccountExpires.Before(time.Now())
+	status.MustChangePassword = entry.GetAttributeValue("pwdLastSet") == "0" ||
+		status.UserAccountControl&UAC_PASSWORD_EXPIRED != 0
+
+	client.Debug("账户状态：%+v", status)
+	return status, nil
+}
+
+// Summary 返回账户状态的可读描述，每项一行
+func (status AccountStatus) Summary() []string {
+	yesNo := func(b bool) string {
+		if b {
+			return "是"
+		}
+		return "否"
+	}
+	formatTime := func(t time.Time, zero string) string {
+		if t.IsZero() {
+			return zero
+		}
+		return t.Local().Format("2006-01-02 15:04:05")
+	}
+
+	return []string{
+		"账户：" + status.DN,
+		"已停用：" + yesNo(status.Disabled),
+		"已锁定：" + yesNo(status.Locked) + "（锁定时间：" + formatTime(status.LockoutTime, "无") + "）",
+		"已过期：" + yesNo(status.Expired) + "（过期时间：" + formatTime(status.AccountExpires, "永不过期") + "）",
+		"下次登录须修改密码：" + yesNo(status.MustChangePassword),
+		"错误密码次数：" + strconv.Itoa(status.BadPwdCount),
+		"上次设置密码：" + formatTime(status.PasswordLastSet, "从未设置"),
+		fmt.Sprintf("userAccountControl：%d (0x%08X)", status.UserAccountControl, status.UserAccountControl),
+	}
+}
+
+// UnlockAccount 解除账户锁定（将lockoutTime置为0，仅AD）
+func (client *LDAPClient) UnlockAccount(userDN string) error {
+	if !client.isActiveDirectory() {
+		return errors.New("解锁账户仅适用于Active Directory")
+	}
+	client.Debug("正在解锁账户：%s", userDN)
+
+	modifyRequest := ldap.NewModifyRequest(userDN, nil)
+	modifyRequest.Replace("lockoutTime", []string{"0"})
+	err := client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modify(conn, modifyRequest)
+	})
+	if err != nil {
+		return errors.New("解锁账户失败: " + ParseLDAPError(err))
+	}
+
+	client.Info("账户已解锁：%s", userDN)
+	return nil
+}
@@ -0,0 +1,337 @@
+package ldap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// sdFlagsControlOID LDAP_SERVER_SD_FLAGS_OID，指定读写nTSecurityDescriptor的哪些部分
+const sdFlagsControlOID = "1.2.840.113556.1.4.801"
+
+// 安全描述符与ACL的结构常量
+const (
+	aclRevision   byte = 0x02 // 只包含普通ACE的ACL版本
+	aclRevisionDS byte = 0x04 // 包含对象ACE的ACL版本
+
+	inheritedACE byte = 0x10 // ACE继承自父对象
+
+	sdControlDACLPresent  uint16 = 0x0004
+	sdControlSelfRelative uint16 = 0x8000
+	// 写回DACL时需要保留的控制位：DACL默认、自动继承请求、自动继承、禁止继承
+	sdControlDACLMask uint16 = 0x0008 | 0x0100 | 0x0400 | 0x1000
+)
+
+// ACE 访问控制项（只支持ACCESS_ALLOWED/ACCESS_DENIED等普通ACE）
+type ACE struct {
+	Type  byte   // ACCESS_ALLOWED_ACE_TYPE或ACCESS_DENIED_ACE_TYPE
+	Flags byte   // CONTAINER_INHERIT_ACE等继承标志
+	Mask  uint32 // RIGHT_DS_READ_PROPERTY等权限掩码
+	SID   []byte // 受托者的二进制SID
+}
+
+// Bytes 按ACE结构编码：类型、标志、长度、权限掩码、SID
+func (ace ACE) Bytes() []byte {
+	size := 8 + len(ace.SID)
+	buf := make([]byte, 8, size)
+	buf[0] = ace.Type
+	buf[1] = ace.Flags
+	binary.LittleEndian.PutUint16(buf[2:4], uint16(size))
+	binary.LittleEndian.PutUint32(buf[4:8], ace.Mask)
+	return append(buf, ace.SID...)
+}
+
+// BuildDACL 将ACE编码为二进制DACL
+func BuildDACL(aces []ACE) []byte {
+	raw := make([][]byte, 0, len(aces))
+	for _, ace := range aces {
+		raw = append(raw, ace.Bytes())
+	}
+	return buildACL(raw)
+}
+
+// buildACL 用已编码的ACE组成ACL，包含对象ACE时使用DS版本
+func buildACL(aces [][]byte) []byte {
+	revision := aclRevision
+	size := 8
+	for _, ace := range aces {
+		if ace[0] >= 0x05 && ace[0] <= 0x08 {
+			revision = aclRevisionDS
+		}
+		size += len(ace)
+	}
+
+	buf := make([]byte, 8, size)
+	buf[0] = revision
+	binary.LittleEndian.PutUint16(buf[2:4], uint16(size))
+	binary.LittleEndian.PutUint16(buf[4:6], uint16(len(aces)))
+	for _, ace := range aces {
+		buf = append(buf, ace...)
+	}
+	return buf
+}
+
+// BuildSecurityDescriptor 生成只包含DACL的自相对安全描述符
+func BuildSecurityDescriptor(dacl []byte) []byte {
+	return buildSecurityDescriptor(0, dacl)
+}
+
+// buildSecurityDescriptor 生成只包含DACL的自相对安全描述符，control为需要保留的控制位
+func buildSecurityDescriptor(control uint16, dacl []byte) []byte {
+	buf := make([]byte, 20, 20+len(dacl))
+	buf[0] = 1 // 版本
+	binary.LittleEndian.PutUint16(buf[2:4], control&sdControlDACLMask|sdControlDACLPresent|sdControlSelfRelative)
+	binary.LittleEndian.PutUint32(buf[16:20], 20) // DACL紧跟在头部之后
+	return append(buf, dacl...)
+}
+
+// parseDACL 从自相对安全描述符中取出控制位和DACL中已编码的各个ACE
+func parseDACL(sd []byte) (uint16, [][]byte, error) {
+	if len(sd) < 20 {
+		return 0, nil, fmt.Errorf("安全描述符长度无效: %d", len(sd))
+	}
+	control := binary.LittleEndian.Uint16(sd[2:4])
+	offset := int(binary.LittleEndian.Uint32(sd[16:20]))
+	if control&sdControlDACLPresent == 0 || offset == 0 {
+		return control, nil, nil
+	}
+	if offset+8 > len(sd) {
+		return 0, nil, fmt.Errorf("DACL偏移无效: %d", offset)
+	}
+
+	acl := sd[offset:]
+	count := int(binary.LittleEndian.Uint16(acl[4:6]))
+	aces := make([][]byte, 0, count)
+	pos := 8
+	for i := 0; i < count; i++ {
+		if pos+4 > len(acl) {
+			return 0, nil, fmt.Errorf("第%d个ACE越界", i+1)
+		}
+		size := int(binary.LittleEndian.Uint16(acl[pos+2 : pos+4]))
+		if size < 8 || pos+size > len(acl) {
+			return 0, nil, fmt.Errorf("第%d个ACE长度无效: %d", i+1, size)
+		}
+		aces = append(aces, acl[pos:pos+size])
+		pos += size
+	}
+	return control, aces, nil
+}
+
+// NewSDFlagsControl 创建LDAP_SERVER_SD_FLAGS_OID控制，flags为OWNER/GROUP/DACL/SACL_SECURITY_INFORMATION的组合
+// AD只返回或写入flags指定的安全描述符部分；不指定SACL时普通账号也能读取
+func NewSDFlagsControl(flags uint32) ldap.Control {
+	value := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "SDFlagsRequestValue")
+	value.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(flags), "Flags"))
+	return ldap.NewControlString(sdFlagsControlOID, true, string(value.Bytes()))
+}
+
+// readSecurityDescriptor 使用SD标志控制读取条目的nTSecurityDescriptor
+func readSecurityDescriptor(conn *ldap.Conn, dn string, flags uint32) ([]byte, error) {
+	searchRequest := ldap.NewSearchRequest(
+		dn,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0, 0, false,
+		"(objectClass=*)",
+		[]string{"nTSecurityDescriptor"},
+		[]ldap.Control{NewSDFlagsControl(flags)},
+	)
+	sr, err := conn.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+	if len(sr.Entries) == 0 {
+		return nil, fmt.Errorf("未找到条目：%s", dn)
+	}
+	sd := sr.Entries[0].GetRawAttributeValue("nTSecurityDescriptor")
+	if len(sd) == 0 {
+		return nil, fmt.Errorf("无法读取 %s 的nTSecurityDescriptor，请确认账号有READ_CONTROL权限", dn)
+	}
+	return sd, nil
+}
+
+// GetSecurityDescriptor 读取条目的nTSecurityDescriptor，只包含所有者、主组和DACL（不读取需要特殊权限的SACL）
+func (client *LDAPClient) GetSecurityDescriptor(dn string) ([]byte, error) {
+	client.Debug("读取安全描述符：%s", dn)
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	flags := OWNER_SECURITY_INFORMATION | GROUP_SECURITY_INFORMATION | DACL_SECURITY_INFORMATION
+	sd, err := readSecurityDescriptor(conn, dn, flags)
+	if err != nil {
+		return nil, fmt.Errorf("读取安全描述符失败: %s", ParseLDAPError(err))
+	}
+	return sd, nil
+}
+
+// aceTypeNames ACE类型对应的名称
+var aceTypeNames = map[byte]string{
+	ACCESS_ALLOWED_ACE_TYPE: "允许",
+	ACCESS_DENIED_ACE_TYPE:  "拒绝",
+	0x05:                    "允许(对象)",
+	0x06:                    "拒绝(对象)",
+}
+
+// accessRightNames 常见目录服务权限位的名称，按位从低到高排列
+var accessRightNames = []struct {
+	mask uint32
+	name string
+}{
+	{0x00000001, "CreateChild"},
+	{0x00000002, "DeleteChild"},
+	{RIGHT_DS_LIST_CONTENTS, "ListContents"},
+	{0x00000008, "Self"},
+	{RIGHT_DS_READ_PROPERTY, "ReadProperty"},
+	{RIGHT_DS_WRITE_PROPERTY, "WriteProperty"},
+	{0x00000040, "DeleteTree"},
+	{RIGHT_DS_LIST_OBJECT, "ListObject"},
+	{0x00000100, "ControlAccess"},
+	{0x00010000, "Delete"},
+	{0x00020000, "ReadControl"},
+	{0x00040000, "WriteDACL"},
+	{0x00080000, "WriteOwner"},
+	{0x10000000, "GenericAll"},
+	{0x20000000, "GenericExecute"},
+	{0x40000000, "GenericWrite"},
+	{0x80000000, "GenericRead"},
+}
+
+// describeAccessMask 将权限掩码转换为权限名称列表
+func describeAccessMask(mask uint32) string {
+	var names []string
+	for _, right := range accessRightNames {
+		if mask&right.mask != 0 {
+			names = append(names, right.name)
+		}
+	}
+	if len(names) == 0 {
+		return "无"
+	}
+	return strings.Join(names, "|")
+}
+
+// aceSID 返回已编码ACE中的受托者SID，对象ACE需要跳过对象类型GUID
+func aceSID(ace []byte) []byte {
+	pos := 8
+	if ace[0] >= 0x05 && ace[0] <= 0x08 {
+		if len(ace) < 12 {
+			return nil
+		}
+		objectFlags := binary.LittleEndian.Uint32(ace[8:12])
+		pos = 12
+		if objectFlags&0x1 != 0 {
+			pos += 16 // ObjectType
+		}
+		if objectFlags&0x2 != 0 {
+			pos += 16 // InheritedObjectType
+		}
+	}
+	if pos > len(ace) {
+		return nil
+	}
+	return ace[pos:]
+}
+
+// DescribeDACL 将安全描述符中的DACL格式化为每个ACE一行的说明
+func DescribeDACL(sd []byte) ([]string, error) {
+	_, aces, err := parseDACL(sd)
+	if err != nil {
+		return nil, err
+	}
+	if len(aces) == 0 {
+		return []string{"（DACL为空或不存在）"}, nil
+	}
+
+	lines := make([]string, 0, len(aces))
+	for _, ace := range aces {
+		typeName, ok := aceTypeNames[ace[0]]
+		if !ok {
+			typeName = fmt.Sprintf("类型0x%02X", ace[0])
+		}
+		mask := binary.LittleEndian.Uint32(ace[4:8])
+		sid := DecodeSID(aceSID(ace))
+		if sid == "" {
+			sid = "（无法解析的SID）"
+		}
+		line := fmt.Sprintf("%s %s 0x%08X %s", typeName, sid, mask, describeAccessMask(mask))
+		if ace[1]&inheritedACE != 0 {
+			line += " [继承]"
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// hasAllowedACE 判断已编码的ACE列表中是否已有授予sid所需全部权限的ACE
+func hasAllowedACE(aces [][]byte, sid []byte, mask uint32) bool {
+	for _, ace := range aces {
+		if ace[0] != ACCESS_ALLOWED_ACE_TYPE || len(ace) < 8 {
+			continue
+		}
+		if binary.LittleEndian.Uint32(ace[4:8])&mask == mask && bytes.Equal(ace[8:], sid) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGroupACL 在targetDN的DACL中为组添加允许ACE（可被子对象继承），已有相同授权时不做修改
+// 只替换DACL部分，原有ACE保持不变；新ACE位于显式ACE之后、继承ACE之前
+func (client *LDAPClient) SetGroupACL(targetDN string, groupDN string, mask uint32) error {
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	groupEntry, err := readEntry(conn, groupDN, []string{"objectSid"})
+	if err != nil {
+		return fmt.Errorf("读取组objectSid失败: %s", ParseLDAPError(err))
+	}
+	sid := groupEntry.GetRawAttributeValue("objectSid")
+	if err := validateSID(sid); err != nil {
+		return err
+	}
+
+	sd, err := readSecurityDescriptor(conn, targetDN, DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return fmt.Errorf("读取安全描述符失败: %s", ParseLDAPError(err))
+	}
+	control, aces, err := parseDACL(sd)
+	if err != nil {
+		return err
+	}
+	if hasAllowedACE(aces, sid, mask) {
+		client.Info("%s 已授予 %s 所需权限，无需修改", targetDN, DecodeSID(sid))
+		return nil
+	}
+
+	newACE := ACE{Type: ACCESS_ALLOWED_ACE_TYPE, Flags: CONTAINER_INHERIT_ACE, Mask: mask, SID: sid}
+	insertAt := len(aces)
+	for i, ace := range aces {
+		if ace[1]&inheritedACE != 0 {
+			insertAt = i
+			break
+		}
+	}
+	merged := make([][]byte, 0, len(aces)+1)
+	merged = append(merged, aces[:insertAt]...)
+	merged = append(merged, newACE.Bytes())
+	merged = append(merged, aces[insertAt:]...)
+
+	modifyRequest := ldap.NewModifyRequest(targetDN, []ldap.Control{NewSDFlagsControl(DACL_SECURITY_INFORMATION)})
+	modifyRequest.Replace("nTSecurityDescriptor", []string{string(buildSecurityDescriptor(control, buildACL(merged)))})
+	if err := client.modify(conn, modifyRequest); err != nil {
+		return fmt.Errorf("写入DACL失败: %s", ParseLDAPError(err))
+	}
+
+	client.Info("已在 %s 上为 %s 授予权限 0x%08X", targetDN, DecodeSID(sid), mask)
+	return nil
+}
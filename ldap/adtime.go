@@ -0,0 +1,72 @@
+package ldap
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileTimeNever AD中表示"永不"的FILETIME值
+const fileTimeNever int64 = 0x7FFFFFFFFFFFFFFF
+
+// fileTimeEpochOffset 1601-01-01到1970-01-01之间的秒数
+const fileTimeEpochOffset int64 = 11644473600
+
+// generalizedTimeLayouts GeneralizedTime常见的格式
+var generalizedTimeLayouts = []string{
+	"20060102150405.0Z",
+	"20060102150405Z",
+	"20060102150405.0Z0700",
+	"20060102150405Z0700",
+}
+
+// FileTimeToTime 将AD的FILETIME（自1601年起的100纳秒数）转换为时间，0和最大值表示未设置
+func FileTimeToTime(value int64) time.Time {
+	if value <= 0 || value == fileTimeNever {
+		return time.Time{}
+	}
+	seconds := value/10000000 - fileTimeEpochOffset
+	nanos := (value % 10000000) * 100
+	return time.Unix(seconds, nanos)
+}
+
+// TimeToFileTime 将时间转换为AD的FILETIME（自1601年起的100纳秒数）
+func TimeToFileTime(t time.Time) int64 {
+	return (t.Unix()+fileTimeEpochOffset)*10000000 + int64(t.Nanosecond()/100)
+}
+
+// DecodeADTime 解析FILETIME（如pwdLastSet）或GeneralizedTime（如whenCreated）格式的时间
+// 0（从未设置）和0x7FFFFFFFFFFFFFFF（永不过期）返回零值时间，无法解析时返回false
+func DecodeADTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	if fileTime, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if fileTime < 0 {
+			return time.Time{}, false
+		}
+		return FileTimeToTime(fileTime), true
+	}
+	for _, layout := range generalizedTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// FormatADTime 将AD时间格式化为本地时间，特殊值显示为"从未设置"或"永不过期"
+func FormatADTime(value string) string {
+	switch strings.TrimSpace(value) {
+	case "0":
+		return "从未设置"
+	case strconv.FormatInt(fileTimeNever, 10):
+		return "永不过期"
+	}
+	t, ok := DecodeADTime(value)
+	if !ok {
+		return "无法解析"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,84 @@
+package ldap
+
+import (
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// 属性修改操作类型
+const (
+	ModifyAdd     = ldap.AddAttribute
+	ModifyDelete  = ldap.DeleteAttribute
+	ModifyReplace = ldap.ReplaceAttribute
+)
+
+// modifyOperationNames 修改操作类型对应的名称
+var modifyOperationNames = map[int]string{
+	ModifyReplace: "Replace",
+	ModifyAdd:     "Add",
+	ModifyDelete:  "Delete",
+}
+
+// ModifyOperations 返回可选的修改操作名称
+func ModifyOperations() []string {
+	return []string{"Replace", "Add", "Delete"}
+}
+
+// ParseModifyOperation 将操作名称转换为修改操作类型
+func ParseModifyOperation(name string) (int, bool) {
+	for op, n := range modifyOperationNames {
+		if n == name {
+			return op, true
+		}
+	}
+	return 0, false
+}
+
+// CompareAttribute 比较条目的某个属性是否包含指定值
+func (client *LDAPClient) CompareAttribute(dn string, attr string, value string) (bool, error) {
+	client.Debug("比较属性：%s 的 %s 是否为 %s", dn, attr, maskSensitive(attr, []string{value})[0])
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return false, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	matched, err := conn.Compare(dn, attr, value)
+	if err != nil {
+		// 旧版本go-ldap会把比较结果作为错误返回
+		switch {
+		case ldap.IsErrorWithCode(err, ldap.LDAPResultCompareTrue):
+			return true, nil
+		case ldap.IsErrorWithCode(err, ldap.LDAPResultCompareFalse):
+			return false, nil
+		}
+		return false, fmt.Errorf("比较失败: %s", ParseLDAPError(err))
+	}
+	return matched, nil
+}
+
+// ModifyEntry 修改条目的单个属性，op为ModifyAdd、ModifyDelete或ModifyReplace
+func (client *LDAPClient) ModifyEntry(dn string, op int, attr string, values []string) error {
+	if _, ok := modifyOperationNames[op]; !ok {
+		return fmt.Errorf("未知的修改操作: %d", op)
+	}
+
+	modifyRequest := ldap.NewModifyRequest(dn, nil)
+	switch op {
+	case ModifyAdd:
+		modifyRequest.Add(attr, values)
+	case ModifyDelete:
+		modifyRequest.Delete(attr, values)
+	case ModifyReplace:
+		modifyRequest.Replace(attr, values)
+	}
+	err := client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modify(conn, modifyRequest)
+	})
+	if err != nil {
+		return fmt.Errorf("修改属性失败: %s", ParseLDAPError(err))
+	}
+	client.Info("已修改属性：%s 的 %s", dn, attr)
+	return nil
+}
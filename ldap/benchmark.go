@@ -0,0 +1,172 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// BenchmarkResult 性能测试结果
+type BenchmarkResult struct {
+	Iterations  int             // 计划执行的次数
+	Concurrency int             // 并发数
+	Errors      int             // 失败次数
+	FirstError  error           // 第一个错误，便于排查
+	Duration    time.Duration   // 总耗时
+	Latencies   []time.Duration // 每次成功的绑定+搜索耗时，已按升序排列
+}
+
+// Min 返回最小耗时
+func (result *BenchmarkResult) Min() time.Duration {
+	if len(result.Latencies) == 0 {
+		return 0
+	}
+	return result.Latencies[0]
+}
+
+// Max 返回最大耗时
+func (result *BenchmarkResult) Max() time.Duration {
+	if len(result.Latencies) == 0 {
+		return 0
+	}
+	return result.Latencies[len(result.Latencies)-1]
+}
+
+// Avg 返回平均耗时
+func (result *BenchmarkResult) Avg() time.Duration {
+	if len(result.Latencies) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, latency := range result.Latencies {
+		total += latency
+	}
+	return total / time.Duration(len(result.Latencies))
+}
+
+// Percentile 返回第p百分位的耗时（p取值0-100）
+func (result *BenchmarkResult) Percentile(p int) time.Duration {
+	if len(result.Latencies) == 0 {
+		return 0
+	}
+	index := (len(result.Latencies)*p+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+	return result.Latencies[index]
+}
+
+// OpsPerSecond 返回每秒完成的绑定+搜索次数
+func (result *BenchmarkResult) OpsPerSecond() float64 {
+	if result.Duration <= 0 {
+		return 0
+	}
+	return float64(len(result.Latencies)) / result.Duration.Seconds()
+}
+
+// Summary 返回性能测试结果的可读描述，每项一行
+func (result *BenchmarkResult) Summary() []string {
+	round := func(d time.Duration) string {
+		return d.Round(10 * time.Microsecond).String()
+	}
+	lines := []string{
+		fmt.Sprintf("次数：%d（并发 %d），成功 %d，失败 %d", result.Iterations, result.Concurrency, len(result.Latencies), result.Errors),
+		"总耗时：" + round(result.Duration),
+		fmt.Sprintf("延迟：最小 %s，平均 %s，最大 %s，P95 %s", round(result.Min()), round(result.Avg()), round(result.Max()), round(result.Percentile(95))),
+		fmt.Sprintf("吞吐量：%.1f 次/秒", result.OpsPerSecond()),
+	}
+	if result.FirstError != nil {
+		lines = append(lines, "首个错误："+ParseLDAPError(result.FirstError))
+	}
+	return lines
+}
+
+// Benchmark 使用测试账号执行iterations次绑定+搜索，concurrency个连接并发执行
+// 连接从最大连接数为concurrency的连接池中获取，连接建立的耗时不计入统计
+func (client *LDAPClient) Benchmark(userDN string, password string, iterations int, concurrency int) (*BenchmarkResult, error) {
+	if iterations <= 0 {
+		return nil, errors.New("测试次数必须大于0")
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > iterations {
+		concurrency = iterations
+	}
+	client.Debug("开始性能测试：%s，次数 %d，并发 %d", userDN, iterations, concurrency)
+
+	result := &BenchmarkResult{Iterations: iterations, Concurrency: concurrency}
+	var mu sync.Mutex
+	record := func(latency time.Duration, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			result.Errors++
+			if result.FirstError == nil {
+				result.FirstError = err
+			}
+			return
+		}
+		result.Latencies = append(result.Latencies, latency)
+	}
+
+	searchRequest := ldap.NewSearchRequest(
+		userDN,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0, 0, false,
+		"(objectClass=*)",
+		[]string{"1.1"}, // 不返回属性
+		nil,
+	)
+
+	jobs := make(chan struct{}, iterations)
+	for i := 0; i < iterations; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+
+	pool := NewConnPool(client, concurrency)
+	defer pool.Close()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				if client.ctx.Err() != nil {
+					record(0, ErrOperationCanceled)
+					continue
+				}
+				conn, err := pool.Get()
+				if err != nil {
+					record(0, err)
+					continue
+				}
+				begin := time.Now()
+				err = conn.Bind(userDN, password)
+				if err == nil {
+					_, err = conn.Search(searchRequest)
+				}
+				record(time.Since(begin), err)
+				pool.Put(conn)
+			}
+		}()
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+
+	sort.Slice(result.Latencies, func(i, j int) bool {
+		return result.Latencies[i] < result.Latencies[j]
+	})
+	if client.ctx.Err() != nil {
+		return result, ErrOperationCanceled
+	}
+	return result, nil
+}
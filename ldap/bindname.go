@@ -0,0 +1,48 @@
+package ldap
+
+import (
+	"errors"
+	"strings"
+)
+
+// 绑定账号的格式
+const (
+	BindNameDN        = "DN"  // 完整DN，如CN=Administrator,CN=Users,DC=corp,DC=local
+	BindNameUPN       = "UPN" // 用户主体名称，如administrator@corp.local
+	BindNameDownLevel = "NT"  // 旧式登录名，如CORP\administrator
+)
+
+// BindNameFormat 判断绑定账号的格式，不含"="且包含"\"或"@"时视为NT或UPN格式，否则视为DN
+func BindNameFormat(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.Contains(name, "=") {
+		return BindNameDN
+	}
+	if strings.Contains(name, `\`) {
+		return BindNameDownLevel
+	}
+	if strings.Contains(name, "@") {
+		return BindNameUPN
+	}
+	return BindNameDN
+}
+
+// ValidateBindName 校验绑定账号，支持DN、UPN和NT三种格式（UPN和NT格式仅AD支持）
+func ValidateBindName(name string) error {
+	name = strings.TrimSpace(name)
+	switch BindNameFormat(name) {
+	case BindNameUPN:
+		user, domain, _ := strings.Cut(name, "@")
+		if user == "" || domain == "" || strings.Contains(domain, "@") {
+			return errors.New("UPN格式应为 用户名@域名")
+		}
+	case BindNameDownLevel:
+		domain, user, _ := strings.Cut(name, `\`)
+		if user == "" || domain == "" || strings.Contains(user, `\`) {
+			return errors.New(`NT格式应为 域\用户名`)
+		}
+	default:
+		return ValidateDN(name)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package ldap
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// BulkUser 批量创建时的一行用户数据
+type BulkUser struct {
+	CN       string // 用户名
+	Password string // 密码
+	OU       string // 可选：父容器，可为完整DN或OU名称
+}
+
+// BulkResult 批量创建结果统计
+type BulkResult struct {
+	Created int
+	Skipped int
+	Failed  int
+}
+
+// ParseBulkUsersCSV 解析批量创建用户的CSV，列依次为cn、password、ou（可选）
+// 首行若为"cn"开头的表头则按列名匹配
+func ParseBulkUsersCSV(r io.Reader) ([]BulkUser, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV失败: %v", err)
+	}
+
+	columns := map[string]int{"cn": 0, "password": 1, "ou": 2}
+	if len(rows) > 0 && strings.EqualFold(strings.TrimSpace(rows[0][0]), "cn") {
+		columns = map[string]int{}
+		for i, name := range rows[0] {
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		if _, ok := columns["password"]; !ok {
+			return nil, errors.New("CSV表头缺少password列")
+		}
+		rows = rows[1:]
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var users []BulkUser
+	for _, row := range rows {
+		user := BulkUser{CN: field(row, "cn"), Password: field(row, "password"), OU: field(row, "ou")}
+		if user.CN == "" {
+			continue
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// ParentDN 返回用户所在容器：OU为完整DN时直接使用，为名称时放在baseDN下，为空时使用baseDN
+func (user BulkUser) ParentDN(baseDN string) string {
+	switch {
+	case user.OU == "":
+		return baseDN
+	case strings.Contains(user.OU, "="):
+		return user.OU
+	default:
+		return RDN("OU", user.OU) + "," + baseDN
+	}
+}
+
+// BulkCreateUsers 按当前模板和SSL模式创建用户，已存在的用户跳过
+// 先按顺序确保各用户的父容器存在，再由DefaultPoolSize个goroutine并发创建用户，每个操作从连接池获取连接；
+// 出现网络错误时停止分派剩余用户并返回错误
+func (client *LDAPClient) BulkCreateUsers(users []BulkUser, baseDN string, isSSL bool) (BulkResult, error) {
+	var result BulkResult
+
+	client.UseConnPool(DefaultPoolSize)
+	defer client.ReleaseConnPool()
+
+	// 同一容器只检查和确认一次，避免并发创建同一容器或重复提示确认
+	parentErrors := make(map[string]error)
+	for _, user := range users {
+		parentDN := user.ParentDN(baseDN)
+		if _, checked := parentErrors[parentDN]; checked {
+			continue
+		}
+		if client.ctx.Err() != nil {
+			return result, ErrOperationCanceled
+		}
+		err := client.EnsureDNExists(parentDN)
+		if isNetworkError(err) {
+			return result, fmt.Errorf("连接中断，已停止批量创建: %v", err)
+		}
+		parentErrors[parentDN] = err
+	}
+
+	var (
+		mu      sync.Mutex
+		stopErr error
+		wg      sync.WaitGroup
+	)
+	// record 累加统计，操作因网络错误失败时停止分派剩余用户
+	record := func(counter *int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		*counter++
+		if stopErr == nil && isNetworkError(err) {
+			stopErr = fmt.Errorf("连接中断，已停止批量创建: %v", err)
+		}
+	}
+	stopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return stopErr != nil
+	}
+
+	indexes := make(chan int)
+	for w := 0; w < DefaultPoolSize; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				user := users[i]
+				userDN := RDN("CN", user.CN) + "," + user.ParentDN(baseDN)
+				progress := fmt.Sprintf("[%d/%d]", i+1, len(users))
+
+				if err := parentErrors[user.ParentDN(baseDN)]; err != nil {
+					client.Error("%s 创建失败：%s，%v", progress, userDN, err)
+					record(&result.Failed, nil)
+					continue
+				}
+				if found, existingDN := client.SearchUser(user.CN, baseDN); found {
+					client.Warn("%s 用户已存在，跳过：%s", progress, existingDN)
+					record(&result.Skipped, nil)
+					continue
+				}
+				if err := client.CreateOrUpdateUser(userDN, user.CN, user.Password, isSSL); err != nil {
+					client.Error("%s 创建失败：%s，%v", progress, userDN, err)
+					record(&result.Failed, err)
+					continue
+				}
+				client.Info("%s 已创建：%s", progress, userDN)
+				record(&result.Created, nil)
+			}
+		}()
+	}
+
+	for i := range users {
+		if client.ctx.Err() != nil || stopped() {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if client.ctx.Err() != nil {
+		return result, ErrOperationCanceled
+	}
+	return result, stopErr
+}
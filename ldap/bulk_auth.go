@@ -0,0 +1,134 @@
+package ldap
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Credential 批量验证时的一行账号数据
+type Credential struct {
+	Username string
+	Password string
+}
+
+// BulkAuthRow 单个账号的验证结果
+type BulkAuthRow struct {
+	Username string
+	Result   *AuthResult
+}
+
+// BulkAuthReport 批量验证结果
+type BulkAuthReport struct {
+	Rows   []BulkAuthRow
+	Passed int
+	Failed int
+}
+
+// ParseCredentialsCSV 解析批量验证的CSV，列依次为username、password
+// 首行若为"username"开头的表头则跳过
+func ParseCredentialsCSV(r io.Reader) ([]Credential, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV失败: %v", err)
+	}
+	if len(rows) > 0 && strings.EqualFold(strings.TrimSpace(rows[0][0]), "username") {
+		rows = rows[1:]
+	}
+
+	var credentials []Credential
+	for _, row := range rows {
+		if len(row) == 0 || strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		credential := Credential{Username: strings.TrimSpace(row[0])}
+		if len(row) > 1 {
+			// 密码可能包含首尾空格，保持原样
+			credential.Password = row[1]
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, nil
+}
+
+// BulkTestUserAuth 依次验证每个账号：搜索复用同一个连接，每个用户使用新连接绑定
+func (client *LDAPClient) BulkTestUserAuth(credentials []Credential, searchDN string, filterPattern string) (*BulkAuthReport, error) {
+	report := &BulkAuthReport{}
+
+	if err := client.UseSharedConnection(); err != nil {
+		return report, fmt.Errorf("连接失败: %v", err)
+	}
+	defer client.ReleaseSharedConnection()
+
+	for i, credential := range credentials {
+		if client.ctx.Err() != nil {
+			return report, ErrOperationCanceled
+		}
+		progress := fmt.Sprintf("[%d/%d]", i+1, len(credentials))
+
+		result := &AuthResult{ErrDetail: "密码为空", Category: "密码为空"}
+		if credential.Password != "" {
+			result = client.TestUserAuthDetailed(credential.Username, credential.Password, searchDN, filterPattern)
+		}
+		report.Rows = append(report.Rows, BulkAuthRow{Username: credential.Username, Result: result})
+
+		if result.Success() {
+			report.Passed++
+			client.Info("%s 验证成功：%s", progress, credential.Username)
+		} else {
+			report.Failed++
+			client.Warn("%s 验证失败：%s，%s", progress, credential.Username, result.ErrDetail)
+		}
+	}
+	return report, nil
+}
+
+// FailuresByCategory 按失败类别统计失败数
+func (report *BulkAuthReport) FailuresByCategory() map[string]int {
+	categories := make(map[string]int)
+	for _, row := range report.Rows {
+		if !row.Result.Success() {
+			categories[row.Result.Category]++
+		}
+	}
+	return categories
+}
+
+// Summary 返回批量验证报告，包括统计、失败分类和每行结果
+func (report *BulkAuthReport) Summary() []string {
+	lines := []string{fmt.Sprintf("共 %d 个账号：成功 %d 个，失败 %d 个", len(report.Rows), report.Passed, report.Failed)}
+
+	categories := report.FailuresByCategory()
+	if len(categories) > 0 {
+		names := make([]string, 0, len(categories))
+		for name := range categories {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if categories[names[i]] != categories[names[j]] {
+				return categories[names[i]] > categories[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		lines = append(lines, "失败分类：")
+		for _, name := range names {
+			lines = append(lines, fmt.Sprintf("  %s：%d", name, categories[name]))
+		}
+	}
+
+	lines = append(lines, "明细：")
+	for _, row := range report.Rows {
+		if row.Result.Success() {
+			lines = append(lines, "  [成功] "+row.Username+" -> "+row.Result.MatchedDN)
+		} else {
+			lines = append(lines, "  [失败] "+row.Username+"："+row.Result.ErrDetail)
+		}
+	}
+	return lines
+}
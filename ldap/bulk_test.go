@@ -0,0 +1,43 @@
+package ldap
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestBulkCreateUsers 批量创建使用连接池中的多个连接并发创建用户，缺失的父容器只创建一次
+func TestBulkCreateUsers(t *testing.T) {
+	server := newFakeServer(t)
+	server.entries = map[string]bool{"dc=example,dc=com": true}
+	log, _ := captureLogger()
+	client := server.client(log)
+	client.SetUserTemplate(UserTemplates()[1])
+
+	var users []BulkUser
+	for i := 0; i < 20; i++ {
+		users = append(users, BulkUser{CN: fmt.Sprintf("user%d", i), Password: "Secret#1", OU: "sales"})
+	}
+	result, err := client.BulkCreateUsers(users, "dc=example,dc=com", false)
+	if err != nil {
+		t.Fatalf("批量创建失败: %v", err)
+	}
+	if result.Created != len(users) || result.Skipped != 0 || result.Failed != 0 {
+		t.Fatalf("结果 = %+v", result)
+	}
+
+	containers := 0
+	for _, dn := range server.addedDNs() {
+		if dn == "OU=sales,dc=example,dc=com" {
+			containers++
+		}
+	}
+	if containers != 1 {
+		t.Errorf("父容器创建了 %d 次，应为1次: %v", containers, server.addedDNs())
+	}
+	if accepted := server.accepted.Load(); accepted < 2 {
+		t.Errorf("只建立了 %d 个连接，批量创建应并发使用连接池", accepted)
+	}
+	if open := waitForOpenConnections(server, 0); open != 0 {
+		t.Errorf("批量创建结束后仍有 %d 个连接未关闭", open)
+	}
+}
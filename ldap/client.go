@@ -2,11 +2,14 @@
 package ldap
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"LdapTest/logger"
@@ -27,6 +30,9 @@ func SetSkipTLSVerify(skip bool) {
 	SkipTLSVerify = skip
 }
 
+// ErrOperationCanceled 表示操作已被用户取消
+var ErrOperationCanceled = errors.New("操作已取消")
+
 // LDAPClient 是LDAP客户端结构体
 type LDAPClient struct {
 	Host         string
@@ -38,12 +44,35 @@ type LDAPClient struct {
 	updateStatus func(string)
 	isSSLMode    bool
 	debugMode    bool
+	userTemplate UserTemplate
+	ctx          context.Context
+	shared       bool // 是否复用共享连接，由connMu保护
+
+	directoryType string // 自动检测到的目录类型，未检测时为空
+	searchScope   int    // 用户、组和条目搜索使用的范围
+	sizeLimit     int    // 搜索最大结果数，0表示不限制
+	timeLimit     int    // 搜索超时（秒），0表示不限制
+	bindMethod    string // 绑定方式，空值表示简单绑定
+	certLogged    bool   // 是否已记录服务器证书信息
+	config        LDAPConfig
+
+	followReferrals bool // 搜索返回引用时是否跟随
+	protocolDebug   bool // 是否输出协议报文
+	globalCatalog   bool // 是否连接全局编录（只读，部分属性集）
+	readOnly        bool // 只读模式，启用后拒绝所有写操作
+
+	confirmContainers func(missing []string) bool // 自动创建缺失容器前的确认，为nil时直接创建
+
+	pool          *ConnPool     // 连接池，启用后非共享模式的操作从池中获取连接，由connMu保护
+	connMu        sync.Mutex    // 保护shared、sharedUsers、pool和共享连接的检测、重连与关闭
+	sharedUsers   int           // 调用UseSharedConnection且尚未释放的次数
+	keepAliveStop chan struct{} // 保持连接的停止信号，未启用时为nil
 }
 
 // NewLDAPClient 创建新的LDAP客户端
 func NewLDAPClient(host string, port int, bindDN, bindPassword string, logger *logger.BaseLogger, updateFunc func(string), useTLS, debugMode bool) *LDAPClient {
 	return &LDAPClient{
-		Host:         host,
+		Host:         NormalizeHost(host),
 		Port:         port,
 		BindDN:       bindDN,
 		BindPassword: bindPassword,
@@ -51,27 +80,132 @@ func NewLDAPClient(host string, port int, bindDN, bindPassword string, logger *l
 		updateStatus: updateFunc,
 		isSSLMode:    useTLS,
 		debugMode:    debugMode,
+		userTemplate: DefaultUserTemplate(),
+		ctx:          context.Background(),
+		searchScope:  DefaultSearchScope,
+		config:       DefaultLDAPConfig(),
 	}
 }
 
+// SetContext 设置客户端的上下文，取消上下文时会中断进行中的连接
+func (client *LDAPClient) SetContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	client.ctx = ctx
+}
+
+// dial 使用可取消的拨号器连接LDAP服务器
+func (client *LDAPClient) dial() (*ldap.Conn, error) {
+	if client.ctx.Err() != nil {
+		return nil, ErrOperationCanceled
+	}
+
+	timeout := client.config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultLDAPConfig().Timeout
+	}
+
+	var tlsConfig *tls.Config
+	if client.isSSLMode {
+		tlsConfig = client.GetTLSConfig()
+		client.Debug("TLS配置详情：跳过验证=%v, 服务器名=%s", tlsConfig.InsecureSkipVerify, tlsConfig.ServerName)
+	}
+
+	conn, err := client.dialLDAP(client.Address(), tlsConfig, timeout)
+	if err != nil {
+		if client.ctx.Err() != nil {
+			return nil, ErrOperationCanceled
+		}
+		return nil, err
+	}
+
+	conn.Debug.Enable(client.protocolDebug)
+	// 请求超时与拨号超时使用相同的配置
+	conn.SetTimeout(timeout)
+
+	if client.isSSLMode {
+		client.logServerCertificate(conn)
+	}
+	return conn, nil
+}
+
+// dialContext 在客户端上下文中建立TCP连接，tlsConfig不为nil时完成TLS握手，取消上下文会中断拨号和握手
+func (client *LDAPClient) dialContext(address string, tlsConfig *tls.Config, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if tlsConfig == nil {
+		return dialer.DialContext(client.ctx, "tcp", address)
+	}
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+	return tlsDialer.DialContext(client.ctx, "tcp", address)
+}
+
+// dialLDAP 建立连接并启动LDAP消息处理，拨号失败时返回网络错误
+func (client *LDAPClient) dialLDAP(address string, tlsConfig *tls.Config, timeout time.Duration) (*ldap.Conn, error) {
+	netConn, err := client.dialContext(address, tlsConfig, timeout)
+	if err != nil {
+		return nil, ldap.NewError(ldap.ErrorNetwork, err)
+	}
+	conn := ldap.NewConn(netConn, tlsConfig != nil)
+	conn.Start()
+	return conn, nil
+}
+
+// closeOnCancel 当前操作的上下文取消时关闭conn，使阻塞中的请求尽快返回
+// 返回的函数停止监听，连接关闭、释放或归还时必须调用，避免连接被长期存在的上下文引用
+func (client *LDAPClient) closeOnCancel(conn *ldap.Conn) func() bool {
+	return context.AfterFunc(client.ctx, func() {
+		conn.Close()
+	})
+}
+
 // GetURL 返回LDAP服务器的URL
 func (client *LDAPClient) GetURL() string {
 	protocol := "ldap"
 	if client.isSSLMode {
 		protocol = "ldaps"
 	}
-	return protocol + "://" + client.Host + ":" + fmt.Sprintf("%d", client.Port)
+	return protocol + "://" + client.Address()
+}
+
+// IsSSL 返回客户端是否使用LDAPS连接
+func (client *LDAPClient) IsSSL() bool {
+	return client.isSSLMode
+}
+
+// Address 返回 host:port 形式的地址，IPv6地址会加上方括号
+func (client *LDAPClient) Address() string {
+	return net.JoinHostPort(client.Host, strconv.Itoa(client.Port))
+}
+
+// NormalizeHost 去除主机地址两端的空白和IPv6地址的方括号
+func NormalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	return host
 }
 
 // GetTLSConfig 获取TLS配置
 func (client *LDAPClient) GetTLSConfig() *tls.Config {
 	client.Debug("TLS配置：跳过验证=%v", SkipTLSVerify)
-	return &tls.Config{
+	config := &tls.Config{
 		InsecureSkipVerify: SkipTLSVerify,
 		ServerName:         client.Host,
 		MinVersion:         tls.VersionTLS12,
 		MaxVersion:         tls.VersionTLS13,
 	}
+	if RootCAs != nil {
+		// 配置了CA证书时始终验证服务器证书
+		client.Debug("使用CA证书验证服务器：%s", CAFile)
+		config.RootCAs = RootCAs
+		config.InsecureSkipVerify = false
+	}
+	if ClientCertificate != nil {
+		config.Certificates = []tls.Certificate{*ClientCertificate}
+	}
+	return config
 }
 
 // Connect 连接到LDAP服务器
@@ -79,22 +213,14 @@ func (client *LDAPClient) Connect() error {
 	client.Info("正在连接到 %s", client.GetURL())
 	client.Debug("TLS验证状态：%v", SkipTLSVerify)
 
-	address := fmt.Sprintf("%s:%d", client.Host, client.Port)
 	var err error
-
-	if client.isSSLMode {
-		tlsConfig := client.GetTLSConfig()
-		client.Debug("使用TLS配置：跳过验证=%v", tlsConfig.InsecureSkipVerify)
-		client.conn, err = ldap.DialURL("ldaps://"+address, ldap.DialWithTLSConfig(tlsConfig))
-	} else {
-		client.conn, err = ldap.DialURL("ldap://" + address)
-	}
-
+	client.conn, err = client.dial()
 	if err != nil {
-		return errors.New("连接LDAP服务器失败: " + err.Error())
+		if errors.Is(err, ErrOperationCanceled) {
+			return err
+		}
+		return fmt.Errorf("连接LDAP服务器失败: %w", err)
 	}
-
-	client.conn.SetTimeout(5 * time.Second)
 	return nil
 }
 
@@ -104,37 +230,121 @@ func (client *LDAPClient) Bind(bindDN, bindPassword string) error {
 		return errors.New("未连接到LDAP服务器")
 	}
 
-	return client.conn.Bind(bindDN, bindPassword)
+	return client.bindConn(client.conn, bindDN, bindPassword)
 }
 
 // BindWithRetry 带重试的绑定操作
 func (client *LDAPClient) BindWithRetry(bindDN, bindPassword string) error {
-	const maxRetries = 3
+	maxRetries := client.config.MaxRetries
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
+		if client.ctx.Err() != nil {
+			return ErrOperationCanceled
+		}
+
+		// 线性退避：第N次重试前等待 RetryDelay * N
+		if attempt > 1 {
+			delay := client.config.RetryDelay * time.Duration(attempt-1)
+			client.Debug("等待 %v 后重试", delay)
+			select {
+			case <-time.After(delay):
+			case <-client.ctx.Done():
+				return ErrOperationCanceled
+			}
+		}
+
 		if err := client.ensureConnection(); err != nil {
 			lastErr = err
-			client.Error("连接失败 (尝试 %d/3): %v", attempt, err)
+			client.Error("连接失败 (尝试 %d/%d): %v", attempt, maxRetries, err)
 			continue
 		}
 
-		if err := client.conn.Bind(bindDN, bindPassword); err != nil {
+		if err := client.bindConn(client.conn, bindDN, bindPassword); err != nil {
 			lastErr = err
-			client.Error("绑定失败 (尝试 %d/3): %v", attempt, err)
+			client.Error("绑定失败 (尝试 %d/%d): %s", attempt, maxRetries, ParseLDAPError(err))
 
-			if ldapErr, ok := err.(*ldap.Error); ok && ldapErr.ResultCode == 200 {
-				client.Close()
-				continue
+			// 凭据错误或权限不足，重试也不会成功
+			if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) ||
+				ldap.IsErrorWithCode(err, ldap.LDAPResultInsufficientAccessRights) {
+				return err
 			}
 
-			return err
+			// 关闭连接，下次尝试重新连接
+			client.Close()
+			continue
 		}
 
 		return nil
 	}
 
-	return errors.New("绑定失败，已重试3次: " + lastErr.Error())
+	return fmt.Errorf("绑定失败，已重试%d次: %v", maxRetries, lastErr)
+}
+
+// isRetryableError 判断修改类操作失败后是否值得重试：网络中断、服务器忙或暂不可用
+// 对象已存在、权限不足等服务器明确拒绝的错误重试也不会成功，不在此列
+func isRetryableError(err error) bool {
+	return isNetworkError(err) ||
+		ldap.IsErrorWithCode(err, ldap.LDAPResultBusy) ||
+		ldap.IsErrorWithCode(err, ldap.LDAPResultUnavailable)
+}
+
+// doWithRetry 获取连接并执行fn，遇到网络错误时丢弃连接、重新连接并绑定后重试，最多MaxRetries次
+// fn应只包含一次完整的操作（可先读取再修改），返回的错误原样交给调用者处理
+// 网络中断时请求可能已经生效，因此重试Add返回对象已存在时会记录警告
+func (client *LDAPClient) doWithRetry(fn func(*ldap.Conn) error) error {
+	maxRetries := client.config.MaxRetries
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
+	var lastErr error
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		if client.ctx.Err() != nil {
+			return ErrOperationCanceled
+		}
+
+		if attempt > 1 {
+			delay := client.config.RetryDelay * time.Duration(attempt-1)
+			client.Debug("等待 %v 后重试", delay)
+			select {
+			case <-time.After(delay):
+			case <-client.ctx.Done():
+				return ErrOperationCanceled
+			}
+		}
+
+		conn, release, err := client.acquireConnection()
+		if err != nil {
+			lastErr = fmt.Errorf("连接失败: %v", err)
+			if !isRetryableError(err) {
+				return lastErr
+			}
+			client.Warn("连接失败 (尝试 %d/%d): %v", attempt, maxRetries, err)
+			continue
+		}
+
+		err = fn(conn)
+		if isNetworkError(err) {
+			// 关闭失效的连接：共享连接和连接池在下次获取时会重新连接并绑定
+			conn.Close()
+		}
+		release()
+
+		if err == nil || !isRetryableError(err) {
+			if attempt > 1 && ldap.IsErrorWithCode(err, ldap.LDAPResultEntryAlreadyExists) {
+				client.Warn("重试后返回对象已存在，之前中断的请求可能已经生效")
+			}
+			return err
+		}
+		lastErr = err
+		client.Warn("操作失败 (尝试 %d/%d)，将重新连接后重试: %s", attempt, maxRetries, ParseLDAPError(err))
+	}
+
+	return lastErr
 }
 
 // ensureConnection 确保连接有效
@@ -166,13 +376,13 @@ func (client *LDAPClient) EnsureConnection() error {
 
 	if err := client.Connect(); err != nil {
 		client.Error("重新连接失败: %v", err)
-		return errors.New("重新连接失败: " + err.Error())
+		return fmt.Errorf("重新连接失败: %w", err)
 	}
 
-	if client.BindDN != "" && client.BindPassword != "" {
+	if client.hasBindCredentials() {
 		if err := client.Bind(client.BindDN, client.BindPassword); err != nil {
 			client.Error("重新绑定失败: %v", err)
-			return errors.New("重新绑定失败: " + err.Error())
+			return fmt.Errorf("重新绑定失败: %w", err)
 		}
 	}
 
@@ -185,7 +395,11 @@ func (client *LDAPClient) IsConnectionValid() bool {
 	if client.conn == nil {
 		return false
 	}
+	return client.isConnAlive(client.conn)
+}
 
+// isConnAlive 通过读取RootDSE检查指定连接是否可用
+func (client *LDAPClient) isConnAlive(conn *ldap.Conn) bool {
 	searchRequest := ldap.NewSearchRequest(
 		"",
 		ldap.ScopeBaseObject,
@@ -198,10 +412,12 @@ func (client *LDAPClient) IsConnectionValid() bool {
 		nil,
 	)
 
-	_, err := client.conn.Search(searchRequest)
+	_, err := conn.Search(searchRequest)
 	if err != nil {
-		if ldapErr, ok := err.(*ldap.Error); ok && ldapErr.ResultCode == 200 {
-			return false
+		if isNetworkError(err) {
+			client.Debug("连接已断开：%v", err)
+		} else {
+			client.Debug("连接检测失败：%v", err)
 		}
 		return false
 	}
@@ -219,17 +435,57 @@ func (client *LDAPClient) Close() {
 
 // IsPortOpen 检查LDAP端口是否开放
 func (client *LDAPClient) IsPortOpen() bool {
-	address := net.JoinHostPort(client.Host, fmt.Sprintf("%d", client.Port))
-	client.Debug("正在检查端口 %s 是否开放", address)
-	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
-	if err != nil {
+	client.Debug("正在检查端口 %s 是否开放", client.Address())
+	if err := client.dialPort(client.Port); err != nil {
 		client.Warn("端口 %d 未开放: %v", client.Port, err)
 		return false
 	}
-	conn.Close()
 	return true
 }
 
+// dialPort 尝试与服务器的指定端口建立TCP连接，成功后立即关闭
+func (client *LDAPClient) dialPort(port int) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(client.Host, strconv.Itoa(port)), 5*time.Second)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+// Reachable 通过多次TCP连接测试服务器可达性，返回平均连接延迟
+func (client *LDAPClient) Reachable() (time.Duration, error) {
+	const attempts = 3
+	address := client.Address()
+	client.Debug("正在测试 %s 的TCP可达性", address)
+
+	var total time.Duration
+	succeeded := 0
+	var lastErr error
+	for i := 1; i <= attempts; i++ {
+		start := time.Now()
+		conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+		if err != nil {
+			lastErr = err
+			client.Debug("第 %d 次连接失败: %v", i, err)
+			continue
+		}
+		elapsed := time.Since(start)
+		conn.Close()
+		client.Debug("第 %d 次连接耗时: %v", i, elapsed)
+		total += elapsed
+		succeeded++
+	}
+
+	if succeeded == 0 {
+		return 0, errors.New("无法连接到 " + address + ": " + lastErr.Error())
+	}
+	if succeeded < attempts {
+		client.Warn("%d 次连接中有 %d 次失败", attempts, attempts-succeeded)
+	}
+	return total / time.Duration(succeeded), nil
+}
+
 // TestLDAPService 测试LDAP服务是否正常
 func (client *LDAPClient) TestLDAPService() bool {
 	client.Debug("正在测试LDAP服务")
@@ -239,11 +495,12 @@ func (client *LDAPClient) TestLDAPService() bool {
 		return false
 	}
 	defer conn.Close()
+	defer client.closeOnCancel(conn)()
 
 	// 尝试绑定
-	err = conn.Bind(client.BindDN, client.BindPassword)
+	err = client.bindConn(conn, client.BindDN, client.BindPassword)
 	if err != nil {
-		client.Error("LDAP绑定失败: %v", err)
+		client.Error("LDAP绑定失败: %s", ParseLDAPError(err))
 		return false
 	}
 
@@ -251,40 +508,90 @@ func (client *LDAPClient) TestLDAPService() bool {
 	return true
 }
 
-// GetConnection 获取LDAP连接
+// TestAnonymousAccess 使用匿名绑定读取基准条目，验证服务器是否允许匿名访问
+// baseDN为空时从RootDSE获取命名上下文，返回实际读取的基准DN
+func (client *LDAPClient) TestAnonymousAccess(baseDN string) (string, error) {
+	client.Debug("正在测试匿名访问")
+	conn, err := client.dial()
+	if err != nil {
+		return "", fmt.Errorf("连接失败: %v", err)
+	}
+	defer conn.Close()
+	defer client.closeOnCancel(conn)()
+
+	if err := conn.UnauthenticatedBind(""); err != nil {
+		return "", fmt.Errorf("匿名绑定被拒绝: %s", ParseLDAPError(err))
+	}
+	client.Debug("匿名绑定成功")
+
+	if baseDN == "" {
+		entry, err := readEntry(conn, "", []string{"defaultNamingContext", "namingContexts"})
+		if err != nil {
+			return "", fmt.Errorf("匿名读取RootDSE失败: %s", ParseLDAPError(err))
+		}
+		baseDN = entry.GetAttributeValue("defaultNamingContext")
+		if baseDN == "" {
+			baseDN = entry.GetAttributeValue("namingContexts")
+		}
+		if baseDN == "" {
+			return "", fmt.Errorf("RootDSE可匿名读取，但未返回命名上下文")
+		}
+	}
+
+	if _, err := readEntry(conn, baseDN, []string{"objectClass"}); err != nil {
+		return baseDN, fmt.Errorf("匿名搜索 %s 失败: %s", baseDN, ParseLDAPError(err))
+	}
+	client.Info("匿名搜索成功：%s", baseDN)
+	return baseDN, nil
+}
+
+// WhoAmI 使用"Who Am I?"扩展操作（RFC 4532）返回当前绑定的身份（authzId）
+func (client *LDAPClient) WhoAmI() (string, error) {
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return "", fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	result, err := conn.WhoAmI(nil)
+	if err != nil {
+		return "", fmt.Errorf("WhoAmI操作失败: %s", ParseLDAPError(err))
+	}
+	return result.AuthzID, nil
+}
+
+// GetConnection 获取一个新的LDAP连接，调用者负责关闭返回的连接
+// 库内部的操作应使用acquireConnection，以便复用共享连接并保证释放
 func (client *LDAPClient) GetConnection() (*ldap.Conn, error) {
-	client.Debug("尝试连接到 %s:%d", client.Host, client.Port)
+	client.Debug("尝试连接到 %s", client.Address())
 	client.Debug("TLS验证状态：%v", SkipTLSVerify)
 
-	var l *ldap.Conn
-	var err error
-
 	if client.isSSLMode {
 		client.Debug("使用TLS连接")
-		tlsConfig := client.GetTLSConfig()
-		client.Debug("TLS配置详情：跳过验证=%v, 服务器名=%s", tlsConfig.InsecureSkipVerify, tlsConfig.ServerName)
-		l, err = ldap.DialURL(fmt.Sprintf("ldaps://%s:%d", client.Host, client.Port), ldap.DialWithTLSConfig(tlsConfig))
 	} else {
 		client.Debug("使用标准连接")
-		l, err = ldap.DialURL(fmt.Sprintf("ldap://%s:%d", client.Host, client.Port))
 	}
 
+	l, err := client.dial()
 	if err != nil {
+		if errors.Is(err, ErrOperationCanceled) {
+			return nil, err
+		}
 		client.Error("连接失败：%v", err)
 		if strings.Contains(err.Error(), "certificate signed by unknown authority") {
 			client.Debug("检测到证书验证错误，当前TLS验证状态：%v", SkipTLSVerify)
 			return nil, errors.New("SSL证书验证失败：证书由未知机构签名\n请检查证书是否有效，或考虑跳过TLS验证")
 		}
-		return nil, errors.New("LDAP连接失败: " + err.Error())
+		return nil, fmt.Errorf("LDAP连接失败: %w", err)
 	}
 
 	// 如果提供了凭证，尝试绑定
-	if client.BindDN != "" && client.BindPassword != "" {
+	if client.hasBindCredentials() {
 		client.Debug("尝试使用提供的凭证绑定")
-		if err := l.Bind(client.BindDN, client.BindPassword); err != nil {
-			client.Error("绑定失败：%v", err)
+		if err := client.bindConn(l, client.BindDN, client.BindPassword); err != nil {
+			client.Error("绑定失败：%s", ParseLDAPError(err))
 			l.Close()
-			return nil, errors.New("LDAP绑定失败: " + err.Error())
+			return nil, describeError("LDAP绑定失败: "+ParseLDAPError(err), err)
 		}
 		client.Debug("绑定成功")
 	}
@@ -292,12 +599,90 @@ func (client *LDAPClient) GetConnection() (*ldap.Conn, error) {
 	return l, nil
 }
 
-// Shutdown 关闭LDAP连接并释放资源
+// UseSharedConnection 建立一个已绑定的共享连接，之后的操作都复用它，直到调用ReleaseSharedConnection
+// 可被多个并发的操作调用，每次调用都必须对应一次ReleaseSharedConnection
+func (client *LDAPClient) UseSharedConnection() error {
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	if err := client.EnsureConnection(); err != nil {
+		return err
+	}
+	client.sharedUsers++
+	client.shared = true
+	client.Debug("已启用共享连接（使用者：%d）", client.sharedUsers)
+	return nil
+}
+
+// ReleaseSharedConnection 释放一次共享连接的使用，最后一个使用者释放后关闭共享连接，之后的操作恢复为每次单独连接
+func (client *LDAPClient) ReleaseSharedConnection() {
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	if client.sharedUsers == 0 {
+		return
+	}
+	client.sharedUsers--
+	// 仍有其他使用者，或保持连接期间共享连接由后台检测负责管理
+	if client.sharedUsers > 0 || client.keepAliveStop != nil {
+		return
+	}
+	client.shared = false
+	client.Close()
+	client.Debug("已释放共享连接")
+}
+
+// acquireConnection 获取可用连接：存在共享连接时复用，否则新建连接
+// 返回的release函数只会关闭新建的连接
+func (client *LDAPClient) acquireConnection() (*ldap.Conn, func(), error) {
+	client.connMu.Lock()
+	if client.shared {
+		defer client.connMu.Unlock()
+		if err := client.EnsureConnection(); err != nil {
+			return nil, nil, err
+		}
+		stop := client.closeOnCancel(client.conn)
+		return client.conn, func() { stop() }, nil
+	}
+	pool := client.pool
+	client.connMu.Unlock()
+
+	if pool != nil {
+		conn, err := pool.Get()
+		if err != nil {
+			return nil, nil, err
+		}
+		stop := client.closeOnCancel(conn)
+		return conn, func() {
+			stop()
+			pool.Put(conn)
+		}, nil
+	}
+
+	conn, err := client.GetConnection()
+	if err != nil {
+		return nil, nil, err
+	}
+	stop := client.closeOnCancel(conn)
+	return conn, func() {
+		stop()
+		conn.Close()
+	}, nil
+}
+
+// Shutdown 停止保持连接，关闭连接池和共享连接并释放资源
+// 共享连接仍有使用者时不立即关闭，由最后一个使用者释放时关闭，客户端之后仍可用于单独连接的操作
 func (client *LDAPClient) Shutdown() {
+	client.StopKeepAlive()
+	client.ReleaseConnPool()
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	if client.sharedUsers > 0 {
+		return
+	}
+	client.shared = false
 	client.Close()
 }
 
-// TestServiceConnection 测试LDAP服务连接（不包括绑定验证）
+// TestServiceConnection 测试LDAP服务连接（不包括绑定验证），调用者负责关闭返回的连接
 func (client *LDAPClient) TestServiceConnection() (*ldap.Conn, error) {
 	client.Debug("正在测试LDAP服务连接")
 	conn, err := client.GetConnection()
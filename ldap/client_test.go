@@ -0,0 +1,292 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// TestCancelDoesNotCloseReleasedConnection 操作结束后取消其上下文，不应关闭之后仍在复用的共享连接
+func TestCancelDoesNotCloseReleasedConnection(t *testing.T) {
+	server := newFakeServer(t)
+	log, _ := captureLogger()
+	client := server.client(log)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.SetContext(ctx)
+	if err := client.UseSharedConnection(); err != nil {
+		t.Fatalf("建立共享连接失败: %v", err)
+	}
+	defer client.Shutdown()
+
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		t.Fatalf("获取连接失败: %v", err)
+	}
+	release()
+
+	client.SetContext(context.Background())
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if conn.IsClosing() {
+		t.Fatal("取消已结束操作的上下文关闭了共享连接")
+	}
+}
+
+// TestCancelClosesConnectionInUse 操作进行中取消上下文应关闭其连接
+func TestCancelClosesConnectionInUse(t *testing.T) {
+	server := newFakeServer(t)
+	log, _ := captureLogger()
+	client := server.client(log)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.SetContext(ctx)
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		t.Fatalf("获取连接失败: %v", err)
+	}
+	defer release()
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for !conn.IsClosing() {
+		if time.Now().After(deadline) {
+			t.Fatal("取消上下文后连接未关闭")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// unusedPort 返回一个当前没有监听的本机端口
+func unusedPort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("获取空闲端口失败: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	return port
+}
+
+// TestConnectionErrorsAreRetryable 连接失败返回的错误应保留底层网络错误，才能被doWithRetry重试
+func TestConnectionErrorsAreRetryable(t *testing.T) {
+	log, _ := captureLogger()
+	client := NewLDAPClient("127.0.0.1", unusedPort(t), "cn=admin", "secret", log, nil, false, true)
+
+	if _, err := client.GetConnection(); !isRetryableError(err) {
+		t.Errorf("GetConnection的错误应可重试: %v", err)
+	}
+	if err := client.Connect(); !isRetryableError(err) {
+		t.Errorf("Connect的错误应可重试: %v", err)
+	}
+	if err := client.EnsureConnection(); !isRetryableError(err) {
+		t.Errorf("EnsureConnection的错误应可重试: %v", err)
+	}
+}
+
+// TestDoWithRetry 连接失败和操作中的网络错误都会重试，其他错误直接返回
+func TestDoWithRetry(t *testing.T) {
+	config := DefaultLDAPConfig()
+	config.MaxRetries = 3
+	config.RetryDelay = time.Millisecond
+
+	t.Run("连接失败", func(t *testing.T) {
+		log, output := captureLogger()
+		client := NewLDAPClient("127.0.0.1", unusedPort(t), "cn=admin", "secret", log, nil, false, true)
+		client.SetConfig(config)
+		calls := 0
+		err := client.doWithRetry(func(*ldap.Conn) error {
+			calls++
+			return nil
+		})
+		if err == nil || calls != 0 {
+			t.Fatalf("err = %v, calls = %d", err, calls)
+		}
+		if !strings.Contains(output(), "尝试 3/3") {
+			t.Error("连接失败后未重试到最大次数")
+		}
+	})
+
+	t.Run("网络错误后成功", func(t *testing.T) {
+		server := newFakeServer(t)
+		log, _ := captureLogger()
+		client := server.client(log)
+		client.SetConfig(config)
+		calls := 0
+		err := client.doWithRetry(func(*ldap.Conn) error {
+			calls++
+			if calls == 1 {
+				return ldap.NewError(ldap.ErrorNetwork, io.EOF)
+			}
+			return nil
+		})
+		if err != nil || calls != 2 {
+			t.Fatalf("err = %v, calls = %d", err, calls)
+		}
+	})
+
+	t.Run("不可重试的错误", func(t *testing.T) {
+		server := newFakeServer(t)
+		log, _ := captureLogger()
+		client := server.client(log)
+		client.SetConfig(config)
+		calls := 0
+		err := client.doWithRetry(func(*ldap.Conn) error {
+			calls++
+			return ldap.NewError(ldap.LDAPResultInsufficientAccessRights, errors.New("拒绝访问"))
+		})
+		if !ldap.IsErrorWithCode(err, ldap.LDAPResultInsufficientAccessRights) || calls != 1 {
+			t.Fatalf("err = %v, calls = %d", err, calls)
+		}
+	})
+}
+
+// TestConfigTimeoutAppliesToRequests 配置的超时同时作用于请求，服务器不响应时请求应按配置超时返回
+func TestConfigTimeoutAppliesToRequests(t *testing.T) {
+	server := newFakeServer(t)
+	server.silent.Store(true)
+	log, _ := captureLogger()
+	client := server.client(log)
+	config := DefaultLDAPConfig()
+	config.Timeout = 100 * time.Millisecond
+	client.SetConfig(config)
+
+	start := time.Now()
+	if _, err := client.GetConnection(); err == nil {
+		t.Fatal("服务器不响应时绑定应失败")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("请求耗时 %v，未使用配置的超时", elapsed)
+	}
+
+	start = time.Now()
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect失败: %v", err)
+	}
+	defer client.Close()
+	if err := client.Bind(client.BindDN, client.BindPassword); err == nil {
+		t.Fatal("服务器不响应时绑定应失败")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("共享连接的请求耗时 %v，未使用配置的超时", elapsed)
+	}
+}
+
+// TestSharedConnectionRefCount 共享连接在最后一个使用者释放后才关闭，并发使用时不应出现数据竞争
+func TestSharedConnectionRefCount(t *testing.T) {
+	server := newFakeServer(t)
+	log, _ := captureLogger()
+	client := server.client(log)
+
+	if err := client.UseSharedConnection(); err != nil {
+		t.Fatalf("建立共享连接失败: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.UseSharedConnection(); err != nil {
+				t.Errorf("建立共享连接失败: %v", err)
+				return
+			}
+			defer client.ReleaseSharedConnection()
+			if _, err := client.WhoAmI(); err != nil {
+				t.Errorf("WhoAmI失败: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		t.Fatalf("获取连接失败: %v", err)
+	}
+	release()
+	if conn.IsClosing() {
+		t.Fatal("仍有使用者时共享连接被关闭")
+	}
+	if accepted := server.accepted.Load(); accepted != 1 {
+		t.Errorf("建立了 %d 个连接，共享连接应只建立1个", accepted)
+	}
+
+	client.ReleaseSharedConnection()
+	if !conn.IsClosing() {
+		t.Error("最后一个使用者释放后共享连接未关闭")
+	}
+	client.ReleaseSharedConnection()
+}
+
+// TestShutdownWaitsForSharedUsers 共享连接仍在使用时Shutdown不应关闭它，最后一个使用者释放后才关闭
+func TestShutdownWaitsForSharedUsers(t *testing.T) {
+	server := newFakeServer(t)
+	log, _ := captureLogger()
+	client := server.client(log)
+
+	if err := client.UseSharedConnection(); err != nil {
+		t.Fatalf("建立共享连接失败: %v", err)
+	}
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		t.Fatalf("获取连接失败: %v", err)
+	}
+	release()
+
+	client.Shutdown()
+	if conn.IsClosing() {
+		t.Fatal("仍有使用者时Shutdown关闭了共享连接")
+	}
+	if _, err := client.WhoAmI(); err != nil {
+		t.Fatalf("Shutdown后使用共享连接失败: %v", err)
+	}
+
+	client.ReleaseSharedConnection()
+	if !conn.IsClosing() {
+		t.Error("最后一个使用者释放后共享连接未关闭")
+	}
+	if _, err := client.WhoAmI(); err != nil {
+		t.Errorf("共享连接关闭后单独连接的操作失败: %v", err)
+	}
+}
+
+// TestConnPoolConcurrentRelease 操作进行中释放连接池不应出现数据竞争，之后的操作恢复为单独连接（使用-race运行）
+func TestConnPoolConcurrentRelease(t *testing.T) {
+	server := newFakeServer(t)
+	log, _ := captureLogger()
+	client := server.client(log)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				client.UseConnPool(2)
+				client.ReleaseConnPool()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				// 连接池在借出后被关闭时返回ErrPoolClosed，其余操作应成功
+				if _, err := client.WhoAmI(); err != nil && !errors.Is(err, ErrPoolClosed) {
+					t.Errorf("WhoAmI失败: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := client.WhoAmI(); err != nil {
+		t.Fatalf("释放连接池后WhoAmI失败: %v", err)
+	}
+}
@@ -10,3 +10,17 @@ type LDAPConfig struct {
 	UseTLS     bool
 	SkipVerify bool
 }
+
+// DefaultLDAPConfig 返回默认配置：重试3次，每次间隔RetryDelay*尝试次数
+func DefaultLDAPConfig() LDAPConfig {
+	return LDAPConfig{
+		Timeout:    10 * time.Second,
+		MaxRetries: 3,
+		RetryDelay: 500 * time.Millisecond,
+	}
+}
+
+// SetConfig 设置客户端的重试和超时配置
+func (client *LDAPClient) SetConfig(config LDAPConfig) {
+	client.config = config
+}
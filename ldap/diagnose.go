@@ -0,0 +1,158 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// 连接诊断的各个阶段
+const (
+	StageDNS       = "DNS解析"
+	StageTCP       = "TCP连接"
+	StageTLS       = "TLS握手"
+	StageAnonymous = "匿名绑定"
+	StageBind      = "认证绑定"
+)
+
+// errStageSkipped 前一阶段失败导致后续阶段无法进行
+var errStageSkipped = errors.New("前一阶段失败，未执行")
+
+// StageResult 单个诊断阶段的结果
+type StageResult struct {
+	Name    string
+	Skipped bool   // 是否跳过（未启用或前一阶段失败）
+	Detail  string // 成功或跳过时的说明
+	Err     error  // 失败原因
+}
+
+// ConnectionDiagnosis 分阶段的连接诊断结果
+type ConnectionDiagnosis struct {
+	Stages []StageResult
+}
+
+// add 记录一个阶段的结果
+func (d *ConnectionDiagnosis) add(name string, detail string, err error) {
+	d.Stages = append(d.Stages, StageResult{Name: name, Detail: detail, Err: err})
+}
+
+// skip 记录一个跳过的阶段
+func (d *ConnectionDiagnosis) skip(name string, reason string) {
+	d.Stages = append(d.Stages, StageResult{Name: name, Skipped: true, Detail: reason})
+}
+
+// skipRest 前一阶段失败时将剩余阶段标记为跳过
+func (d *ConnectionDiagnosis) skipRest(names ...string) {
+	for _, name := range names {
+		d.skip(name, errStageSkipped.Error())
+	}
+}
+
+// Passed 返回所有执行过的阶段是否都成功
+func (d *ConnectionDiagnosis) Passed() bool {
+	for _, stage := range d.Stages {
+		if !stage.Skipped && stage.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary 以检查清单的形式返回诊断结果，每个阶段一行
+func (d *ConnectionDiagnosis) Summary() []string {
+	lines := make([]string, 0, len(d.Stages))
+	for _, stage := range d.Stages {
+		switch {
+		case stage.Skipped:
+			lines = append(lines, fmt.Sprintf("[跳过] %s：%s", stage.Name, stage.Detail))
+		case stage.Err != nil:
+			lines = append(lines, fmt.Sprintf("[失败] %s：%s", stage.Name, ParseLDAPError(stage.Err)))
+		default:
+			lines = append(lines, fmt.Sprintf("[成功] %s：%s", stage.Name, stage.Detail))
+		}
+	}
+	return lines
+}
+
+// Diagnose 依次检测DNS解析、TCP连接、TLS握手、匿名绑定和认证绑定，区分每一层的故障
+func (client *LDAPClient) Diagnose() *ConnectionDiagnosis {
+	diagnosis := &ConnectionDiagnosis{}
+	ctx := client.ctx
+	timeout := client.config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultLDAPConfig().Timeout
+	}
+
+	// DNS解析
+	if ip := net.ParseIP(client.Host); ip != nil {
+		diagnosis.add(StageDNS, "地址为IP，无需解析", nil)
+	} else {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, client.Host)
+		if err != nil {
+			diagnosis.add(StageDNS, "", err)
+			diagnosis.skipRest(StageTCP, StageTLS, StageAnonymous, StageBind)
+			return diagnosis
+		}
+		diagnosis.add(StageDNS, strings.Join(addrs, ", "), nil)
+	}
+
+	// TCP连接
+	address := client.Address()
+	dialer := &net.Dialer{Timeout: timeout}
+	rawConn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		diagnosis.add(StageTCP, "", err)
+		diagnosis.skipRest(StageTLS, StageAnonymous, StageBind)
+		return diagnosis
+	}
+	diagnosis.add(StageTCP, address, nil)
+
+	// TLS握手
+	netConn := rawConn
+	if client.isSSLMode {
+		tlsConn := tls.Client(rawConn, client.GetTLSConfig())
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			rawConn.Close()
+			diagnosis.add(StageTLS, "", err)
+			diagnosis.skipRest(StageAnonymous, StageBind)
+			return diagnosis
+		}
+		state := tlsConn.ConnectionState()
+		diagnosis.add(StageTLS, tls.VersionName(state.Version)+" "+tls.CipherSuiteName(state.CipherSuite), nil)
+		netConn = tlsConn
+	} else {
+		diagnosis.skip(StageTLS, "未启用SSL")
+	}
+
+	conn := ldap.NewConn(netConn, client.isSSLMode)
+	conn.Debug.Enable(client.protocolDebug)
+	conn.Start()
+	defer conn.Close()
+
+	// 匿名绑定失败不影响认证绑定，服务器可能禁止匿名访问
+	if err := conn.UnauthenticatedBind(""); err != nil {
+		diagnosis.add(StageAnonymous, "", err)
+	} else {
+		diagnosis.add(StageAnonymous, "服务器接受匿名绑定", nil)
+	}
+
+	// 认证绑定
+	if !client.hasBindCredentials() {
+		diagnosis.skip(StageBind, "未提供凭据")
+		return diagnosis
+	}
+	if err := client.bindConn(conn, client.BindDN, client.BindPassword); err != nil {
+		diagnosis.add(StageBind, "", err)
+		return diagnosis
+	}
+	detail := client.BindDN
+	if client.bindMethod != "" && client.bindMethod != BindMethodSimple {
+		detail = "SASL " + client.bindMethod
+	}
+	diagnosis.add(StageBind, detail, nil)
+	return diagnosis
+}
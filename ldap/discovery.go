@@ -0,0 +1,43 @@
+package ldap
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// DiscoverLDAPServers 通过DNS SRV记录 _ldap._tcp.<domain> 查找域控制器，按优先级升序、权重降序排列
+func DiscoverLDAPServers(domain string) ([]string, error) {
+	domain = strings.TrimSuffix(strings.TrimSpace(domain), ".")
+	if domain == "" {
+		return nil, fmt.Errorf("域名不能为空")
+	}
+
+	_, records, err := net.LookupSRV("ldap", "tcp", domain)
+	if err != nil {
+		return nil, fmt.Errorf("查询SRV记录失败: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("未找到 _ldap._tcp.%s 的SRV记录", domain)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Priority != records[j].Priority {
+			return records[i].Priority < records[j].Priority
+		}
+		return records[i].Weight > records[j].Weight
+	})
+
+	servers := make([]string, 0, len(records))
+	seen := make(map[string]bool, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		servers = append(servers, host)
+	}
+	return servers, nil
+}
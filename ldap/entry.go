@@ -0,0 +1,152 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// timeAttributes 以FILETIME或GeneralizedTime格式存储的时间属性
+var timeAttributes = map[string]bool{
+	"pwdlastset":         true,
+	"accountexpires":     true,
+	"lastlogon":          true,
+	"lastlogontimestamp": true,
+	"lastlogoff":         true,
+	"lockouttime":        true,
+	"badpasswordtime":    true,
+	"whencreated":        true,
+	"whenchanged":        true,
+	"createtimestamp":    true,
+	"modifytimestamp":    true,
+}
+
+// GetEntry 读取单个条目的属性，attrs为空时返回所有用户属性
+func (client *LDAPClient) GetEntry(dn string, attrs []string) (*ldap.Entry, error) {
+	if len(attrs) == 0 {
+		attrs = []string{"*"}
+	}
+	client.Debug("读取条目：%s，属性：%v", dn, attrs)
+
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	entry, err := readEntry(conn, dn, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("读取条目失败: %s", ParseLDAPError(err))
+	}
+	return entry, nil
+}
+
+// DecodeGUID 将AD的objectGUID（前三段为小端序）转换为标准字符串形式，长度不正确时返回空字符串
+func DecodeGUID(raw []byte) string {
+	if len(raw) != 16 {
+		return ""
+	}
+	return fmt.Sprintf("%02x%02x%02x%02x-%02x%02x-%02x%02x-%02x%02x-%x",
+		raw[3], raw[2], raw[1], raw[0],
+		raw[5], raw[4],
+		raw[7], raw[6],
+		raw[8], raw[9],
+		raw[10:])
+}
+
+// EncodeGUID 将标准字符串形式的GUID（可带花括号）转换为AD的objectGUID二进制值
+func EncodeGUID(guid string) ([]byte, error) {
+	text := strings.Trim(strings.TrimSpace(guid), "{}")
+	groups := strings.Split(text, "-")
+	if len(groups) != 5 || len(groups[0]) != 8 || len(groups[1]) != 4 || len(groups[2]) != 4 || len(groups[3]) != 4 || len(groups[4]) != 12 {
+		return nil, fmt.Errorf("GUID格式无效: %s", guid)
+	}
+	decoded, err := hex.DecodeString(strings.Join(groups, ""))
+	if err != nil {
+		return nil, fmt.Errorf("GUID格式无效: %s", guid)
+	}
+
+	// 前三段在objectGUID中为小端序
+	raw := make([]byte, 16)
+	raw[0], raw[1], raw[2], raw[3] = decoded[3], decoded[2], decoded[1], decoded[0]
+	raw[4], raw[5] = decoded[5], decoded[4]
+	raw[6], raw[7] = decoded[7], decoded[6]
+	copy(raw[8:], decoded[8:])
+	return raw, nil
+}
+
+// EncodeGUIDForFilter 将GUID转换为过滤器中使用的转义形式（\xx\xx...）
+func EncodeGUIDForFilter(guid string) (string, error) {
+	raw, err := EncodeGUID(guid)
+	if err != nil {
+		return "", err
+	}
+	return escapeBinaryForFilter(raw), nil
+}
+
+// FindByObjectID 按objectGUID或objectSid在baseDN下查找条目，id为GUID或"S-1-..."形式的SID
+func (client *LDAPClient) FindByObjectID(id string, baseDN string) (string, error) {
+	id = strings.TrimSpace(id)
+	var filter string
+	if strings.HasPrefix(strings.ToUpper(id), "S-") {
+		encoded, err := EncodeSIDForFilter(id)
+		if err != nil {
+			return "", err
+		}
+		filter = "(objectSid=" + encoded + ")"
+	} else {
+		encoded, err := EncodeGUIDForFilter(id)
+		if err != nil {
+			return "", err
+		}
+		filter = "(objectGUID=" + encoded + ")"
+	}
+
+	result, err := client.SearchEntries(baseDN, filter, []string{"1.1"}, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(result.Entries) == 0 {
+		return "", fmt.Errorf("未找到对象: %s", id)
+	}
+	return result.Entries[0].DN, nil
+}
+
+// DescribeAttributeValues 返回属性便于阅读的值，解码objectSid、objectGUID和时间属性，隐藏密码属性
+func DescribeAttributeValues(attr *ldap.EntryAttribute) []string {
+	name := strings.ToLower(attr.Name)
+	switch {
+	case IsPasswordAttribute(name):
+		return maskSensitive(name, attr.Values)
+	case name == "objectguid":
+		values := make([]string, 0, len(attr.ByteValues))
+		for _, raw := range attr.ByteValues {
+			if guid := DecodeGUID(raw); guid != "" {
+				values = append(values, guid)
+			} else {
+				values = append(values, fmt.Sprintf("%x", raw))
+			}
+		}
+		return values
+	case timeAttributes[name]:
+		values := make([]string, 0, len(attr.Values))
+		for _, value := range attr.Values {
+			values = append(values, value+"（"+FormatADTime(value)+"）")
+		}
+		return values
+	}
+	return AttributeDisplayValues(attr)
+}
+
+// EntryDump 返回条目所有属性的可读描述，每个值一行
+func EntryDump(entry *ldap.Entry) []string {
+	lines := []string{"DN：" + entry.DN}
+	for _, attr := range entry.Attributes {
+		for _, value := range DescribeAttributeValues(attr) {
+			lines = append(lines, "  "+attr.Name+": "+value)
+		}
+	}
+	return lines
+}
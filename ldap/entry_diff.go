@@ -0,0 +1,135 @@
+package ldap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// AttributeDiff 单个属性在两个条目中的差异
+type AttributeDiff struct {
+	Name   string
+	OnlyA  []string // 只在条目A中出现的值
+	OnlyB  []string // 只在条目B中出现的值
+	Common []string // 两个条目都有的值
+}
+
+// EntryDiff 两个条目的属性对比结果
+type EntryDiff struct {
+	DNA       string
+	DNB       string
+	OnlyA     []*ldap.EntryAttribute // 只在条目A中存在的属性
+	OnlyB     []*ldap.EntryAttribute // 只在条目B中存在的属性
+	Different []AttributeDiff        // 两个条目都有但值不同的属性
+	Same      []string               // 值完全相同的属性名
+}
+
+// Identical 返回两个条目的属性是否完全相同
+func (d *EntryDiff) Identical() bool {
+	return len(d.OnlyA) == 0 && len(d.OnlyB) == 0 && len(d.Different) == 0
+}
+
+// Summary 返回对比结果的可读描述，值相同的属性只列出名称
+func (d *EntryDiff) Summary() []string {
+	lines := []string{"A：" + d.DNA, "B：" + d.DNB}
+	for _, attr := range d.OnlyA {
+		lines = append(lines, fmt.Sprintf("[仅A] %s: %s", attr.Name, strings.Join(DescribeAttributeValues(attr), "; ")))
+	}
+	for _, attr := range d.OnlyB {
+		lines = append(lines, fmt.Sprintf("[仅B] %s: %s", attr.Name, strings.Join(DescribeAttributeValues(attr), "; ")))
+	}
+	for _, diff := range d.Different {
+		lines = append(lines, "[不同] "+diff.Name)
+		if len(diff.OnlyA) > 0 {
+			lines = append(lines, "    A: "+strings.Join(diff.OnlyA, "; "))
+		}
+		if len(diff.OnlyB) > 0 {
+			lines = append(lines, "    B: "+strings.Join(diff.OnlyB, "; "))
+		}
+		if len(diff.Common) > 0 {
+			lines = append(lines, "    共同: "+strings.Join(diff.Common, "; "))
+		}
+	}
+	if len(d.Same) > 0 {
+		lines = append(lines, fmt.Sprintf("[相同] %d 个属性：%s", len(d.Same), strings.Join(d.Same, ", ")))
+	}
+	return lines
+}
+
+// DiffEntries 逐属性对比两个条目，属性名不区分大小写，值按可读形式比较（objectSid、objectGUID已解码）
+func DiffEntries(a *ldap.Entry, b *ldap.Entry) *EntryDiff {
+	diff := &EntryDiff{DNA: a.DN, DNB: b.DN}
+
+	attrsB := make(map[string]*ldap.EntryAttribute, len(b.Attributes))
+	for _, attr := range b.Attributes {
+		attrsB[strings.ToLower(attr.Name)] = attr
+	}
+
+	seen := make(map[string]bool, len(a.Attributes))
+	for _, attrA := range a.Attributes {
+		key := strings.ToLower(attrA.Name)
+		seen[key] = true
+		attrB, ok := attrsB[key]
+		if !ok {
+			diff.OnlyA = append(diff.OnlyA, attrA)
+			continue
+		}
+		valuesA := DescribeAttributeValues(attrA)
+		valuesB := DescribeAttributeValues(attrB)
+		onlyA, onlyB, common := compareValues(valuesA, valuesB)
+		if len(onlyA) == 0 && len(onlyB) == 0 {
+			diff.Same = append(diff.Same, attrA.Name)
+			continue
+		}
+		diff.Different = append(diff.Different, AttributeDiff{Name: attrA.Name, OnlyA: onlyA, OnlyB: onlyB, Common: common})
+	}
+	for _, attrB := range b.Attributes {
+		if !seen[strings.ToLower(attrB.Name)] {
+			diff.OnlyB = append(diff.OnlyB, attrB)
+		}
+	}
+	return diff
+}
+
+// compareValues 比较两组值，返回只在a中、只在b中和两者共有的值
+func compareValues(a []string, b []string) (onlyA []string, onlyB []string, common []string) {
+	inA := make(map[string]bool, len(a))
+	for _, value := range a {
+		inA[value] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, value := range b {
+		inB[value] = true
+	}
+	for _, value := range a {
+		if inB[value] {
+			common = append(common, value)
+		} else {
+			onlyA = append(onlyA, value)
+		}
+	}
+	for _, value := range b {
+		if !inA[value] {
+			onlyB = append(onlyB, value)
+		}
+	}
+	return onlyA, onlyB, common
+}
+
+// CompareEntries 读取两个条目的所有用户属性并逐属性对比，operational为true时同时对比操作属性
+func (client *LDAPClient) CompareEntries(dnA string, dnB string, operational bool) (*EntryDiff, error) {
+	attrs := []string{AllUserAttributes}
+	if operational {
+		attrs = append(attrs, AllOperationalAttributes)
+	}
+	entryA, err := client.GetEntry(dnA, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("读取条目A失败: %v", err)
+	}
+	entryB, err := client.GetEntry(dnB, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("读取条目B失败: %v", err)
+	}
+	return DiffEntries(entryA, entryB), nil
+}
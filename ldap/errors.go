@@ -0,0 +1,90 @@
+package ldap
+
+import (
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// isNetworkError 判断错误是否由网络或连接中断引起（可通过重新连接恢复）
+func isNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// go-ldap在连接关闭后返回的错误没有包装底层错误
+	return strings.Contains(err.Error(), "connection closed")
+}
+
+// describedError 使用可读的描述作为错误信息，同时保留原始错误，便于errors.Is/As判断错误类型
+type describedError struct {
+	message string
+	err     error
+}
+
+func (e *describedError) Error() string { return e.message }
+
+func (e *describedError) Unwrap() error { return e.err }
+
+// describeError 返回以message为信息、包装err的错误
+func describeError(message string, err error) error {
+	return &describedError{message: message, err: err}
+}
+
+// LDAPResultCode 返回错误中的LDAP结果码，非LDAP错误返回0
+func LDAPResultCode(err error) int {
+	var ldapErr *ldap.Error
+	if errors.As(err, &ldapErr) {
+		return int(ldapErr.ResultCode)
+	}
+	return 0
+}
+
+// adDataCodePattern 匹配AD诊断信息中的子错误码，如"AcceptSecurityContext error, data 52e, v4563"
+var adDataCodePattern = regexp.MustCompile(`(?i)\bdata ([0-9a-f]+)\b`)
+
+// adDataCodes AD绑定失败时常见的子错误码
+var adDataCodes = map[string]string{
+	"525": "用户不存在",
+	"52e": "密码错误",
+	"530": "当前时间不允许登录",
+	"531": "不允许从此工作站登录",
+	"532": "密码已过期",
+	"533": "账户已停用",
+	"568": "用户所属的安全组过多",
+	"701": "账户已过期",
+	"773": "用户必须在下次登录时修改密码",
+	"775": "账户已锁定",
+}
+
+// ParseADErrorCode 从AD的诊断信息中提取"data XXX"子错误码及其含义
+func ParseADErrorCode(err error) (code string, explanation string, ok bool) {
+	if err == nil {
+		return "", "", false
+	}
+	match := adDataCodePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return "", "", false
+	}
+	code = strings.ToLower(match[1])
+	explanation, ok = adDataCodes[code]
+	if !ok {
+		explanation = "未知的AD错误码"
+	}
+	return code, explanation, true
+}
@@ -0,0 +1,43 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestIsNetworkError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"EOF", io.EOF, true},
+		{"意外EOF", io.ErrUnexpectedEOF, true},
+		{"连接被重置", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"管道断开", syscall.EPIPE, true},
+		{"已关闭的连接", net.ErrClosed, true},
+		{"go-ldap网络错误", ldap.NewError(ldap.ErrorNetwork, errors.New("dial failed")), true},
+		{"go-ldap连接已关闭", errors.New("ldap: connection closed"), true},
+		{"包装的EOF", fmt.Errorf("搜索失败: %w", io.EOF), true},
+		{"多层包装的连接重置", fmt.Errorf("重新连接失败: %w", fmt.Errorf("连接失败: %w", syscall.ECONNRESET)), true},
+		{"描述错误包装的网络错误", describeError("LDAP绑定失败: 网络错误", ldap.NewError(ldap.ErrorNetwork, io.EOF)), true},
+		{"权限不足", ldap.NewError(ldap.LDAPResultInsufficientAccessRights, errors.New("denied")), false},
+		{"凭据无效", ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("data 52e")), false},
+		{"未包装的文本", errors.New("连接失败: " + io.EOF.Error()), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNetworkError(tt.err); got != tt.want {
+				t.Errorf("isNetworkError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
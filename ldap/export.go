@@ -0,0 +1,42 @@
+package ldap
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// WriteCSV 将搜索结果写为CSV：首列为DN，其余每个属性一列，多值以";"连接
+func (result *SearchResult) WriteCSV(w io.Writer) error {
+	columns := result.Columns()
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(append([]string{"dn"}, columns...)); err != nil {
+		return err
+	}
+	for _, entry := range result.Entries {
+		record := make([]string, 0, len(columns)+1)
+		record = append(record, entry.DN)
+		for _, column := range columns {
+			record = append(record, strings.Join(entryDisplayValues(entry, column), ";"))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// entryDisplayValues 返回条目中指定属性的可读值
+func entryDisplayValues(entry *ldap.Entry, name string) []string {
+	for _, attr := range entry.Attributes {
+		if strings.EqualFold(attr.Name, name) {
+			return AttributeDisplayValues(attr)
+		}
+	}
+	return nil
+}
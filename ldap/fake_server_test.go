@@ -0,0 +1,306 @@
+package ldap
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+
+	"LdapTest/logger"
+)
+
+// fakeServer 测试用的最小LDAP服务器，所有请求都返回成功，并统计当前打开的连接数
+type fakeServer struct {
+	listener net.Listener
+	open     atomic.Int32 // 当前打开的连接数
+	accepted atomic.Int32 // 累计接受的连接数
+
+	mu                sync.Mutex
+	entryAttributes   map[string][]string // 搜索返回的条目属性
+	generatedPassword string              // 密码修改扩展操作返回的生成密码
+	silent            atomic.Bool         // 为true时读取请求但不响应，用于测试超时
+
+	entries map[string]bool // 非nil时模拟目录中已存在的条目（DN小写），读取不存在的条目返回noSuchObject，子树和单层搜索不返回条目
+	added   []addedEntry    // 收到的添加请求，按顺序记录
+
+	sortSupported bool   // 为true时对带排序控制的搜索返回排序结果控制
+	sortRejected  uint16 // 非0时以该结果码拒绝带排序控制的搜索
+	sortRequests  int    // 收到的带排序控制的搜索数
+}
+
+// addedEntry 测试服务器收到的添加请求
+type addedEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// addedDNs 返回收到的添加请求的DN
+func (s *fakeServer) addedDNs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dns := make([]string, len(s.added))
+	for i, entry := range s.added {
+		dns[i] = entry.DN
+	}
+	return dns
+}
+
+// newFakeServer 在本机随机端口启动测试服务器，测试结束时关闭
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动测试服务器失败: %v", err)
+	}
+	server := &fakeServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+// port 返回服务器监听的端口
+func (s *fakeServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// client 创建连接到测试服务器的客户端，日志输出到log
+func (s *fakeServer) client(log *logger.BaseLogger) *LDAPClient {
+	return NewLDAPClient("127.0.0.1", s.port(), "cn=admin,dc=example,dc=com", "AdminSecret#1", log, nil, false, true)
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.accepted.Add(1)
+		s.open.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// handle 逐个读取请求并返回对应的成功响应
+func (s *fakeServer) handle(conn net.Conn) {
+	defer s.open.Add(-1)
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		if s.silent.Load() {
+			continue
+		}
+		messageID := packet.Children[0].Value.(int64)
+		var responses []*ber.Packet
+		var controls *ber.Packet // 附加在最后一个响应上的控制
+		switch packet.Children[1].Tag {
+		case ldap.ApplicationUnbindRequest:
+			return
+		case ldap.ApplicationBindRequest:
+			responses = append(responses, resultPacket(ldap.ApplicationBindResponse))
+		case ldap.ApplicationSearchRequest:
+			baseDN := packet.Children[1].Children[0].Value.(string)
+			if !s.exists(baseDN) {
+				responses = append(responses, resultCodePacket(ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject))
+				break
+			}
+			sorted, rejected := s.sort(packet)
+			if rejected != ldap.LDAPResultSuccess {
+				responses = append(responses, resultCodePacket(ldap.ApplicationSearchResultDone, rejected))
+				break
+			}
+			if sorted {
+				controls = sortResultControls()
+			}
+			if s.returnsEntry(packet.Children[1]) {
+				responses = append(responses, s.entryPacket(baseDN))
+			}
+			responses = append(responses, resultPacket(ldap.ApplicationSearchResultDone))
+		case ldap.ApplicationAddRequest:
+			responses = append(responses, resultCodePacket(ldap.ApplicationAddResponse, s.add(packet.Children[1])))
+		case ldap.ApplicationModifyRequest:
+			responses = append(responses, resultPacket(ldap.ApplicationModifyResponse))
+		case ldap.ApplicationDelRequest:
+			responses = append(responses, resultPacket(ldap.ApplicationDelResponse))
+		case ldap.ApplicationExtendedRequest:
+			responses = append(responses, s.extendedPacket())
+		default:
+			return
+		}
+		for i, response := range responses {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+			envelope.AppendChild(response)
+			if controls != nil && i == len(responses)-1 {
+				envelope.AppendChild(controls)
+			}
+			if _, err := conn.Write(envelope.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// exists 判断条目是否存在，未模拟目录内容时所有条目都存在
+func (s *fakeServer) exists(dn string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries == nil || dn == "" || s.entries[strings.ToLower(dn)]
+}
+
+// returnsEntry 判断搜索是否返回基准条目，模拟目录内容时只有读取条目本身的搜索返回
+func (s *fakeServer) returnsEntry(request *ber.Packet) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries == nil || request.Children[1].Value.(int64) == ldap.ScopeBaseObject
+}
+
+// add 记录添加请求，模拟目录内容时条目已存在返回entryAlreadyExists
+func (s *fakeServer) add(request *ber.Packet) uint16 {
+	entry := addedEntry{DN: request.Children[0].Value.(string), Attributes: map[string][]string{}}
+	for _, attr := range request.Children[1].Children {
+		name := attr.Children[0].Value.(string)
+		for _, value := range attr.Children[1].Children {
+			entry.Attributes[name] = append(entry.Attributes[name], value.Value.(string))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.added = append(s.added, entry)
+	if s.entries == nil {
+		return ldap.LDAPResultSuccess
+	}
+	key := strings.ToLower(entry.DN)
+	if s.entries[key] {
+		return ldap.LDAPResultEntryAlreadyExists
+	}
+	s.entries[key] = true
+	return ldap.LDAPResultSuccess
+}
+
+// sort 检查搜索请求是否带排序控制，返回是否应返回排序结果控制以及拒绝搜索的结果码
+func (s *fakeServer) sort(packet *ber.Packet) (bool, uint16) {
+	if len(packet.Children) < 3 {
+		return false, ldap.LDAPResultSuccess
+	}
+	for _, control := range packet.Children[2].Children {
+		if control.Children[0].Value.(string) != ldap.ControlTypeServerSideSorting {
+			continue
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.sortRequests++
+		return s.sortSupported, s.sortRejected
+	}
+	return false, ldap.LDAPResultSuccess
+}
+
+// sortResultControls 构造包含排序成功结果的响应控制
+func sortResultControls() *ber.Packet {
+	value := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "SortResult")
+	value.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(ldap.ControlServerSideSortingCodeSuccess), "Sort Result"))
+	control := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Control")
+	control.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, ldap.ControlTypeServerSideSortingResult, "Control Type"))
+	control.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, string(value.Bytes()), "Control Value"))
+	controls := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
+	controls.AppendChild(control)
+	return controls
+}
+
+// resultPacket 构造结果码为成功的LDAPResult
+func resultPacket(tag ber.Tag) *ber.Packet {
+	return resultCodePacket(tag, ldap.LDAPResultSuccess)
+}
+
+// resultCodePacket 构造指定结果码的LDAPResult
+func resultCodePacket(tag ber.Tag, code uint16) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return result
+}
+
+// entryPacket 构造以baseDN为DN的搜索结果条目
+func (s *fakeServer) entryPacket(baseDN string) *ber.Packet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, baseDN, "Object Name"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range s.entryAttributes {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attr.AppendChild(set)
+		attributes.AppendChild(attr)
+	}
+	entry.AppendChild(attributes)
+	return entry
+}
+
+// extendedPacket 构造扩展操作响应，设置了生成密码时按密码修改响应格式返回
+func (s *fakeServer) extendedPacket() *ber.Packet {
+	response := resultPacket(ldap.ApplicationExtendedResponse)
+	s.mu.Lock()
+	generated := s.generatedPassword
+	s.mu.Unlock()
+	if generated != "" {
+		value := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Password Modify Response")
+		value.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, generated, "Generated Password"))
+		responseValue := ber.Encode(ber.ClassContext, ber.TypePrimitive, 11, nil, "Response Value")
+		responseValue.Data.Write(value.Bytes())
+		response.AppendChild(responseValue)
+	}
+	return response
+}
+
+// captureLogger 创建记录所有输出的日志记录器，返回状态区域输出和JSON日志的读取函数
+func captureLogger() (*logger.BaseLogger, func() string) {
+	var mu sync.Mutex
+	var status []byte
+	log := logger.New(true, nil)
+	log.SetDedupe(false)
+	var jsonLog syncBuffer
+	log.SetJSONOutput(&jsonLog)
+	base := log.NewBaseLogger(func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		status = append(status, line...)
+		status = append(status, '\n')
+	})
+	return base, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return string(status) + jsonLog.String()
+	}
+}
+
+// syncBuffer 可并发写入的缓冲区
+type syncBuffer struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
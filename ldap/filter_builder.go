@@ -0,0 +1,97 @@
+package ldap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// 过滤器构建器支持的比较方式
+const (
+	FilterOpEquals     = "等于"
+	FilterOpStartsWith = "开头为"
+	FilterOpContains   = "包含"
+	FilterOpPresent    = "存在"
+)
+
+// 组合多个条件的方式
+const (
+	FilterCombineAnd = "AND"
+	FilterCombineOr  = "OR"
+)
+
+// attributeNamePattern 属性名（或OID）允许的字符
+var attributeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9;.-]*$`)
+
+// FilterOperators 返回可选的比较方式
+func FilterOperators() []string {
+	return []string{FilterOpEquals, FilterOpStartsWith, FilterOpContains, FilterOpPresent}
+}
+
+// FilterBuilderAttributes 返回构建过滤器时可选的常用属性
+func FilterBuilderAttributes() []string {
+	return []string{
+		"cn", "sAMAccountName", "uid", "userPrincipalName", "mail", "displayName",
+		"givenName", "sn", "description", "objectClass", "memberOf", "member", "ou",
+	}
+}
+
+// FilterCondition 过滤器中的单个条件
+type FilterCondition struct {
+	Attribute string
+	Operator  string
+	Value     string
+}
+
+// String 生成条件对应的过滤器，值会经过转义
+func (c FilterCondition) String() (string, error) {
+	attr := strings.TrimSpace(c.Attribute)
+	if !attributeNamePattern.MatchString(attr) {
+		return "", fmt.Errorf("属性名无效: %q", c.Attribute)
+	}
+	if c.Operator != FilterOpPresent && c.Value == "" {
+		return "", fmt.Errorf("属性 %s 的值不能为空", attr)
+	}
+
+	value := ldap.EscapeFilter(c.Value)
+	switch c.Operator {
+	case FilterOpEquals:
+		return "(" + attr + "=" + value + ")", nil
+	case FilterOpStartsWith:
+		return "(" + attr + "=" + value + "*)", nil
+	case FilterOpContains:
+		return "(" + attr + "=*" + value + "*)", nil
+	case FilterOpPresent:
+		return "(" + attr + "=*)", nil
+	}
+	return "", fmt.Errorf("未知的比较方式: %s", c.Operator)
+}
+
+// BuildFilter 将条件按AND或OR组合为过滤器，只有一个条件时不加组合符
+func BuildFilter(conditions []FilterCondition, combine string) (string, error) {
+	if len(conditions) == 0 {
+		return "", fmt.Errorf("至少需要一个条件")
+	}
+
+	parts := make([]string, 0, len(conditions))
+	for _, c := range conditions {
+		part, err := c.String()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+
+	switch combine {
+	case FilterCombineAnd:
+		return "(&" + strings.Join(parts, "") + ")", nil
+	case FilterCombineOr:
+		return "(|" + strings.Join(parts, "") + ")", nil
+	}
+	return "", fmt.Errorf("未知的组合方式: %s", combine)
+}
@@ -0,0 +1,38 @@
+package ldap
+
+import "fmt"
+
+// SetGlobalCatalog 设置是否连接全局编录（端口3268/3269），全局编录模式下从林根搜索
+func (client *LDAPClient) SetGlobalCatalog(enabled bool) {
+	client.globalCatalog = enabled
+}
+
+// IsGlobalCatalog 返回是否处于全局编录模式
+func (client *LDAPClient) IsGlobalCatalog() bool {
+	return client.globalCatalog
+}
+
+// ForestRootDN 返回林根域的DN（RootDSE的rootDomainNamingContext），
+// 服务器未返回时退回到DetectBaseDN检测的基准DN
+func (client *LDAPClient) ForestRootDN() (string, error) {
+	rootDSE, err := client.QueryRootDSE()
+	if err == nil {
+		if values := rootDSE["rootDomainNamingContext"]; len(values) > 0 && values[0] != "" {
+			client.Debug("从rootDomainNamingContext检测到林根：%s", values[0])
+			return values[0], nil
+		}
+		client.Warn("RootDSE未返回rootDomainNamingContext，改用默认基准DN")
+	}
+	baseDN, err := client.DetectBaseDN()
+	if err != nil {
+		return "", fmt.Errorf("检测林根失败: %v", err)
+	}
+	return baseDN, nil
+}
+
+// warnGlobalCatalogWrite 全局编录模式下执行写操作时记录警告：全局编录只读且只包含部分属性，写操作应连接域控制器的389/636端口
+func (client *LDAPClient) warnGlobalCatalogWrite(operation string, dn string) {
+	if client.globalCatalog {
+		client.Warn("全局编录是只读的，%s操作可能被拒绝，请关闭全局编录模式后连接域控制器：%s", operation, dn)
+	}
+}
@@ -1,7 +1,10 @@
 package ldap
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/go-ldap/ldap/v3"
 )
@@ -23,43 +26,41 @@ const (
 	INHERIT_ONLY_ACE      byte = 0x08
 	OBJECT_INHERIT_ACE    byte = 0x01
 
-	// 安全描述符控制标志
-	DACL_SECURITY_INFORMATION uint32 = 0x00000004
+	// 安全描述符控制标志（SD标志控制中指定读写的部分）
+	OWNER_SECURITY_INFORMATION uint32 = 0x00000001
+	GROUP_SECURITY_INFORMATION uint32 = 0x00000002
+	DACL_SECURITY_INFORMATION  uint32 = 0x00000004
+	SACL_SECURITY_INFORMATION  uint32 = 0x00000008
 )
 
-// SetGroupACL 为组设置完整ACL
-func (client *LDAPClient) SetGroupACL(groupDN string, attributes map[string][]string) error {
-	conn, err := client.GetConnection()
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
+// SSOGroupDescription 配置SSO时写入组的描述
+const SSOGroupDescription = "SSO Authentication Group"
 
-	// 创建修改请求
-	modifyRequest := ldap.NewModifyRequest(groupDN, nil)
+// ssoReadRights SSO组需要的读取权限：列出子对象、列出对象、读取属性
+const ssoReadRights = RIGHT_DS_LIST_CONTENTS | RIGHT_DS_LIST_OBJECT | RIGHT_DS_READ_PROPERTY
 
-	// 添加或替换所有属性
-	for name, values := range attributes {
-		modifyRequest.Replace(name, values)
-	}
+// ErrContainerCreationDeclined 用户拒绝创建缺失的上级容器
+var ErrContainerCreationDeclined = errors.New("已取消创建缺失的容器，操作已中止")
 
-	// 执行LDAP修改
-	return conn.Modify(modifyRequest)
+// SetContainerConfirm 设置自动创建缺失容器前的确认函数，参数为将要创建的DN（由上至下），返回false时中止
+func (client *LDAPClient) SetContainerConfirm(confirm func(missing []string) bool) {
+	client.confirmContainers = confirm
 }
 
-// EnsureDNExists 确保DN存在
+// EnsureDNExists 确保DN存在，不存在时逐级创建缺失的容器（设置了确认函数时需先确认）
 func (client *LDAPClient) EnsureDNExists(dn string) error {
 	client.Debug("正在检查DN是否存在：%s", dn)
 	// 确保连接有效
-	conn, err := client.GetConnection()
+	conn, release, err := client.acquireConnection()
 	if err != nil {
 		return fmt.Errorf("检查DN时连接失败: %v", err)
 	}
+	defer release()
 
 	// 构建搜索请求
 	searchRequest := ldap.NewSearchRequest(
-		dn,                                             // 基准DN
-		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, // 搜索范围和别名处理
+		dn,                                           // 基准DN
+		ldap.ScopeBaseObject, ldap.NeverDerefAliases, // 只检查DN本身
 		0, 0, false, // 大小限制，时间限制，仅类型
 		"(objectClass=*)", // 过滤器
 		[]string{"dn"},    // 返回属性
@@ -70,8 +71,18 @@ func (client *LDAPClient) EnsureDNExists(dn string) error {
 	_, err = conn.Search(searchRequest)
 	if err != nil {
 		if ldapErr, ok := err.(*ldap.Error); ok && ldapErr.ResultCode == 32 {
-			// DN不存在，需要创建
+			// DN不存在，逐级创建缺失的上级容器
 			client.Debug("DN不存在，正在创建：%s", dn)
+			if client.confirmContainers != nil {
+				missing, err := client.missingDNLevels(conn, dn)
+				if err != nil {
+					return err
+				}
+				if len(missing) > 0 && !client.confirmContainers(missing) {
+					client.Warn("%v：%s", ErrContainerCreationDeclined, strings.Join(missing, "; "))
+					return ErrContainerCreationDeclined
+				}
+			}
 			return client.CreateDN(dn)
 		}
 		return fmt.Errorf("检查DN失败: %v", err)
@@ -85,10 +96,11 @@ func (client *LDAPClient) EnsureDNExists(dn string) error {
 func (client *LDAPClient) GetGroupMembers(groupDN string) ([]string, error) {
 	client.Debug("正在获取组成员列表：%s", groupDN)
 	// 确保连接有效
-	conn, err := client.GetConnection()
+	conn, release, err := client.acquireConnection()
 	if err != nil {
 		return nil, fmt.Errorf("获取组成员时连接失败: %v", err)
 	}
+	defer release()
 
 	// 构建搜索请求
 	searchRequest := ldap.NewSearchRequest(
@@ -116,20 +128,148 @@ func (client *LDAPClient) GetGroupMembers(groupDN string) ([]string, error) {
 	return members, nil
 }
 
-// AddUserToGroup 添加用户到组
-func (client *LDAPClient) AddUserToGroup(userDN string, groupDN string) error {
-	conn, err := client.GetConnection()
+// GetGroupMembersRecursive 递归获取组成员（展开嵌套组，带循环检测）
+func (client *LDAPClient) GetGroupMembersRecursive(groupDN string) ([]string, error) {
+	client.Debug("正在递归获取组成员列表：%s", groupDN)
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return nil, fmt.Errorf("获取组成员时连接失败: %v", err)
+	}
+	defer release()
+
+	visitedGroups := make(map[string]bool)
+	seenMembers := make(map[string]bool)
+	var members []string
+
+	queue := []string{groupDN}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		// 循环检测：每个组只展开一次
+		key := strings.ToLower(current)
+		if visitedGroups[key] {
+			client.Debug("组已处理过，跳过：%s", current)
+			continue
+		}
+		visitedGroups[key] = true
+
+		entry, err := readEntry(conn, current, []string{"member"})
+		if err != nil {
+			if current == groupDN {
+				return nil, fmt.Errorf("搜索组失败: %v", err)
+			}
+			client.Warn("读取嵌套组 %s 失败: %v", current, err)
+			continue
+		}
+
+		for _, member := range entry.GetAttributeValues("member") {
+			memberEntry, err := readEntry(conn, member, []string{"objectClass"})
+			if err == nil && isGroupEntry(memberEntry) {
+				client.Debug("发现嵌套组：%s", member)
+				queue = append(queue, member)
+				continue
+			}
+
+			memberKey := strings.ToLower(member)
+			if !seenMembers[memberKey] {
+				seenMembers[memberKey] = true
+				members = append(members, member)
+			}
+		}
+	}
+
+	client.Debug("共找到 %d 个成员（已展开 %d 个组）", len(members), len(visitedGroups))
+	return members, nil
+}
+
+// matchingRuleInChain AD的LDAP_MATCHING_RULE_IN_CHAIN，可在一次查询中匹配传递的组成员关系
+const matchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// IsMemberOf 检查用户是否直接或通过嵌套组间接属于指定组
+// AD使用LDAP_MATCHING_RULE_IN_CHAIN查询，其他目录递归展开组成员
+func (client *LDAPClient) IsMemberOf(userDN string, groupDN string) (bool, error) {
+	client.Debug("检查 %s 是否属于组 %s", userDN, groupDN)
+	if !client.isActiveDirectory() {
+		members, err := client.GetGroupMembersRecursive(groupDN)
+		if err != nil {
+			return false, err
+		}
+		for _, member := range members {
+			if strings.EqualFold(member, userDN) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return false, fmt.Errorf("检查组成员关系时连接失败: %v", err)
+	}
+	defer release()
+
+	filter := fmt.Sprintf("(memberOf:%s:=%s)", matchingRuleInChain, ldap.EscapeFilter(groupDN))
+	searchRequest := ldap.NewSearchRequest(
+		userDN,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0, 0, false,
+		filter,
+		[]string{"distinguishedName"},
+		nil,
+	)
+	sr, err := conn.Search(searchRequest)
+	if err != nil {
+		return false, fmt.Errorf("检查组成员关系失败: %s", ParseLDAPError(err))
+	}
+	return len(sr.Entries) > 0, nil
+}
+
+// readEntry 以基准范围读取单个条目
+func readEntry(conn *ldap.Conn, dn string, attributes []string) (*ldap.Entry, error) {
+	searchRequest := ldap.NewSearchRequest(
+		dn,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0, 0, false,
+		"(objectClass=*)",
+		attributes,
+		nil,
+	)
+
+	sr, err := conn.Search(searchRequest)
 	if err != nil {
-		return fmt.Errorf("连接失败: %v", err)
+		return nil, err
 	}
-	defer conn.Close()
+	if len(sr.Entries) == 0 {
+		return nil, fmt.Errorf("未找到条目：%s", dn)
+	}
+	return sr.Entries[0], nil
+}
 
+// isGroupEntry 判断条目是否为组对象
+func isGroupEntry(entry *ldap.Entry) bool {
+	for _, class := range entry.GetAttributeValues("objectClass") {
+		switch strings.ToLower(class) {
+		case "group", "groupofnames", "groupofuniquenames":
+			return true
+		}
+	}
+	return false
+}
+
+// AddUserToGroup 添加用户到组
+func (client *LDAPClient) AddUserToGroup(userDN string, groupDN string) error {
 	// 创建修改请求
 	modifyRequest := ldap.NewModifyRequest(groupDN, nil)
 	modifyRequest.Add("member", []string{userDN})
 
 	// 执行修改
-	if err := conn.Modify(modifyRequest); err != nil {
+	err := client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modify(conn, modifyRequest)
+	})
+	if err != nil {
 		if ldapErr, ok := err.(*ldap.Error); ok && ldapErr.ResultCode == 68 {
 			// 用户已经是组成员，忽略错误
 			return nil
@@ -140,15 +280,35 @@ func (client *LDAPClient) AddUserToGroup(userDN string, groupDN string) error {
 	return nil
 }
 
-// RemoveUserFromAllGroups 从所有组中移除用户
-func (client *LDAPClient) RemoveUserFromAllGroups(userDN string, searchDN string) error {
-	conn, err := client.GetConnection()
+// RemoveUserFromGroup 从指定组中移除用户
+func (client *LDAPClient) RemoveUserFromGroup(userDN string, groupDN string) error {
+	modifyRequest := ldap.NewModifyRequest(groupDN, nil)
+	modifyRequest.Delete("member", []string{userDN})
+
+	err := client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modify(conn, modifyRequest)
+	})
 	if err != nil {
-		return fmt.Errorf("连接失败: %v", err)
+		if ldapErr, ok := err.(*ldap.Error); ok && ldapErr.ResultCode == ldap.LDAPResultNoSuchAttribute {
+			// 用户本来就不是组成员，忽略错误
+			client.Debug("用户不在组 %s 中，无需移除", groupDN)
+			return nil
+		}
+		return fmt.Errorf("从组移除用户失败: %v", err)
 	}
-	defer conn.Close()
 
-	// 搜索包含该用户的所有组
+	client.Info("已从组 %s 移除用户", groupDN)
+	return nil
+}
+
+// GetUserGroups 获取直接包含该用户的所有组
+func (client *LDAPClient) GetUserGroups(userDN string, searchDN string) ([]string, error) {
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
 	searchRequest := ldap.NewSearchRequest(
 		searchDN,
 		ldap.ScopeWholeSubtree,
@@ -161,32 +321,89 @@ func (client *LDAPClient) RemoveUserFromAllGroups(userDN string, searchDN string
 
 	sr, err := conn.Search(searchRequest)
 	if err != nil {
-		return fmt.Errorf("搜索组失败: %v", err)
+		return nil, fmt.Errorf("搜索组失败: %v", err)
+	}
+
+	var groups []string
+	for _, entry := range sr.Entries {
+		groups = append(groups, entry.DN)
+	}
+	return groups, nil
+}
+
+// RemoveUserFromAllGroups 从所有组中移除用户，返回受影响的组
+// 用户的主组（RID等于primaryGroupID）无法通过member移除，会被跳过
+// dryRun为true时只返回将受影响的组，不做修改
+func (client *LDAPClient) RemoveUserFromAllGroups(userDN string, searchDN string, dryRun bool) ([]string, error) {
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	// 读取用户的主组RID（非AD目录没有该属性）
+	var primaryGroupID uint64
+	if userEntry, err := readEntry(conn, userDN, []string{"primaryGroupID"}); err == nil {
+		if value := userEntry.GetAttributeValue("primaryGroupID"); value != "" {
+			primaryGroupID, _ = strconv.ParseUint(value, 10, 32)
+		}
+	}
+
+	// 搜索包含该用户的所有组
+	searchRequest := ldap.NewSearchRequest(
+		searchDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0, 0, false,
+		fmt.Sprintf("(&(objectClass=group)(member=%s))", ldap.EscapeFilter(userDN)),
+		[]string{"dn", "objectSid"},
+		nil,
+	)
+
+	sr, err := conn.Search(searchRequest)
+	if err != nil {
+		return nil, fmt.Errorf("搜索组失败: %v", err)
 	}
 
-	// 从每个组中移除用户
+	var affected []string
 	for _, entry := range sr.Entries {
-		modifyRequest := ldap.NewModifyRequest(entry.DN, nil)
+		client.Debug("用户所属组：%s（%s）", entry.DN, DecodeSID(entry.GetRawAttributeValue("objectSid")))
+		if primaryGroupID != 0 {
+			if rid, err := SIDRID(entry.GetRawAttributeValue("objectSid")); err == nil && uint64(rid) == primaryGroupID {
+				client.Info("跳过用户的主组：%s", entry.DN)
+				continue
+			}
+		}
+		affected = append(affected, entry.DN)
+	}
+	if dryRun {
+		return affected, nil
+	}
+
+	// 从每个组中移除用户
+	for _, groupDN := range affected {
+		modifyRequest := ldap.NewModifyRequest(groupDN, nil)
 		modifyRequest.Delete("member", []string{userDN})
-		if err := conn.Modify(modifyRequest); err != nil {
-			client.Warn("从组 %s 移除用户失败: %v", entry.DN, err)
+		if err := client.modify(conn, modifyRequest); err != nil {
+			client.Warn("从组 %s 移除用户失败: %v", groupDN, err)
 		}
 	}
 
-	return nil
+	return affected, nil
 }
 
 // SearchGroup 搜索组
 func (client *LDAPClient) SearchGroup(groupName string, searchDN string) (bool, string) {
-	conn, err := client.GetConnection()
+	conn, release, err := client.acquireConnection()
 	if err != nil {
 		client.Error("搜索组时连接失败: %v", err)
 		return false, ""
 	}
+	defer release()
 
 	searchRequest := ldap.NewSearchRequest(
 		searchDN,
-		ldap.ScopeWholeSubtree,
+		client.searchScope,
 		ldap.NeverDerefAliases,
 		0, 0, false,
 		fmt.Sprintf("(&(objectClass=group)(cn=%s))", ldap.EscapeFilter(groupName)),
@@ -209,12 +426,6 @@ func (client *LDAPClient) SearchGroup(groupName string, searchDN string) (bool,
 
 // ModifyGroup 修改组属性
 func (client *LDAPClient) ModifyGroup(groupDN string, attributes map[string][]string) error {
-	conn, err := client.GetConnection()
-	if err != nil {
-		return fmt.Errorf("连接失败: %v", err)
-	}
-	defer conn.Close()
-
 	// 创建修改请求
 	modifyRequest := ldap.NewModifyRequest(groupDN, nil)
 
@@ -224,53 +435,109 @@ func (client *LDAPClient) ModifyGroup(groupDN string, attributes map[string][]st
 	}
 
 	// 执行修改
-	if err := conn.Modify(modifyRequest); err != nil {
+	err := client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modify(conn, modifyRequest)
+	})
+	if err != nil {
 		return fmt.Errorf("修改组属性失败: %v", err)
 	}
 
 	return nil
 }
 
-// ConfigureGroupForSSO 配置组的SSO权限
-func (client *LDAPClient) ConfigureGroupForSSO(groupDN string, searchDN string) error {
-	conn, err := client.GetConnection()
-	if err != nil {
-		return fmt.Errorf("连接失败: %v", err)
-	}
-	defer conn.Close()
-
+// ConfigureGroupForSSO 配置组的SSO权限，groupType为GroupType返回的属性值
+func (client *LDAPClient) ConfigureGroupForSSO(groupDN string, searchDN string, groupType string) error {
 	// 设置组属性
 	attributes := map[string][]string{
-		"groupType":   {"-2147483646"}, // 安全组
-		"description": {"SSO Authentication Group"},
+		"groupType":   {groupType},
+		"description": {SSOGroupDescription},
 	}
 
 	if err := client.ModifyGroup(groupDN, attributes); err != nil {
 		return fmt.Errorf("配置组属性失败: %v", err)
 	}
 
+	// 授予组读取搜索DN下用户的权限，nTSecurityDescriptor只存在于AD
+	if !client.isActiveDirectory() {
+		client.Debug("非AD目录，跳过ACL配置")
+		return nil
+	}
+	if err := client.SetGroupACL(searchDN, groupDN, ssoReadRights); err != nil {
+		return fmt.Errorf("配置ACL失败: %v", err)
+	}
+
 	return nil
 }
 
-// CreateGroup 创建新组
-func (client *LDAPClient) CreateGroup(groupDN string, groupName string) error {
-	conn, err := client.GetConnection()
-	if err != nil {
-		return fmt.Errorf("连接失败: %v", err)
-	}
-	defer conn.Close()
-
+// CreateGroup 创建新组，groupType为GroupType返回的属性值，AD目录同时设置sAMAccountName
+func (client *LDAPClient) CreateGroup(groupDN string, groupName string, groupType string) error {
 	// 创建组请求
 	addRequest := ldap.NewAddRequest(groupDN, nil)
 	addRequest.Attribute("objectClass", []string{"top", "group"})
 	addRequest.Attribute("cn", []string{groupName})
-	addRequest.Attribute("groupType", []string{"-2147483646"}) // 安全组
+	if client.isActiveDirectory() {
+		addRequest.Attribute("sAMAccountName", []string{groupName})
+	}
+	addRequest.Attribute("groupType", []string{groupType})
 	addRequest.Attribute("description", []string{"LDAP Authentication Group"})
 
 	// 执行创建
-	if err := conn.Add(addRequest); err != nil {
+	err := client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.add(conn, addRequest)
+	})
+	if err != nil {
 		return fmt.Errorf("创建组失败: %v", err)
 	}
 
 	return nil
 }
+
+// GetGroupRID 读取组的objectSid并返回其RID（即成员primaryGroupID的取值）
+func (client *LDAPClient) GetGroupRID(groupDN string) (uint32, error) {
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return 0, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	entry, err := readEntry(conn, groupDN, []string{"objectSid"})
+	if err != nil {
+		return 0, fmt.Errorf("读取组objectSid失败: %s", ParseLDAPError(err))
+	}
+	sid := entry.GetRawAttributeValue("objectSid")
+	if len(sid) == 0 {
+		return 0, fmt.Errorf("组 %s 没有objectSid属性", groupDN)
+	}
+	client.Debug("组 %s 的objectSid：%s", groupDN, DecodeSID(sid))
+	return SIDRID(sid)
+}
+
+// SetPrimaryGroup 将组设为用户的主组（仅AD）
+// AD要求用户先是组成员，因此会先确保用户已加入该组
+func (client *LDAPClient) SetPrimaryGroup(userDN string, groupDN string) error {
+	if !client.isActiveDirectory() {
+		return fmt.Errorf("primaryGroupID仅适用于Active Directory")
+	}
+
+	rid, err := client.GetGroupRID(groupDN)
+	if err != nil {
+		return err
+	}
+	client.Debug("组 %s 的RID：%d", groupDN, rid)
+
+	if err := client.AddUserToGroup(userDN, groupDN); err != nil {
+		return fmt.Errorf("设为主组前加入组失败: %v", err)
+	}
+
+	modifyRequest := ldap.NewModifyRequest(userDN, nil)
+	modifyRequest.Replace("primaryGroupID", []string{strconv.FormatUint(uint64(rid), 10)})
+	err = client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modify(conn, modifyRequest)
+	})
+	if err != nil {
+		return fmt.Errorf("设置主组失败: %s", ParseLDAPError(err))
+	}
+
+	client.Info("已将 %s 设为用户的主组（primaryGroupID=%d）", groupDN, rid)
+	return nil
+}
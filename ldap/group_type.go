@@ -0,0 +1,64 @@
+package ldap
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// AD组的范围（groupType属性的低位）
+const (
+	GroupScopeGlobal      = 0x00000002
+	GroupScopeDomainLocal = 0x00000004
+	GroupScopeUniversal   = 0x00000008
+)
+
+// groupTypeSecurityEnabled groupType中表示安全组的标志位，未设置时为通讯组
+const groupTypeSecurityEnabled = 0x80000000
+
+// DefaultGroupScope 默认的组范围
+const DefaultGroupScope = GroupScopeGlobal
+
+// groupScopeNames 组范围对应的名称
+var groupScopeNames = map[int]string{
+	GroupScopeGlobal:      "全局",
+	GroupScopeUniversal:   "通用",
+	GroupScopeDomainLocal: "本地域",
+}
+
+// GroupScopes 返回可选的组范围名称
+func GroupScopes() []string {
+	return []string{"全局", "通用", "本地域"}
+}
+
+// ParseGroupScope 将范围名称转换为组范围
+func ParseGroupScope(name string) (int, bool) {
+	for scope, n := range groupScopeNames {
+		if n == name {
+			return scope, true
+		}
+	}
+	return 0, false
+}
+
+// GroupScopeName 返回组范围的名称
+func GroupScopeName(scope int) string {
+	if name, ok := groupScopeNames[scope]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", scope)
+}
+
+// GroupType 组合组范围与安全组标志，返回groupType属性使用的有符号32位值
+// 例如全局安全组为 0x80000000|0x2 = -2147483646
+func GroupType(scope int, security bool) string {
+	value := uint32(scope)
+	if security {
+		value |= groupTypeSecurityEnabled
+	}
+	return strconv.FormatInt(int64(int32(value)), 10)
+}
+
+// DefaultGroupType 默认的groupType属性值（全局安全组）
+func DefaultGroupType() string {
+	return GroupType(DefaultGroupScope, true)
+}
@@ -0,0 +1,76 @@
+package ldap
+
+import (
+	"time"
+)
+
+// DefaultKeepAliveInterval 保持连接的默认检测间隔
+const DefaultKeepAliveInterval = 60 * time.Second
+
+// StartKeepAlive 启用共享连接，并在后台定期检测连接，失效时主动重连
+func (client *LDAPClient) StartKeepAlive(interval time.Duration) {
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	if client.keepAliveStop != nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultKeepAliveInterval
+	}
+
+	// 连接在首次操作或首次检测时建立
+	client.shared = true
+	stop := make(chan struct{})
+	client.keepAliveStop = stop
+	client.Debug("已启用保持连接，检测间隔：%v", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				client.keepAliveCheck()
+			}
+		}
+	}()
+}
+
+// keepAliveCheck 执行一次轻量的连接检测，失效时重新连接并绑定
+func (client *LDAPClient) keepAliveCheck() {
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	if client.keepAliveStop == nil {
+		return
+	}
+	client.Debug("保持连接：正在检测连接")
+	if err := client.EnsureConnection(); err != nil {
+		client.Warn("保持连接：重新连接失败: %v", err)
+	}
+}
+
+// StopKeepAlive 停止后台检测，没有其他共享连接使用者时关闭共享连接
+func (client *LDAPClient) StopKeepAlive() {
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	if client.keepAliveStop == nil {
+		return
+	}
+	close(client.keepAliveStop)
+	client.keepAliveStop = nil
+	client.Debug("已停止保持连接")
+	if client.sharedUsers > 0 {
+		return
+	}
+	client.shared = false
+	client.Close()
+}
+
+// KeepAliveRunning 返回是否正在保持连接
+func (client *LDAPClient) KeepAliveRunning() bool {
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	return client.keepAliveStop != nil
+}
@@ -0,0 +1,62 @@
+package ldap
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LdapsearchPasswordVar 命令中代替密码的环境变量
+const LdapsearchPasswordVar = "$LDAP_PW"
+
+// shellQuote 用单引号包裹参数，使其在shell中按原样传递
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:/@%+=,", r))
+	}) < 0 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// LdapsearchCommand 生成与当前客户端设置等效的ldapsearch命令
+// includePassword为false时密码使用环境变量$LDAP_PW代替
+func (client *LDAPClient) LdapsearchCommand(baseDN string, filter string, attributes []string, includePassword bool) string {
+	var args []string
+	if client.isSSLMode && SkipTLSVerify {
+		args = append(args, "LDAPTLS_REQCERT=never")
+	}
+	args = append(args, "ldapsearch", "-H", shellQuote(client.GetURL()))
+
+	password := `"` + LdapsearchPasswordVar + `"`
+	if includePassword {
+		password = shellQuote(client.BindPassword)
+	}
+	switch client.bindMethod {
+	case BindMethodExternal:
+		args = append(args, "-Y", "EXTERNAL")
+	case BindMethodDigestMD5:
+		args = append(args, "-Y", "DIGEST-MD5", "-U", shellQuote(client.BindDN), "-w", password)
+	default:
+		args = append(args, "-x")
+		if client.hasBindCredentials() {
+			args = append(args, "-D", shellQuote(client.BindDN), "-w", password)
+		}
+	}
+
+	args = append(args, "-b", shellQuote(baseDN), "-s", SearchScopeName(client.searchScope))
+	if client.sizeLimit > 0 {
+		args = append(args, "-z", strconv.Itoa(client.sizeLimit))
+	}
+	if client.timeLimit > 0 {
+		args = append(args, "-l", strconv.Itoa(client.timeLimit))
+	}
+	if client.followReferrals {
+		args = append(args, "-C")
+	}
+	args = append(args, "-E", "pr="+strconv.Itoa(searchPageSize)+"/noprompt")
+	args = append(args, shellQuote(filter))
+	for _, attr := range attributes {
+		args = append(args, shellQuote(attr))
+	}
+	return strings.Join(args, " ")
+}
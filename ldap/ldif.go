@@ -0,0 +1,265 @@
+package ldap
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldifLineWidth RFC 2849建议的最大行宽，超出部分折行
+const ldifLineWidth = 76
+
+// binaryAttributes 始终按二进制处理的属性
+var binaryAttributes = map[string]bool{
+	"objectsid":            true,
+	"objectguid":           true,
+	"ntsecuritydescriptor": true,
+	"usercertificate":      true,
+	"jpegphoto":            true,
+	"thumbnailphoto":       true,
+}
+
+// EntryToLDIF 将条目转换为LDIF格式（RFC 2849）
+func EntryToLDIF(entry *ldap.Entry) string {
+	var b strings.Builder
+	writeLDIFLine(&b, "dn", []byte(entry.DN), false)
+	for _, attr := range entry.Attributes {
+		binary := binaryAttributes[strings.ToLower(attr.Name)]
+		for _, value := range attr.ByteValues {
+			writeLDIFLine(&b, attr.Name, value, binary)
+		}
+	}
+	return b.String()
+}
+
+// EntriesToLDIF 将多个条目转换为LDIF，条目之间以空行分隔
+func EntriesToLDIF(entries []*ldap.Entry) string {
+	records := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		records = append(records, EntryToLDIF(entry))
+	}
+	return "version: 1\n\n" + strings.Join(records, "\n")
+}
+
+// writeLDIFLine 写入一行"属性: 值"，必要时使用base64并按行宽折行
+func writeLDIFLine(b *strings.Builder, name string, value []byte, binary bool) {
+	var line string
+	if binary || !isSafeLDIFString(value) {
+		line = name + ":: " + base64.StdEncoding.EncodeToString(value)
+	} else {
+		line = name + ": " + string(value)
+	}
+
+	// 折行：续行以一个空格开头，空格计入行宽
+	width := ldifLineWidth
+	for len(line) > width {
+		b.WriteString(line[:width])
+		b.WriteString("\n ")
+		line = line[width:]
+		width = ldifLineWidth - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\n")
+}
+
+// isSafeLDIFString 判断值是否可以按SAFE-STRING原样输出
+func isSafeLDIFString(value []byte) bool {
+	if len(value) == 0 {
+		return true
+	}
+	switch value[0] {
+	case ' ', ':', '<':
+		return false
+	}
+	if value[len(value)-1] == ' ' {
+		return false
+	}
+	for _, c := range value {
+		// 只允许可打印ASCII，其余（包括控制字符和非ASCII）使用base64
+		if c < 0x20 || c > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseLDIF 解析LDIF内容为添加请求，目前仅支持add类型的记录
+func ParseLDIF(r io.Reader) ([]*ldap.AddRequest, error) {
+	records, err := readLDIFRecords(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*ldap.AddRequest
+	for _, record := range records {
+		request, err := parseLDIFRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行: %v", record.line, err)
+		}
+		if request != nil {
+			requests = append(requests, request)
+		}
+	}
+	return requests, nil
+}
+
+// ldifRecord 一条LDIF记录（已合并折行），line为记录起始行号
+type ldifRecord struct {
+	line  int
+	lines []string
+}
+
+// readLDIFRecords 按空行拆分记录，合并折行并去掉注释
+func readLDIFRecords(r io.Reader) ([]ldifRecord, error) {
+	var records []ldifRecord
+	var current *ldifRecord
+	inComment := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if line == "" {
+			if current != nil {
+				records = append(records, *current)
+				current = nil
+			}
+			inComment = false
+			continue
+		}
+
+		// 以空格开头的行是上一行的续行
+		if strings.HasPrefix(line, " ") {
+			if inComment {
+				continue
+			}
+			if current == nil || len(current.lines) == 0 {
+				return nil, fmt.Errorf("第 %d 行: 续行前没有内容", lineNumber)
+			}
+			current.lines[len(current.lines)-1] += line[1:]
+			continue
+		}
+
+		inComment = strings.HasPrefix(line, "#")
+		if inComment {
+			continue
+		}
+
+		if current == nil {
+			current = &ldifRecord{line: lineNumber}
+		}
+		current.lines = append(current.lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		records = append(records, *current)
+	}
+	return records, nil
+}
+
+// parseLDIFRecord 将一条记录转换为添加请求，仅含version的记录返回nil
+func parseLDIFRecord(record ldifRecord) (*ldap.AddRequest, error) {
+	var request *ldap.AddRequest
+	var order []string
+	values := make(map[string][]string)
+
+	for _, line := range record.lines {
+		name, value, err := parseLDIFLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case strings.EqualFold(name, "version") && request == nil:
+			continue
+		case strings.EqualFold(name, "dn"):
+			if request != nil {
+				return nil, fmt.Errorf("记录中包含多个dn")
+			}
+			request = ldap.NewAddRequest(value, nil)
+		case request == nil:
+			return nil, fmt.Errorf("记录必须以dn开头")
+		case strings.EqualFold(name, "changetype"):
+			if !strings.EqualFold(value, "add") {
+				return nil, fmt.Errorf("不支持的changetype: %s（仅支持add）", value)
+			}
+		default:
+			if _, ok := values[name]; !ok {
+				order = append(order, name)
+			}
+			values[name] = append(values[name], value)
+		}
+	}
+
+	if request == nil {
+		return nil, nil
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("条目 %s 没有任何属性", request.DN)
+	}
+	for _, name := range order {
+		request.Attribute(name, values[name])
+	}
+	return request, nil
+}
+
+// parseLDIFLine 解析"属性: 值"或"属性:: base64值"
+func parseLDIFLine(line string) (string, string, error) {
+	colon := strings.Index(line, ":")
+	if colon <= 0 {
+		return "", "", fmt.Errorf("无效的行: %s", line)
+	}
+	name := line[:colon]
+	rest := line[colon+1:]
+
+	switch {
+	case strings.HasPrefix(rest, ":"):
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(rest[1:]))
+		if err != nil {
+			return "", "", fmt.Errorf("属性 %s 的base64值无效: %v", name, err)
+		}
+		return name, string(decoded), nil
+	case strings.HasPrefix(rest, "<"):
+		return "", "", fmt.Errorf("不支持URL形式的值: %s", name)
+	default:
+		return name, strings.TrimLeft(rest, " "), nil
+	}
+}
+
+// AddEntries 依次添加条目并记录每个条目的结果，已存在的条目跳过
+func (client *LDAPClient) AddEntries(requests []*ldap.AddRequest) (added int, skipped int, failed int, err error) {
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	for i, request := range requests {
+		if client.ctx.Err() != nil {
+			return added, skipped, failed, ErrOperationCanceled
+		}
+
+		if err := client.add(conn, request); err != nil {
+			if ldap.IsErrorWithCode(err, ldap.LDAPResultEntryAlreadyExists) {
+				client.Warn("[%d/%d] 条目已存在，跳过：%s", i+1, len(requests), request.DN)
+				skipped++
+				continue
+			}
+			client.Error("[%d/%d] 添加失败：%s，%s", i+1, len(requests), request.DN, ParseLDAPError(err))
+			failed++
+			continue
+		}
+		client.Info("[%d/%d] 已添加：%s", i+1, len(requests), request.DN)
+		added++
+	}
+	return added, skipped, failed, nil
+}
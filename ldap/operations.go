@@ -1,8 +1,13 @@
 package ldap
 
 import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"unicode/utf16"
 
 	"github.com/go-ldap/ldap/v3"
@@ -23,66 +28,323 @@ func EncodePassword(password string) string {
 	return string(bytes)
 }
 
+// EncodeUserPasswordSSHA 将密码编码为OpenLDAP的{SSHA}格式（加盐SHA1）
+func EncodeUserPasswordSSHA(password string) (string, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("生成密码盐失败: %v", err)
+	}
+	return encodeSSHAWithSalt(password, salt), nil
+}
+
+// encodeSSHAWithSalt 使用指定的盐计算{SSHA}值
+func encodeSSHAWithSalt(password string, salt []byte) string {
+	hash := sha1.New()
+	hash.Write([]byte(password))
+	hash.Write(salt)
+	digest := append(hash.Sum(nil), salt...)
+	return "{SSHA}" + base64.StdEncoding.EncodeToString(digest)
+}
+
+// encodePasswordAttribute 根据目录类型返回密码属性名及编码后的值
+func (client *LDAPClient) encodePasswordAttribute(password string) (string, string, error) {
+	if client.isActiveDirectory() {
+		return "unicodePwd", EncodePassword(password), nil
+	}
+	encoded, err := EncodeUserPasswordSSHA(password)
+	if err != nil {
+		return "", "", err
+	}
+	return client.userTemplate.PasswordAttribute, encoded, nil
+}
+
 // LDAPFilter 定义LDAP过滤器结构
 type LDAPFilter struct {
 	Name    string // 过滤器名称
 	Pattern string // 过滤器模式
 }
 
-// CommonFilters 返回常用的LDAP过滤器列表
+// CommonFilters 返回当前目录类型的常用LDAP过滤器列表
 func CommonFilters() []LDAPFilter {
+	filtersMu.RLock()
+	defer filtersMu.RUnlock()
+	return FiltersForDirectoryType(filterDirectoryType)
+}
+
+// ADFilters 返回Active Directory的常用过滤器（默认）
+func ADFilters() []LDAPFilter {
 	return []LDAPFilter{
 		{Name: "用户名模式:sAMAccountName", Pattern: "(&(objectClass=user)(sAMAccountName=%s))"},
 		{Name: "用户邮箱格式:userPrincipalName", Pattern: "(&(objectClass=user)(userPrincipalName=%s))"},
 		{Name: "用户邮箱:mail", Pattern: "(&(objectClass=user)(mail=%s))"},
-		{Name: "OpenLDAP模式:distinguishedName", Pattern: "(&(objectClass=user)(distinguishedName=%s))"},
+		{Name: "DN模式:distinguishedName", Pattern: "(&(objectClass=user)(distinguishedName=%s))"},
 		{Name: "通用模式:cn", Pattern: "(&(objectClass=user)(cn=%s))"},
 	}
 }
 
-// ExtractUsernameFromDN 从DN中提取用户名
-func ExtractUsernameFromDN(dn string) string {
-	// 分割DN字符串
-	parts := strings.Split(dn, ",")
-	if len(parts) == 0 {
-		return ""
+// OpenLDAPFilters 返回OpenLDAP的常用过滤器，使用inetOrgPerson和posixAccount对象类
+func OpenLDAPFilters() []LDAPFilter {
+	return []LDAPFilter{
+		{Name: "OpenLDAP用户名:uid", Pattern: "(&(objectClass=inetOrgPerson)(uid=%s))"},
+		{Name: "OpenLDAP邮箱:mail", Pattern: "(&(objectClass=inetOrgPerson)(mail=%s))"},
+		{Name: "OpenLDAP POSIX账号:uid", Pattern: "(&(objectClass=posixAccount)(uid=%s))"},
+		{Name: "OpenLDAP通用模式:cn", Pattern: "(&(objectClass=inetOrgPerson)(cn=%s))"},
+	}
+}
+
+// FiltersForDirectoryType 返回目录类型对应的常用过滤器，未知类型使用AD过滤器
+func FiltersForDirectoryType(directoryType string) []LDAPFilter {
+	if directoryType == DirectoryTypeOpenLDAP {
+		return OpenLDAPFilters()
+	}
+	return ADFilters()
+}
+
+// filtersMu 保护filterDirectoryType、customFilters和filtersByName，界面切换目录类型或添加过滤器时后台操作可能正在读取
+var filtersMu sync.RWMutex
+
+// filterDirectoryType 内置过滤器使用的目录类型
+var filterDirectoryType = DirectoryTypeAD
+
+// SetFilterDirectoryType 切换内置过滤器对应的目录类型
+func SetFilterDirectoryType(directoryType string) {
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+	filterDirectoryType = directoryType
+	filtersByName = indexFilters(allFiltersLocked())
+}
+
+// ValidateFilterPattern 校验过滤器模式：必须恰好包含一个%s，且替换后语法有效
+func ValidateFilterPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("过滤器模式为空")
+	}
+	if count := strings.Count(pattern, "%s"); count != 1 {
+		return fmt.Errorf("过滤器模式必须恰好包含一个 %%s 占位符，当前为 %d 个", count)
+	}
+	if strings.Count(pattern, "%") != 1 {
+		return fmt.Errorf("过滤器模式包含多余的 %% 格式符")
+	}
+	if _, err := ldap.CompileFilter(strings.Replace(pattern, "%s", "sample", 1)); err != nil {
+		return fmt.Errorf("过滤器语法无效: %v", err)
+	}
+	return nil
+}
+
+// customFilters 保存用户自定义的过滤器
+var customFilters []LDAPFilter
+
+// filtersByName 按名称索引的过滤器，内置过滤器优先于同名的自定义过滤器
+var filtersByName = indexFilters(FiltersForDirectoryType(filterDirectoryType))
+
+// indexFilters 为过滤器列表建立名称索引，同名时保留第一个
+func indexFilters(filters []LDAPFilter) map[string]LDAPFilter {
+	index := make(map[string]LDAPFilter, len(filters))
+	for _, f := range filters {
+		if _, exists := index[f.Name]; !exists {
+			index[f.Name] = f
+		}
+	}
+	return index
+}
+
+// AddCustomFilter 添加自定义过滤器，同名过滤器会被替换
+func AddCustomFilter(filter LDAPFilter) {
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+	replaced := false
+	for i, f := range customFilters {
+		if f.Name == filter.Name {
+			customFilters[i] = filter
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		customFilters = append(customFilters, filter)
 	}
+	filtersByName = indexFilters(allFiltersLocked())
+}
+
+// FilterByName 根据名称查找过滤器（内置或自定义）
+func FilterByName(name string) (LDAPFilter, bool) {
+	filtersMu.RLock()
+	defer filtersMu.RUnlock()
+	f, ok := filtersByName[name]
+	return f, ok
+}
+
+// CustomFilters 返回用户自定义的过滤器列表
+func CustomFilters() []LDAPFilter {
+	filtersMu.RLock()
+	defer filtersMu.RUnlock()
+	return append([]LDAPFilter(nil), customFilters...)
+}
+
+// AllFilters 返回内置过滤器和自定义过滤器的合并列表
+func AllFilters() []LDAPFilter {
+	filtersMu.RLock()
+	defer filtersMu.RUnlock()
+	return allFiltersLocked()
+}
+
+// allFiltersLocked 返回内置过滤器和自定义过滤器合并后的新列表，调用方需持有filtersMu
+func allFiltersLocked() []LDAPFilter {
+	return append(FiltersForDirectoryType(filterDirectoryType), customFilters...)
+}
+
+// ValidateDN 校验DN语法，空DN或无法解析的DN返回错误
+func ValidateDN(dn string) error {
+	if strings.TrimSpace(dn) == "" {
+		return errors.New("DN不能为空")
+	}
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return fmt.Errorf("DN格式无效: %v", err)
+	}
+	if len(parsed.RDNs) == 0 {
+		return errors.New("DN不能为空")
+	}
+	return nil
+}
+
+// SplitDN 将DN拆分为第一个RDN和父DN，保留原有写法；根级或无效的DN返回错误
+func SplitDN(dn string) (string, string, error) {
+	if err := ValidateDN(dn); err != nil {
+		return "", "", err
+	}
+
+	// 查找第一个未转义且不在引号内的分隔符
+	inQuotes := false
+	for i := 0; i < len(dn); i++ {
+		switch dn[i] {
+		case '\\':
+			i++
+		case '"':
+			inQuotes = !inQuotes
+		case ',', ';':
+			if parent := trimDNSpace(dn[i+1:]); !inQuotes && parent != "" {
+				return trimDNSpace(dn[:i]), parent, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("DN %s 没有父级，无法在根级操作", dn)
+}
+
+// trimDNSpace 去除DN片段两端的空格，保留值末尾转义的空格（"\ "）
+func trimDNSpace(s string) string {
+	s = strings.TrimLeft(s, " ")
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\\ ") {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// EscapeRDNValue 按RFC 4514转义RDN的属性值，如"Smith, John"转义为"Smith\, John"
+// 在ldap.EscapeDN的基础上同时转义"="，避免部分工具把值中的等号误认为属性分隔
+func EscapeRDNValue(value string) string {
+	return strings.ReplaceAll(ldap.EscapeDN(value), "=", "\\=")
+}
+
+// RDN 由属性类型和未转义的值构建RDN，如RDN("CN", "Smith, John")返回"CN=Smith\, John"
+func RDN(attrType string, value string) string {
+	return attrType + "=" + EscapeRDNValue(value)
+}
 
-	// 获取第一部分（通常是CN=username）
-	firstPart := parts[0]
-	if !strings.HasPrefix(strings.ToUpper(firstPart), "CN=") {
+// FirstRDNValue 返回DN第一个RDN的属性值（已反转义），属性类型不是attrType（不区分大小写）或DN无效时返回空字符串
+func FirstRDNValue(dn string, attrType string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
 		return ""
 	}
+	rdn := parsed.RDNs[0].Attributes[0]
+	if !strings.EqualFold(rdn.Type, attrType) {
+		return ""
+	}
+	return rdn.Value
+}
+
+// ParentDN 返回DN的父DN，根级或无效的DN返回错误
+func ParentDN(dn string) (string, error) {
+	_, parent, err := SplitDN(dn)
+	return parent, err
+}
 
-	// 返回CN=后面的部分
-	return strings.TrimPrefix(firstPart, "CN=")
+// NormalizeDN 返回规范化后的DN，解析失败时原样返回
+func NormalizeDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return dn
+	}
+	return parsed.String()
+}
+
+// ExtractUsernameFromDN 从DN中提取用户名（第一个RDN为CN时的值，已反转义）
+func ExtractUsernameFromDN(dn string) string {
+	return FirstRDNValue(dn, "CN")
 }
 
 // MoveUser 移动用户到新位置
 func (client *LDAPClient) MoveUser(oldDN, newDN string) error {
-	// 获取有效连接
-	conn, err := client.GetConnection()
+	if err := ValidateDN(oldDN); err != nil {
+		return fmt.Errorf("原DN无效: %v", err)
+	}
+	newRDN, newSuperior, err := SplitDN(newDN)
 	if err != nil {
-		return fmt.Errorf("连接失败: %v", err)
+		return fmt.Errorf("目标DN无效: %v", err)
+	}
+
+	// 执行移动操作
+	modifyDNRequest := ldap.NewModifyDNRequest(oldDN, newRDN, true, newSuperior)
+	return client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modifyDN(conn, modifyDNRequest)
+	})
+}
+
+// RenameEntry 在原容器内重命名条目（只修改RDN，不移动位置），新旧RDN相同时不做任何操作
+func (client *LDAPClient) RenameEntry(dn string, newRDN string, deleteOldRDN bool) error {
+	currentRDN, _, err := SplitDN(dn)
+	if err != nil {
+		return fmt.Errorf("DN无效: %v", err)
+	}
+	if err := ValidateDN(newRDN); err != nil {
+		return fmt.Errorf("新RDN无效: %v", err)
+	}
+	if parsed, _ := ldap.ParseDN(newRDN); len(parsed.RDNs) != 1 {
+		return fmt.Errorf("新RDN只能包含一个组件: %s", newRDN)
+	}
+	if NormalizeDN(currentRDN) == NormalizeDN(newRDN) {
+		client.Info("新RDN与当前RDN相同，无需重命名：%s", dn)
+		return nil
 	}
 
-	// 分解新旧DN
-	oldParts := strings.SplitN(oldDN, ",", 2)
-	newParts := strings.SplitN(newDN, ",", 2)
-	if len(oldParts) != 2 || len(newParts) != 2 {
-		return fmt.Errorf("无效的DN格式")
+	client.Debug("重命名条目：%s -> %s，删除旧RDN：%v", dn, newRDN, deleteOldRDN)
+	modifyDNRequest := ldap.NewModifyDNRequest(dn, newRDN, deleteOldRDN, "")
+	err = client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modifyDN(conn, modifyDNRequest)
+	})
+	if err != nil {
+		return fmt.Errorf("重命名失败: %s", ParseLDAPError(err))
 	}
 
-	// 提取新旧RDN和新的父DN
-	newRDN := newParts[0]
-	newSuperior := newParts[1]
+	client.Info("已重命名条目：%s -> %s", dn, newRDN)
+	return nil
+}
+
+// DeleteEntry 删除条目（条目不能有子条目）
+func (client *LDAPClient) DeleteEntry(dn string) error {
+	if err := ValidateDN(dn); err != nil {
+		return fmt.Errorf("DN无效: %v", err)
+	}
 
-	// 执行移动操作
-	modifyDNRequest := ldap.NewModifyDNRequest(oldDN, newRDN, true, newSuperior)
-	if err := conn.ModifyDN(modifyDNRequest); err != nil {
-		return err
+	err := client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.del(conn, ldap.NewDelRequest(dn, nil))
+	})
+	if err != nil {
+		return fmt.Errorf("删除条目失败: %s", ParseLDAPError(err))
 	}
 
+	client.Info("已删除条目：%s", dn)
 	return nil
 }
 
@@ -94,6 +356,11 @@ func ParseLDAPError(err error) string {
 
 	// 检查是否是LDAP错误
 	if ldapErr, ok := err.(*ldap.Error); ok {
+		// AD在诊断信息中附带更具体的子错误码
+		if code, explanation, found := ParseADErrorCode(ldapErr); found {
+			return explanation + " (data " + code + ")"
+		}
+
 		switch ldapErr.ResultCode {
 		case ldap.LDAPResultInsufficientAccessRights:
 			return "权限不足"
@@ -122,29 +389,74 @@ func ParseLDAPError(err error) string {
 
 // UpdateUserPassword 更新用户密码
 func (client *LDAPClient) UpdateUserPassword(userDN string, newPassword string) error {
-	// 获取有效连接
-	conn, err := client.GetConnection()
-	if err != nil {
-		return fmt.Errorf("连接失败: %v", err)
-	}
-
 	// 创建修改请求
 	modifyRequest := ldap.NewModifyRequest(userDN, nil)
 
-	// 将密码转换为UTF-16LE格式
-	utf16Password := EncodePassword(newPassword)
+	// 根据目录类型编码密码（AD使用unicodePwd，OpenLDAP使用userPassword）
+	passwordAttr, encodedPassword, err := client.encodePasswordAttribute(newPassword)
+	if err != nil {
+		return fmt.Errorf("更新密码失败: %v", err)
+	}
 
 	// 替换密码属性
-	modifyRequest.Replace("unicodePwd", []string{utf16Password})
+	modifyRequest.Replace(passwordAttr, []string{encodedPassword})
 
 	// 执行修改
-	if err := conn.Modify(modifyRequest); err != nil {
+	err = client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modify(conn, modifyRequest)
+	})
+	if err != nil {
 		return fmt.Errorf("更新密码失败: %v", err)
 	}
 
 	return nil
 }
 
+// ChangePasswordExOp 使用密码修改扩展操作（RFC 3062）修改密码
+// 提供oldPassword时以用户本人身份绑定（自助修改），否则使用当前管理员连接；
+// newPassword为空时由服务器生成新密码并返回，生成的密码不会写入日志
+func (client *LDAPClient) ChangePasswordExOp(userDN string, oldPassword string, newPassword string) (string, error) {
+	if err := client.checkWritable("PasswordModify", userDN); err != nil {
+		return "", err
+	}
+	var conn *ldap.Conn
+	if oldPassword != "" {
+		userConn, err := client.dial()
+		if err != nil {
+			return "", fmt.Errorf("连接失败: %v", err)
+		}
+		defer userConn.Close()
+		defer client.closeOnCancel(userConn)()
+		if err := userConn.Bind(userDN, oldPassword); err != nil {
+			return "", fmt.Errorf("使用旧密码绑定失败: %s", ParseLDAPError(err))
+		}
+		conn = userConn
+	} else {
+		adminConn, release, err := client.acquireConnection()
+		if err != nil {
+			return "", fmt.Errorf("连接失败: %v", err)
+		}
+		defer release()
+		conn = adminConn
+	}
+
+	request := ldap.NewPasswordModifyRequest(userDN, oldPassword, newPassword)
+	result, err := conn.PasswordModify(request)
+	if err != nil {
+		return "", fmt.Errorf("密码修改扩展操作失败: %s", ParseLDAPError(err))
+	}
+
+	if newPassword == "" {
+		if result.GeneratedPassword == "" {
+			client.Warn("服务器未返回生成的密码")
+		} else {
+			client.Info("服务器已生成新密码")
+		}
+	}
+	client.Info("密码已通过扩展操作修改：%s", userDN)
+	return result.GeneratedPassword, nil
+}
+
 // HandleGroupMembership 处理用户的组成员关系
 func (client *LDAPClient) HandleGroupMembership(userDN string, groupDN string) error {
 	// 获取有效连接
@@ -160,51 +472,138 @@ func (client *LDAPClient) HandleGroupMembership(userDN string, groupDN string) e
 	return nil
 }
 
-// BuildDN 构建完整的DN
+// BuildDN 将"类型=值"形式的RDN依次拼接为完整的DN，parts中的值为未转义的原始值，拼接时按RFC 4514转义
 func (client *LDAPClient) BuildDN(parts []string) string {
 	var currentDN string
 	for i, part := range parts {
 		if i > 0 {
 			currentDN += ","
 		}
+		if attrType, value, ok := strings.Cut(part, "="); ok {
+			part = RDN(attrType, value)
+		}
 		currentDN += part
 		client.Debug("已创建DN部分: %s", currentDN)
 	}
 	return currentDN
 }
 
-// CreateDN 创建DN
+// containerType 自动创建路径时某种RDN类型使用的对象类和命名属性
+type containerType struct {
+	objectClass     string
+	namingAttribute string
+}
+
+// containerTypes 自动创建路径时各RDN类型的定义，DC组件不创建
+var containerTypes = map[string]containerType{
+	"OU": {objectClass: "organizationalUnit", namingAttribute: "ou"},
+	"CN": {objectClass: "container", namingAttribute: "cn"},
+}
+
+// containerAddRequest 为路径中的一级构建添加请求，DC组件返回nil表示跳过
+func containerAddRequest(dn string) (*ldap.AddRequest, error) {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return nil, fmt.Errorf("无效的DN格式：%s", dn)
+	}
+	rdn := parsed.RDNs[0].Attributes[0]
+	rdnType := strings.ToUpper(rdn.Type)
+	if rdnType == "DC" {
+		return nil, nil
+	}
+
+	container, ok := containerTypes[rdnType]
+	if !ok {
+		return nil, fmt.Errorf("不支持自动创建 %s 类型的路径：%s", rdn.Type, dn)
+	}
+	add := ldap.NewAddRequest(dn, nil)
+	add.Attribute("objectClass", []string{"top", container.objectClass})
+	add.Attribute(container.namingAttribute, []string{rdn.Value})
+	return add, nil
+}
+
+// dnLevels 返回DN自身及其所有上级DN，由下至上排列
+func dnLevels(dn string) ([]string, error) {
+	if err := ValidateDN(dn); err != nil {
+		return nil, err
+	}
+	levels := []string{dn}
+	for {
+		_, parent, err := SplitDN(levels[len(levels)-1])
+		if err != nil {
+			return levels, nil
+		}
+		levels = append(levels, parent)
+	}
+}
+
+// missingDNLevels 返回DN及其上级中不存在且需要创建的容器，由上至下排列，DC组件不计入
+func (client *LDAPClient) missingDNLevels(conn *ldap.Conn, dn string) ([]string, error) {
+	levels, err := dnLevels(dn)
+	if err != nil {
+		return nil, fmt.Errorf("无效的DN格式：%s: %v", dn, err)
+	}
+	var missing []string
+	for i := len(levels) - 1; i >= 0; i-- {
+		add, err := containerAddRequest(levels[i])
+		if err != nil {
+			return nil, err
+		}
+		if add == nil {
+			continue
+		}
+		if len(missing) == 0 {
+			// 一旦某级缺失，其下各级必然缺失，无需再检查
+			if _, err := readEntry(conn, levels[i], []string{"1.1"}); err == nil {
+				continue
+			} else if !ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+				return nil, fmt.Errorf("检查DN失败: %s", ParseLDAPError(err))
+			}
+		}
+		missing = append(missing, levels[i])
+	}
+	return missing, nil
+}
+
+// CreateDN 由上至下逐级创建DN：OU创建为organizationalUnit，CN创建为container，DC跳过
 func (client *LDAPClient) CreateDN(dn string) error {
 	client.Debug("正在创建DN：%s", dn)
-	// 确保连接有效
-	conn, err := client.GetConnection()
+	levels, err := dnLevels(dn)
 	if err != nil {
-		return fmt.Errorf("创建DN时连接失败: %v", err)
+		return fmt.Errorf("无效的DN格式：%s: %v", dn, err)
 	}
 
-	// 解析DN
-	parts := strings.Split(dn, ",")
-	if len(parts) == 0 {
-		return fmt.Errorf("无效的DN格式：%s", dn)
+	// 确保连接有效
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return fmt.Errorf("创建DN时连接失败: %v", err)
 	}
+	defer release()
 
 	// 创建DN的各个部分
-	for i := len(parts) - 1; i >= 0; i-- {
-		currentDN := strings.Join(parts[i:], ",")
-		client.Debug("正在创建DN部分：%s", currentDN)
+	for i := len(levels) - 1; i >= 0; i-- {
+		currentDN := levels[i]
 
 		// 构建添加请求
-		add := ldap.NewAddRequest(currentDN, nil)
-		add.Attribute("objectClass", []string{"container"})
+		add, err := containerAddRequest(currentDN)
+		if err != nil {
+			return err
+		}
+		if add == nil {
+			// 域组件由目录本身提供，不能创建
+			client.Debug("跳过域组件：%s", currentDN)
+			continue
+		}
+		client.Debug("正在创建DN部分：%s", currentDN)
 
 		// 执行添加
-		if err := conn.Add(add); err != nil {
-			if ldapErr, ok := err.(*ldap.Error); ok && ldapErr.ResultCode == 68 {
+		if err := client.add(conn, add); err != nil {
+			if ldap.IsErrorWithCode(err, ldap.LDAPResultEntryAlreadyExists) {
 				// 已存在，继续下一个
 				client.Debug("DN部分已存在：%s", currentDN)
 				continue
 			}
-			return fmt.Errorf("创建DN部分失败: %v", err)
+			return fmt.Errorf("创建DN部分失败: %s", ParseLDAPError(err))
 		}
 
 		client.Debug("成功创建DN部分：%s", currentDN)
@@ -0,0 +1,297 @@
+package ldap
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestChangePasswordExOpReturnsGeneratedPassword 服务器生成的密码返回给调用方，不写入日志
+func TestChangePasswordExOpReturnsGeneratedPassword(t *testing.T) {
+	server := newFakeServer(t)
+	server.generatedPassword = "Generated#Pass9"
+	log, output := captureLogger()
+	client := server.client(log)
+
+	generated, err := client.ChangePasswordExOp("cn=user1,dc=example,dc=com", "", "")
+	if err != nil {
+		t.Fatalf("ChangePasswordExOp失败: %v", err)
+	}
+	if generated != "Generated#Pass9" {
+		t.Errorf("生成的密码 = %q", generated)
+	}
+	if strings.Contains(output(), "Generated#Pass9") {
+		t.Error("生成的密码出现在日志中")
+	}
+}
+
+func TestEncodeSSHAWithSalt(t *testing.T) {
+	salt := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	const want = "{SSHA}lHFzXul4wnzRItssVcTnvXWRjNgBAgMEBQYHCA=="
+	if got := encodeSSHAWithSalt("secret", salt); got != want {
+		t.Errorf("encodeSSHAWithSalt = %q, want %q", got, want)
+	}
+}
+
+// TestEncodeUserPasswordSSHA 随机盐编码的结果应能用其中的盐重新验证密码
+func TestEncodeUserPasswordSSHA(t *testing.T) {
+	encoded, err := EncodeUserPasswordSSHA("secret")
+	if err != nil {
+		t.Fatalf("EncodeUserPasswordSSHA失败: %v", err)
+	}
+	digest, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, "{SSHA}"))
+	if err != nil || len(digest) != sha1.Size+8 {
+		t.Fatalf("无效的SSHA值: %q", encoded)
+	}
+	if again := encodeSSHAWithSalt("secret", digest[sha1.Size:]); again != encoded {
+		t.Errorf("使用相同的盐重新编码得到 %q, want %q", again, encoded)
+	}
+}
+
+func TestValidateDN(t *testing.T) {
+	tests := []struct {
+		dn    string
+		valid bool
+	}{
+		{"", false},
+		{"   ", false},
+		{"cn", false},
+		{"=value", false},
+		{"cn=a,", false},
+		{"cn=a,,dc=com", false},
+		{`cn=a\zz,dc=com`, false},
+		{"dc=com", true},
+		{"cn=user1,ou=Users,dc=example,dc=com", true},
+		{`cn=Smith\, John,dc=example,dc=com`, true},
+		{"cn=a+sn=b,dc=com", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateDN(tt.dn); (err == nil) != tt.valid {
+			t.Errorf("ValidateDN(%q) = %v, valid = %v", tt.dn, err, tt.valid)
+		}
+	}
+}
+
+func TestSplitDN(t *testing.T) {
+	tests := []struct {
+		dn     string
+		rdn    string
+		parent string
+	}{
+		{"cn=user1,ou=Users,dc=example,dc=com", "cn=user1", "ou=Users,dc=example,dc=com"},
+		{"cn=user1, ou=Users , dc=com", "cn=user1", "ou=Users , dc=com"},
+		{"cn=a;dc=com", "cn=a", "dc=com"},
+		{`cn=Smith\, John,ou=Users,dc=com`, `cn=Smith\, John`, "ou=Users,dc=com"},
+		{`cn=trailing\ ,dc=com`, `cn=trailing\ `, "dc=com"},
+		{`cn=a,dc=b\ `, "cn=a", `dc=b\ `},
+		{"cn=a+sn=b,dc=com", "cn=a+sn=b", "dc=com"},
+	}
+	for _, tt := range tests {
+		rdn, parent, err := SplitDN(tt.dn)
+		if err != nil || rdn != tt.rdn || parent != tt.parent {
+			t.Errorf("SplitDN(%q) = %q, %q, %v, want %q, %q", tt.dn, rdn, parent, err, tt.rdn, tt.parent)
+		}
+	}
+
+	// 空DN、单个RDN（根级）和格式错误的DN都无法拆分
+	for _, dn := range []string{"", "dc=com", "cn=user1", "cn", "cn=a,", "cn=a,,dc=com"} {
+		if _, _, err := SplitDN(dn); err == nil {
+			t.Errorf("SplitDN(%q) 应返回错误", dn)
+		}
+	}
+}
+
+func TestContainerAddRequest(t *testing.T) {
+	tests := []struct {
+		dn          string
+		objectClass string
+		naming      string
+		value       string
+	}{
+		{"OU=Sales,DC=example,DC=com", "organizationalUnit", "ou", "Sales"},
+		{"ou=Sales,dc=example,dc=com", "organizationalUnit", "ou", "Sales"},
+		{"CN=Staff,OU=Sales,DC=example,DC=com", "container", "cn", "Staff"},
+		{`OU=R\, D,DC=example,DC=com`, "organizationalUnit", "ou", "R, D"},
+	}
+	for _, tt := range tests {
+		add, err := containerAddRequest(tt.dn)
+		if err != nil || add == nil {
+			t.Errorf("containerAddRequest(%q) = %v, %v", tt.dn, add, err)
+			continue
+		}
+		attrs := map[string][]string{}
+		for _, attr := range add.Attributes {
+			attrs[attr.Type] = attr.Vals
+		}
+		if classes := attrs["objectClass"]; len(classes) != 2 || classes[1] != tt.objectClass {
+			t.Errorf("%q 的objectClass = %v, want %s", tt.dn, classes, tt.objectClass)
+		}
+		if values := attrs[tt.naming]; len(values) != 1 || values[0] != tt.value {
+			t.Errorf("%q 的%s = %v, want %q", tt.dn, tt.naming, values, tt.value)
+		}
+	}
+
+	if add, err := containerAddRequest("DC=example,DC=com"); add != nil || err != nil {
+		t.Errorf("DC组件应跳过: %v, %v", add, err)
+	}
+	for _, dn := range []string{"O=Org,DC=com", "L=City,DC=com", "cn", ""} {
+		if _, err := containerAddRequest(dn); err == nil {
+			t.Errorf("containerAddRequest(%q) 应返回错误", dn)
+		}
+	}
+}
+
+// TestCreateDN 由上至下创建OU和CN容器，跳过DC组件和已存在的容器
+func TestCreateDN(t *testing.T) {
+	server := newFakeServer(t)
+	server.entries = map[string]bool{"dc=example,dc=com": true, "ou=sales,dc=example,dc=com": true}
+	log, _ := captureLogger()
+	client := server.client(log)
+
+	if err := client.CreateDN("CN=Staff,OU=Team,OU=Sales,DC=example,DC=com"); err != nil {
+		t.Fatalf("CreateDN失败: %v", err)
+	}
+
+	want := []string{
+		"OU=Sales,DC=example,DC=com",
+		"OU=Team,OU=Sales,DC=example,DC=com",
+		"CN=Staff,OU=Team,OU=Sales,DC=example,DC=com",
+	}
+	if got := server.addedDNs(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("添加的条目 = %q, want %q", got, want)
+	}
+	if classes := server.added[1].Attributes["objectClass"]; classes[1] != "organizationalUnit" {
+		t.Errorf("OU的objectClass = %v", classes)
+	}
+	if classes := server.added[2].Attributes["objectClass"]; classes[1] != "container" {
+		t.Errorf("CN的objectClass = %v", classes)
+	}
+
+	if err := client.CreateDN("O=Org,DC=example,DC=com"); err == nil {
+		t.Error("不支持的RDN类型应返回错误")
+	}
+}
+
+// TestEnsureDNExistsConfirm 只把缺失的容器交给确认函数，拒绝时不创建任何条目
+func TestEnsureDNExistsConfirm(t *testing.T) {
+	server := newFakeServer(t)
+	server.entries = map[string]bool{"dc=example,dc=com": true, "ou=sales,dc=example,dc=com": true}
+	log, _ := captureLogger()
+	client := server.client(log)
+
+	var asked []string
+	client.SetContainerConfirm(func(missing []string) bool {
+		asked = missing
+		return false
+	})
+	err := client.EnsureDNExists("CN=Staff,OU=Team,OU=Sales,DC=example,DC=com")
+	if err != ErrContainerCreationDeclined {
+		t.Fatalf("EnsureDNExists = %v, want ErrContainerCreationDeclined", err)
+	}
+	want := []string{"OU=Team,OU=Sales,DC=example,DC=com", "CN=Staff,OU=Team,OU=Sales,DC=example,DC=com"}
+	if strings.Join(asked, "|") != strings.Join(want, "|") {
+		t.Errorf("确认的容器 = %q, want %q", asked, want)
+	}
+	if added := server.addedDNs(); len(added) != 0 {
+		t.Errorf("拒绝后仍创建了 %q", added)
+	}
+}
+
+func TestEscapeRDNValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Smith, John", `Smith\, John`},
+		{"#hash", `\#hash`},
+		{"a#b", "a#b"},
+		{" leading", `\ leading`},
+		{"trailing ", `trailing\ `},
+		{"R+D", `R\+D`},
+		{"x=y", `x\=y`},
+		{`back\slash`, `back\\slash`},
+		{"plain", "plain"},
+	}
+	for _, tt := range tests {
+		if got := EscapeRDNValue(tt.value); got != tt.want {
+			t.Errorf("EscapeRDNValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+		// 转义后的值应能被解析回原值
+		dn := RDN("CN", tt.value) + ",DC=example,DC=com"
+		if got := FirstRDNValue(dn, "cn"); got != tt.value {
+			t.Errorf("FirstRDNValue(%q) = %q, want %q", dn, got, tt.value)
+		}
+	}
+}
+
+func TestRDN(t *testing.T) {
+	if got := RDN("CN", "Smith, John"); got != `CN=Smith\, John` {
+		t.Errorf("RDN = %q", got)
+	}
+	if got := RDN("OU", "#1 Team"); got != `OU=\#1 Team` {
+		t.Errorf("RDN = %q", got)
+	}
+	if got := FirstRDNValue(`CN=Smith\, John,DC=com`, "ou"); got != "" {
+		t.Errorf("属性类型不匹配时应返回空字符串，得到 %q", got)
+	}
+}
+
+func TestBuildDN(t *testing.T) {
+	log, _ := captureLogger()
+	client := NewLDAPClient("127.0.0.1", 389, "", "", log, nil, false, false)
+
+	dn := client.BuildDN([]string{"CN=Smith, John", "OU=R+D", "OU= Sales", "DC=example", "DC=com"})
+	if want := `CN=Smith\, John,OU=R\+D,OU=\ Sales,DC=example,DC=com`; dn != want {
+		t.Fatalf("BuildDN = %q, want %q", dn, want)
+	}
+	if err := ValidateDN(dn); err != nil {
+		t.Errorf("BuildDN生成的DN无效: %v", err)
+	}
+	rdn, parent, err := SplitDN(dn)
+	if err != nil || rdn != `CN=Smith\, John` || parent != `OU=R\+D,OU=\ Sales,DC=example,DC=com` {
+		t.Errorf("SplitDN(%q) = %q, %q, %v", dn, rdn, parent, err)
+	}
+}
+
+// TestFiltersConcurrentAccess 切换目录类型和添加自定义过滤器时可以同时查找过滤器（使用-race运行）
+func TestFiltersConcurrentAccess(t *testing.T) {
+	defer func() {
+		filtersMu.Lock()
+		customFilters = nil
+		filtersMu.Unlock()
+		SetFilterDirectoryType(DirectoryTypeAD)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if j%2 == 0 {
+					SetFilterDirectoryType(DirectoryTypeOpenLDAP)
+				} else {
+					SetFilterDirectoryType(DirectoryTypeAD)
+				}
+				AddCustomFilter(LDAPFilter{Name: fmt.Sprintf("自定义%d", i), Pattern: "(uid=%s)"})
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				for _, f := range AllFilters() {
+					FilterByName(f.Name)
+				}
+				CustomFilters()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if f, ok := FilterByName("自定义0"); !ok || f.Pattern != "(uid=%s)" {
+		t.Errorf("FilterByName(自定义0) = %v, %v", f, ok)
+	}
+}
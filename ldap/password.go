@@ -0,0 +1,114 @@
+package ldap
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// 默认AD密码策略
+const (
+	MinPasswordLength      = 7
+	MinPasswordCharClasses = 3
+	minUsernameCheckLength = 3
+)
+
+// 随机密码使用的字符集（去掉了容易混淆的字符）
+const (
+	passwordUpper   = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordLower   = "abcdefghijkmnopqrstuvwxyz"
+	passwordDigits  = "23456789"
+	passwordSymbols = "!@#$%^&*-_=+?"
+)
+
+// GeneratePassword 使用crypto/rand生成满足AD复杂性要求的随机密码
+func GeneratePassword(length int, username string) (string, error) {
+	if length < 4 {
+		length = 4
+	}
+	classes := []string{passwordUpper, passwordLower, passwordDigits, passwordSymbols}
+	all := strings.Join(classes, "")
+
+	for {
+		password := make([]byte, 0, length)
+		// 每类字符至少一个
+		for _, class := range classes {
+			c, err := randomChar(class)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, c)
+		}
+		for len(password) < length {
+			c, err := randomChar(all)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, c)
+		}
+
+		// 打乱顺序，避免固定的字符类别位置
+		for i := len(password) - 1; i > 0; i-- {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+			if err != nil {
+				return "", err
+			}
+			j := int(n.Int64())
+			password[i], password[j] = password[j], password[i]
+		}
+
+		if len(CheckPasswordComplexity(string(password), username)) == 0 {
+			return string(password), nil
+		}
+	}
+}
+
+// randomChar 从字符集中随机选取一个字符
+func randomChar(charset string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+	if err != nil {
+		return 0, err
+	}
+	return charset[n.Int64()], nil
+}
+
+// CheckPasswordComplexity 按AD默认密码策略检查密码，返回未满足的规则列表
+func CheckPasswordComplexity(password string, username string) []string {
+	var problems []string
+
+	if len([]rune(password)) < MinPasswordLength {
+		problems = append(problems, "密码长度至少为7个字符")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	classes := 0
+	for _, has := range []bool{hasUpper, hasLower, hasDigit, hasSymbol} {
+		if has {
+			classes++
+		}
+	}
+	if classes < MinPasswordCharClasses {
+		problems = append(problems, "密码需包含大写字母、小写字母、数字、特殊字符中的至少3类")
+	}
+
+	// AD仅在账户名长度不少于3时检查密码是否包含账户名
+	if len([]rune(username)) >= minUsernameCheckLength &&
+		strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		problems = append(problems, "密码不能包含账户名")
+	}
+
+	return problems
+}
@@ -0,0 +1,128 @@
+package ldap
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// DefaultPoolSize 连接池默认的最大连接数
+const DefaultPoolSize = 4
+
+// ErrPoolClosed 连接池已关闭
+var ErrPoolClosed = errors.New("连接池已关闭")
+
+// ConnPool 使用客户端的地址、TLS和绑定设置建立连接的简单连接池
+// 同时借出的连接数不超过maxSize，归还的连接在下次借出前会检查是否可用
+type ConnPool struct {
+	client  *LDAPClient
+	maxSize int
+	slots   chan struct{} // 限制同时借出的连接数
+	mu      sync.Mutex
+	idle    []*ldap.Conn
+	closed  bool
+}
+
+// NewConnPool 创建连接池，maxSize小于1时使用DefaultPoolSize
+func NewConnPool(client *LDAPClient, maxSize int) *ConnPool {
+	if maxSize < 1 {
+		maxSize = DefaultPoolSize
+	}
+	return &ConnPool{
+		client:  client,
+		maxSize: maxSize,
+		slots:   make(chan struct{}, maxSize),
+	}
+}
+
+// Get 借出一个可用连接，已达到最大连接数时等待其他连接归还
+// 空闲连接失效时丢弃并新建连接，新连接使用客户端的凭据绑定
+func (pool *ConnPool) Get() (*ldap.Conn, error) {
+	select {
+	case pool.slots <- struct{}{}:
+	case <-pool.client.ctx.Done():
+		return nil, ErrOperationCanceled
+	}
+
+	for {
+		pool.mu.Lock()
+		if pool.closed {
+			pool.mu.Unlock()
+			<-pool.slots
+			return nil, ErrPoolClosed
+		}
+		if len(pool.idle) == 0 {
+			pool.mu.Unlock()
+			break
+		}
+		conn := pool.idle[len(pool.idle)-1]
+		pool.idle = pool.idle[:len(pool.idle)-1]
+		pool.mu.Unlock()
+
+		if pool.client.isConnAlive(conn) {
+			return conn, nil
+		}
+		pool.client.Debug("连接池中的连接已失效，丢弃")
+		conn.Close()
+	}
+
+	conn, err := pool.client.GetConnection()
+	if err != nil {
+		<-pool.slots
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Put 归还连接，连接池已关闭时直接关闭连接
+func (pool *ConnPool) Put(conn *ldap.Conn) {
+	if conn == nil {
+		return
+	}
+	pool.mu.Lock()
+	if pool.closed || conn.IsClosing() {
+		conn.Close()
+	} else {
+		pool.idle = append(pool.idle, conn)
+	}
+	pool.mu.Unlock()
+	<-pool.slots
+}
+
+// Close 关闭所有空闲连接，借出的连接在归还时关闭
+func (pool *ConnPool) Close() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.closed {
+		return
+	}
+	pool.closed = true
+	for _, conn := range pool.idle {
+		conn.Close()
+	}
+	pool.idle = nil
+}
+
+// UseConnPool 启用连接池，之后非共享模式的操作从池中获取连接，直到调用ReleaseConnPool
+func (client *LDAPClient) UseConnPool(maxSize int) *ConnPool {
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	if client.pool == nil {
+		client.pool = NewConnPool(client, maxSize)
+		client.Debug("已启用连接池，最大连接数：%d", client.pool.maxSize)
+	}
+	return client.pool
+}
+
+// ReleaseConnPool 关闭连接池，之后的操作恢复为每次单独连接
+func (client *LDAPClient) ReleaseConnPool() {
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	if client.pool == nil {
+		return
+	}
+	client.pool.Close()
+	client.pool = nil
+	client.Debug("已释放连接池")
+}
@@ -0,0 +1,138 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 全局编录端口（仅AD）
+const (
+	GlobalCatalogPort    = 3268
+	GlobalCatalogSSLPort = 3269
+)
+
+// PortProbe 单个端口的探测结果
+type PortProbe struct {
+	Port    int
+	Name    string // 服务名称，如LDAP、LDAPS
+	SSL     bool   // 是否为直接TLS端口
+	Open    bool
+	OpenErr error  // 端口未开放的原因
+	TLS     string // TLS握手成功时的协议版本和加密套件
+	TLSErr  error  // TLS握手失败的原因
+	CertErr error  // 握手本身成功但证书验证失败的原因
+}
+
+// TLSStatus 返回TLS握手结果的简要描述
+func (probe PortProbe) TLSStatus() string {
+	switch {
+	case !probe.SSL:
+		return "-"
+	case !probe.Open:
+		return "未测试"
+	case probe.TLSErr != nil:
+		return "失败：" + probe.TLSErr.Error()
+	case probe.CertErr != nil:
+		return "成功（证书验证失败：" + probe.CertErr.Error() + "）"
+	}
+	return "成功（" + probe.TLS + "）"
+}
+
+// PortProbeResult 多个端口的探测结果，按探测顺序排列
+type PortProbeResult struct {
+	Host   string
+	Probes []PortProbe
+}
+
+// Summary 以矩阵形式返回探测结果，每个端口一行
+func (r *PortProbeResult) Summary() []string {
+	lines := []string{fmt.Sprintf("%-6s %-10s %-8s %s", "端口", "服务", "TCP", "TLS握手")}
+	for _, probe := range r.Probes {
+		open := "关闭"
+		if probe.Open {
+			open = "开放"
+		}
+		lines = append(lines, fmt.Sprintf("%-6d %-10s %-8s %s", probe.Port, probe.Name, open, probe.TLSStatus()))
+	}
+	return lines
+}
+
+// OpenPorts 返回开放的端口
+func (r *PortProbeResult) OpenPorts() []int {
+	var ports []int
+	for _, probe := range r.Probes {
+		if probe.Open {
+			ports = append(ports, probe.Port)
+		}
+	}
+	return ports
+}
+
+// ProbePorts 同时探测389（LDAP）和636（LDAPS），includeGC为true时同时探测3268/3269（全局编录）
+// 端口开放后，TLS端口再按当前证书设置尝试握手，证书验证失败时改为不验证握手以区分证书问题和TLS不可用
+func (client *LDAPClient) ProbePorts(includeGC bool) *PortProbeResult {
+	probes := []PortProbe{
+		{Port: DefaultLDAPPort, Name: "LDAP"},
+		{Port: DefaultLDAPSPort, Name: "LDAPS", SSL: true},
+	}
+	if includeGC {
+		probes = append(probes,
+			PortProbe{Port: GlobalCatalogPort, Name: "GC"},
+			PortProbe{Port: GlobalCatalogSSLPort, Name: "GC-SSL", SSL: true},
+		)
+	}
+	client.Debug("正在探测 %s 的端口：%d 个", client.Host, len(probes))
+
+	var wg sync.WaitGroup
+	for i := range probes {
+		wg.Add(1)
+		go func(probe *PortProbe) {
+			defer wg.Done()
+			client.probePort(probe)
+		}(&probes[i])
+	}
+	wg.Wait()
+
+	return &PortProbeResult{Host: client.Host, Probes: probes}
+}
+
+// probePort 探测单个端口，结果写入probe
+func (client *LDAPClient) probePort(probe *PortProbe) {
+	if err := client.dialPort(probe.Port); err != nil {
+		probe.OpenErr = err
+		return
+	}
+	probe.Open = true
+	if !probe.SSL {
+		return
+	}
+
+	state, err := client.tlsHandshake(probe.Port, false)
+	if err != nil && isCertificateError(err) {
+		probe.CertErr = err
+		state, err = client.tlsHandshake(probe.Port, true)
+	}
+	if err != nil {
+		probe.TLSErr = err
+		return
+	}
+	probe.TLS = tls.VersionName(state.Version) + " " + tls.CipherSuiteName(state.CipherSuite)
+}
+
+// tlsHandshake 与指定端口完成TLS握手后立即断开，insecure为true时不验证证书
+func (client *LDAPClient) tlsHandshake(port int, insecure bool) (tls.ConnectionState, error) {
+	config := client.GetTLSConfig()
+	if insecure {
+		config.InsecureSkipVerify = true
+	}
+	conn, err := client.dialContext(net.JoinHostPort(client.Host, strconv.Itoa(port)), config, 5*time.Second)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState(), nil
+}
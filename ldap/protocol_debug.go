@@ -0,0 +1,208 @@
+package ldap
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// maskedValue 日志中代替密码的文本
+const maskedValue = "[受保护]"
+
+// IsPasswordAttribute 判断属性是否为密码属性（unicodePwd、userPassword等），记录日志时需要隐藏其值
+// 按后缀判断，避免误伤badPasswordTime、pwdLastSet等非敏感属性
+func IsPasswordAttribute(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, "pwd") || strings.HasSuffix(name, "password")
+}
+
+// maskSensitive 返回可记录到日志的属性值，密码属性的每个值替换为"[受保护]"
+// 所有记录属性值的日志都应经过此函数
+func maskSensitive(attr string, vals []string) []string {
+	if !IsPasswordAttribute(attr) {
+		return vals
+	}
+	masked := make([]string, len(vals))
+	for i := range masked {
+		masked[i] = maskedValue
+	}
+	return masked
+}
+
+// protocolDebugMu 保护go-ldap包级别日志记录器的安装和转发目标
+var protocolDebugMu sync.Mutex
+
+// protocolWriter go-ldap调试输出的唯一写入器，只在首次启用协议调试时安装一次
+var protocolWriter *protocolLogWriter
+
+// packetValue 匹配报文描述行末尾的值部分（Len=N "value"）
+var packetValue = regexp.MustCompile(`(Len=\d+ ).*$`)
+
+// sensitivePacketFields 值为密码的报文字段描述：绑定密码、密码修改扩展操作的新旧密码
+var sensitivePacketFields = []string{"Password:", "Old Password:", "New Password:"}
+
+// extendedResponseValue 扩展操作响应值（上下文标签11），密码修改操作由服务器生成的密码位于其中
+const extendedResponseValue = "(Context, Primitive, 0x0B)"
+
+// protocolLogWriter 将go-ldap的调试输出按行转发到最近启用协议调试的客户端日志
+// 密码属性的值、绑定密码和密码修改操作中的密码都会被隐藏
+type protocolLogWriter struct {
+	buf    bytes.Buffer
+	client *LDAPClient
+
+	maskIndent int // 密码属性的缩进层级，-1表示当前不在密码属性内
+}
+
+// Write 缓存输出，每凑满一行记录一次
+func (w *protocolLogWriter) Write(p []byte) (int, error) {
+	protocolDebugMu.Lock()
+	defer protocolDebugMu.Unlock()
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// 不完整的行留到下次写入
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		line = w.mask(strings.TrimRight(line, "\r\n"))
+		if w.client != nil {
+			w.client.Debug("[协议] %s", line)
+		}
+	}
+}
+
+// mask 隐藏报文行中的敏感值
+// 报文按缩进表示层级，属性类型行之后同级及更深层的行属于同一属性，类型为密码属性时隐藏这些行的值
+func (w *protocolLogWriter) mask(line string) string {
+	if w.client != nil && w.client.BindPassword != "" {
+		line = strings.ReplaceAll(line, w.client.BindPassword, maskedValue)
+	}
+	if !packetValue.MatchString(line) {
+		// 非报文行（如go-ldap的普通调试信息）
+		return line
+	}
+	content := strings.TrimLeft(line, " ")
+	indent := len(line) - len(content)
+	if w.maskIndent >= 0 && indent < w.maskIndent {
+		w.maskIndent = -1
+	}
+	if w.maskIndent >= 0 {
+		return maskPacketValue(line)
+	}
+	if value, ok := packetLineValue(line); ok && IsPasswordAttribute(value) {
+		w.maskIndent = indent
+		return line
+	}
+	if strings.Contains(content, extendedResponseValue) {
+		return maskPacketValue(line)
+	}
+	for _, field := range sensitivePacketFields {
+		if strings.HasPrefix(content, field) {
+			return maskPacketValue(line)
+		}
+	}
+	return line
+}
+
+// packetLineValue 返回报文行末尾引号中的值
+func packetLineValue(line string) (string, bool) {
+	start := strings.Index(line, "Len=")
+	if start < 0 {
+		return "", false
+	}
+	quote := strings.Index(line[start:], " ")
+	if quote < 0 {
+		return "", false
+	}
+	value, err := strconv.Unquote(line[start+quote+1:])
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// maskPacketValue 将报文行的值替换为"[受保护]"，保留字段描述和长度
+func maskPacketValue(line string) string {
+	return packetValue.ReplaceAllString(line, "${1}"+maskedValue)
+}
+
+// SetProtocolDebug 启用或关闭协议调试，启用后新建连接的请求和响应报文会输出到调试日志
+// go-ldap的调试输出使用包级别的日志记录器，因此输出转发到最近启用协议调试的客户端
+// 该客户端关闭协议调试时恢复go-ldap的默认日志记录器
+func (client *LDAPClient) SetProtocolDebug(enabled bool) {
+	protocolDebugMu.Lock()
+	defer protocolDebugMu.Unlock()
+	client.protocolDebug = enabled
+	if enabled {
+		if protocolWriter == nil {
+			protocolWriter = &protocolLogWriter{maskIndent: -1}
+			ldap.Logger(log.New(protocolWriter, "", 0))
+		}
+		protocolWriter.client = client
+		return
+	}
+	if protocolWriter != nil && protocolWriter.client == client {
+		protocolWriter = nil
+		ldap.Logger(log.New(os.Stderr, "", log.LstdFlags))
+	}
+}
+
+// add 执行添加请求，执行前记录请求的属性（隐藏密码）
+func (client *LDAPClient) add(conn *ldap.Conn, request *ldap.AddRequest) error {
+	if err := client.checkWritable("Add", request.DN); err != nil {
+		return err
+	}
+	client.warnGlobalCatalogWrite("Add", request.DN)
+	client.Debug("Add请求：dn=%s", request.DN)
+	for _, attr := range request.Attributes {
+		client.Debug("  %s: %v", attr.Type, maskSensitive(attr.Type, attr.Vals))
+	}
+	return conn.Add(request)
+}
+
+// modify 执行修改请求，执行前记录每个修改操作（隐藏密码）
+func (client *LDAPClient) modify(conn *ldap.Conn, request *ldap.ModifyRequest) error {
+	if err := client.checkWritable("Modify", request.DN); err != nil {
+		return err
+	}
+	client.warnGlobalCatalogWrite("Modify", request.DN)
+	client.Debug("Modify请求：dn=%s", request.DN)
+	for _, change := range request.Changes {
+		opName, ok := modifyOperationNames[int(change.Operation)]
+		if !ok {
+			opName = fmt.Sprintf("%d", change.Operation)
+		}
+		client.Debug("  %s %s: %v", opName, change.Modification.Type, maskSensitive(change.Modification.Type, change.Modification.Vals))
+	}
+	return conn.Modify(request)
+}
+
+// del 执行删除请求，执行前记录要删除的条目
+func (client *LDAPClient) del(conn *ldap.Conn, request *ldap.DelRequest) error {
+	if err := client.checkWritable("Delete", request.DN); err != nil {
+		return err
+	}
+	client.warnGlobalCatalogWrite("Delete", request.DN)
+	client.Debug("Delete请求：dn=%s", request.DN)
+	return conn.Del(request)
+}
+
+// modifyDN 执行重命名或移动请求，执行前记录请求内容
+func (client *LDAPClient) modifyDN(conn *ldap.Conn, request *ldap.ModifyDNRequest) error {
+	if err := client.checkWritable("ModifyDN", request.DN); err != nil {
+		return err
+	}
+	client.warnGlobalCatalogWrite("ModifyDN", request.DN)
+	client.Debug("ModifyDN请求：dn=%s, newRDN=%s, deleteOldRDN=%v, newSuperior=%s",
+		request.DN, request.NewRDN, request.DeleteOldRDN, request.NewSuperior)
+	return conn.ModifyDN(request)
+}
@@ -0,0 +1,99 @@
+package ldap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestMaskSensitive(t *testing.T) {
+	if got := maskSensitive("unicodePwd", []string{"secret"}); got[0] != maskedValue {
+		t.Errorf("unicodePwd 未隐藏: %v", got)
+	}
+	if got := maskSensitive("badPasswordTime", []string{"0"}); got[0] != "0" {
+		t.Errorf("badPasswordTime 不应隐藏: %v", got)
+	}
+}
+
+// TestProtocolDebugHidesPasswords 启用协议调试后执行各种携带密码的操作，状态区域和JSON日志中都不应出现密码
+func TestProtocolDebugHidesPasswords(t *testing.T) {
+	server := newFakeServer(t)
+	server.generatedPassword = "GeneratedSecret#4"
+
+	log, output := captureLogger()
+	client := server.client(log)
+	client.SetProtocolDebug(true)
+	defer client.SetProtocolDebug(false)
+
+	conn, err := client.GetConnection()
+	if err != nil {
+		t.Fatalf("连接测试服务器失败: %v", err)
+	}
+	defer conn.Close()
+
+	add := ldap.NewAddRequest("cn=user1,dc=example,dc=com", nil)
+	add.Attribute("objectClass", []string{"user"})
+	add.Attribute("unicodePwd", []string{"NewUserSecret#2"})
+	if err := client.add(conn, add); err != nil {
+		t.Fatalf("Add失败: %v", err)
+	}
+
+	modify := ldap.NewModifyRequest("cn=user1,dc=example,dc=com", nil)
+	modify.Replace("userPassword", []string{"{SSHA}ModifiedSecret#3"})
+	modify.Replace("description", []string{"visible"})
+	if err := client.modify(conn, modify); err != nil {
+		t.Fatalf("Modify失败: %v", err)
+	}
+
+	result, err := conn.PasswordModify(ldap.NewPasswordModifyRequest("", "OldSecret#5", ""))
+	if err != nil {
+		t.Fatalf("PasswordModify失败: %v", err)
+	}
+	if result.GeneratedPassword != "GeneratedSecret#4" {
+		t.Fatalf("生成的密码 = %q", result.GeneratedPassword)
+	}
+
+	if err := conn.Bind("cn=test,dc=example,dc=com", "TestUserSecret#6"); err != nil {
+		t.Fatalf("测试用户绑定失败: %v", err)
+	}
+
+	logged := output()
+	if !strings.Contains(logged, "[协议]") {
+		t.Fatal("未记录协议报文")
+	}
+	if !strings.Contains(logged, "visible") {
+		t.Error("非密码属性的值不应隐藏")
+	}
+	for _, secret := range []string{"AdminSecret#1", "NewUserSecret#2", "ModifiedSecret#3", "GeneratedSecret#4", "OldSecret#5", "TestUserSecret#6"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("日志中出现了密码 %q", secret)
+		}
+	}
+}
+
+// TestSetProtocolDebugRestoresLogger 只有最近启用协议调试的客户端关闭时才恢复默认日志记录器
+func TestSetProtocolDebugRestoresLogger(t *testing.T) {
+	log, _ := captureLogger()
+	first := NewLDAPClient("127.0.0.1", 389, "", "", log, nil, false, true)
+	second := NewLDAPClient("127.0.0.1", 389, "", "", log, nil, false, true)
+
+	first.SetProtocolDebug(true)
+	writer := protocolWriter
+	second.SetProtocolDebug(true)
+	if protocolWriter != writer {
+		t.Fatal("再次启用不应重新安装写入器")
+	}
+	if protocolWriter.client != second {
+		t.Fatal("输出应转发到最近启用的客户端")
+	}
+
+	first.SetProtocolDebug(false)
+	if protocolWriter == nil {
+		t.Fatal("其他客户端关闭时不应恢复日志记录器")
+	}
+	second.SetProtocolDebug(false)
+	if protocolWriter != nil {
+		t.Fatal("关闭后应恢复默认日志记录器")
+	}
+}
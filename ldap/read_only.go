@@ -0,0 +1,25 @@
+package ldap
+
+import "errors"
+
+// ErrReadOnly 只读模式下拒绝执行写操作
+var ErrReadOnly = errors.New("只读模式已启用")
+
+// SetReadOnly 设置只读模式，启用后所有添加、修改、删除、重命名和密码修改请求都不会发送到服务器
+func (client *LDAPClient) SetReadOnly(enabled bool) {
+	client.readOnly = enabled
+}
+
+// IsReadOnly 返回是否处于只读模式
+func (client *LDAPClient) IsReadOnly() bool {
+	return client.readOnly
+}
+
+// checkWritable 只读模式下记录被拒绝的写操作并返回ErrReadOnly
+func (client *LDAPClient) checkWritable(operation string, dn string) error {
+	if !client.readOnly {
+		return nil
+	}
+	client.Warn("只读模式已启用，已阻止%s操作：%s", operation, dn)
+	return ErrReadOnly
+}
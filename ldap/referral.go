@@ -0,0 +1,99 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// SetFollowReferrals 设置搜索返回引用时是否使用相同凭据跟随
+func (client *LDAPClient) SetFollowReferrals(follow bool) {
+	client.followReferrals = follow
+}
+
+// handleReferrals 记录搜索返回的引用；启用跟随时到引用的服务器重复搜索并返回额外的条目
+// 只跟随一层引用，引用服务器再返回的引用只记录不跟随
+func (client *LDAPClient) handleReferrals(referrals []string, request *ldap.SearchRequest) []*ldap.Entry {
+	if len(referrals) == 0 {
+		return nil
+	}
+	for _, referral := range referrals {
+		client.Info("搜索返回引用：%s", referral)
+	}
+	if !client.followReferrals {
+		client.Info("数据可能位于以上服务器，可勾选\"跟随引用\"自动搜索")
+		return nil
+	}
+
+	var entries []*ldap.Entry
+	for _, referral := range referrals {
+		found, err := client.searchReferral(referral, request)
+		if err != nil {
+			client.Warn("跟随引用 %s 失败：%v", referral, err)
+			continue
+		}
+		client.Info("引用 %s 返回 %d 个条目", referral, len(found))
+		entries = append(entries, found...)
+	}
+	return entries
+}
+
+// searchReferral 连接引用URL指向的服务器，以相同凭据和过滤器重复搜索
+func (client *LDAPClient) searchReferral(referral string, request *ldap.SearchRequest) ([]*ldap.Entry, error) {
+	u, err := url.Parse(referral)
+	if err != nil {
+		return nil, fmt.Errorf("无效的引用URL: %v", err)
+	}
+	baseDN := request.BaseDN
+	if u.Path != "" && u.Path != "/" {
+		baseDN = u.Path[1:]
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		port := "389"
+		if u.Scheme == "ldaps" {
+			port = "636"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var tlsConfig *tls.Config
+	if u.Scheme == "ldaps" {
+		tlsConfig = client.GetTLSConfig()
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	conn, err := client.dialLDAP(host, tlsConfig, client.config.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer conn.Close()
+	defer client.closeOnCancel(conn)()
+
+	if client.hasBindCredentials() {
+		if err := client.bindConn(conn, client.BindDN, client.BindPassword); err != nil {
+			return nil, fmt.Errorf("绑定失败: %s", ParseLDAPError(err))
+		}
+	}
+
+	searchRequest := ldap.NewSearchRequest(
+		baseDN,
+		request.Scope, request.DerefAliases,
+		request.SizeLimit, request.TimeLimit, request.TypesOnly,
+		request.Filter,
+		request.Attributes,
+		nil,
+	)
+	sr, err := conn.SearchWithPaging(searchRequest, searchPageSize)
+	if err != nil && sr == nil {
+		return nil, fmt.Errorf("搜索失败: %s", ParseLDAPError(err))
+	}
+	for _, nested := range sr.Referrals {
+		client.Info("引用服务器返回了新的引用（未跟随）：%s", nested)
+	}
+	return sr.Entries, nil
+}
@@ -0,0 +1,172 @@
+package ldap
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// RootDSEAttributes 返回查询RootDSE时请求的属性
+func RootDSEAttributes() []string {
+	return []string{
+		"supportedLDAPVersion",
+		"supportedSASLMechanisms",
+		"supportedControl",
+		"supportedExtension",
+		"namingContexts",
+		"defaultNamingContext",
+		"rootDomainNamingContext",
+		"supportedCapabilities",
+		"forestFunctionality",
+		"vendorName",
+		"objectClass",
+	}
+}
+
+// AD在supportedCapabilities中声明的能力OID
+const (
+	oidActiveDirectory    = "1.2.840.113556.1.4.800"  // LDAP_CAP_ACTIVE_DIRECTORY_OID
+	oidActiveDirectoryLDS = "1.2.840.113556.1.4.1851" // LDAP_CAP_ACTIVE_DIRECTORY_ADAM_OID
+)
+
+// QueryRootDSE 查询RootDSE，返回服务器能力信息
+func (client *LDAPClient) QueryRootDSE() (map[string][]string, error) {
+	client.Debug("正在查询RootDSE")
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	searchRequest := ldap.NewSearchRequest(
+		"",
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0, 0, false,
+		"(objectClass=*)",
+		RootDSEAttributes(),
+		nil,
+	)
+
+	sr, err := conn.Search(searchRequest)
+	if err != nil {
+		return nil, fmt.Errorf("查询RootDSE失败: %v", err)
+	}
+	if len(sr.Entries) == 0 {
+		return nil, fmt.Errorf("RootDSE为空")
+	}
+
+	result := make(map[string][]string)
+	for _, attr := range sr.Entries[0].Attributes {
+		result[attr.Name] = attr.Values
+	}
+	client.Debug("RootDSE返回 %d 个属性", len(result))
+	return result, nil
+}
+
+// DetectBaseDN 自动检测搜索基准DN
+// 优先使用RootDSE的defaultNamingContext/namingContexts，失败时按主机名拆分
+func (client *LDAPClient) DetectBaseDN() (string, error) {
+	rootDSE, err := client.QueryRootDSE()
+	if err == nil {
+		if values := rootDSE["defaultNamingContext"]; len(values) > 0 && values[0] != "" {
+			client.Debug("从defaultNamingContext检测到基准DN：%s", values[0])
+			return values[0], nil
+		}
+		if values := rootDSE["namingContexts"]; len(values) > 0 && values[0] != "" {
+			client.Debug("从namingContexts检测到基准DN：%s", values[0])
+			return values[0], nil
+		}
+		client.Warn("RootDSE未返回命名上下文，改用主机名推断基准DN")
+	} else {
+		client.Warn("RootDSE查询失败，改用主机名推断基准DN: %v", err)
+	}
+
+	baseDN := hostToBaseDN(client.Host)
+	if baseDN == "" {
+		return "", fmt.Errorf("无法从主机 %s 推断基准DN", client.Host)
+	}
+	client.Debug("从主机名推断基准DN：%s", baseDN)
+	return baseDN, nil
+}
+
+// hostToBaseDN 将主机名按"."拆分为DC组件，IP地址返回空字符串
+func hostToBaseDN(host string) string {
+	if host == "" || net.ParseIP(host) != nil {
+		return ""
+	}
+	var dnParts []string
+	for _, part := range strings.Split(host, ".") {
+		if part != "" {
+			dnParts = append(dnParts, RDN("DC", part))
+		}
+	}
+	return strings.Join(dnParts, ",")
+}
+
+// DetectDirectoryType 根据RootDSE判断目录类型（ActiveDirectory、ADLDS、OpenLDAP或Unknown）
+// 检测结果保存在客户端上；若与当前模板不符，切换为该类型的默认模板，ADLDS没有对应模板，沿用当前模板
+// 检测成功后内置过滤器随最终使用的模板切换，界面需重新加载过滤器列表
+func (client *LDAPClient) DetectDirectoryType() (string, error) {
+	rootDSE, err := client.QueryRootDSE()
+	if err != nil {
+		return DirectoryTypeUnknown, err
+	}
+
+	directoryType := classifyRootDSE(rootDSE)
+	client.directoryType = directoryType
+	client.Info("检测到目录类型：%s", directoryType)
+
+	if directoryType != DirectoryTypeUnknown && client.userTemplate.DirectoryType != directoryType {
+		if template, ok := UserTemplateForDirectoryType(directoryType); ok {
+			client.Warn("当前模板 %s 与检测结果不符，改用模板：%s", client.userTemplate.Name, template.Name)
+			client.userTemplate = template
+		}
+	}
+	if directoryType != DirectoryTypeUnknown {
+		SetFilterDirectoryType(client.userTemplate.DirectoryType)
+	}
+	return directoryType, nil
+}
+
+// DirectoryType 返回最近一次检测到的目录类型，未检测时返回Unknown
+func (client *LDAPClient) DirectoryType() string {
+	if client.directoryType == "" {
+		return DirectoryTypeUnknown
+	}
+	return client.directoryType
+}
+
+// classifyRootDSE 根据RootDSE属性判断目录类型
+// AD LDS只声明ADAM能力而不声明AD能力，单独归为ADLDS类型，不套用AD域模板
+func classifyRootDSE(rootDSE map[string][]string) string {
+	lds := false
+	for _, capability := range rootDSE["supportedCapabilities"] {
+		switch capability {
+		case oidActiveDirectory:
+			return DirectoryTypeAD
+		case oidActiveDirectoryLDS:
+			lds = true
+		}
+	}
+	if lds {
+		return DirectoryTypeADLDS
+	}
+	if len(rootDSE["forestFunctionality"]) > 0 {
+		return DirectoryTypeAD
+	}
+
+	for _, objectClass := range rootDSE["objectClass"] {
+		if strings.EqualFold(objectClass, "OpenLDAProotDSE") {
+			return DirectoryTypeOpenLDAP
+		}
+	}
+	for _, vendor := range rootDSE["vendorName"] {
+		if strings.Contains(strings.ToLower(vendor), "openldap") {
+			return DirectoryTypeOpenLDAP
+		}
+	}
+	return DirectoryTypeUnknown
+}
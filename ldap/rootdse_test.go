@@ -0,0 +1,51 @@
+package ldap
+
+import "testing"
+
+func TestClassifyRootDSE(t *testing.T) {
+	tests := []struct {
+		name    string
+		rootDSE map[string][]string
+		want    string
+	}{
+		{"AD", map[string][]string{"supportedCapabilities": {"1.2.840.113556.1.4.800", "1.2.840.113556.1.4.1670"}}, DirectoryTypeAD},
+		{"AD林功能级别", map[string][]string{"forestFunctionality": {"7"}}, DirectoryTypeAD},
+		{"AD LDS", map[string][]string{"supportedCapabilities": {"1.2.840.113556.1.4.1851", "1.2.840.113556.1.4.1670"}}, DirectoryTypeADLDS},
+		{"AD LDS带林功能级别", map[string][]string{"supportedCapabilities": {"1.2.840.113556.1.4.1851"}, "forestFunctionality": {"7"}}, DirectoryTypeADLDS},
+		{"OpenLDAP", map[string][]string{"objectClass": {"top", "OpenLDAProotDSE"}}, DirectoryTypeOpenLDAP},
+		{"OpenLDAP厂商", map[string][]string{"vendorName": {"OpenLDAP Project"}}, DirectoryTypeOpenLDAP},
+		{"未知", map[string][]string{"vendorName": {"389 Project"}}, DirectoryTypeUnknown},
+	}
+	for _, tt := range tests {
+		if got := classifyRootDSE(tt.rootDSE); got != tt.want {
+			t.Errorf("%s: classifyRootDSE = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestDetectDirectoryTypeADLDS AD LDS沿用当前模板，且不按AD处理，内置过滤器随模板切换
+func TestDetectDirectoryTypeADLDS(t *testing.T) {
+	defer SetFilterDirectoryType(DirectoryTypeAD)
+	server := newFakeServer(t)
+	server.entryAttributes = map[string][]string{"supportedCapabilities": {"1.2.840.113556.1.4.1851"}}
+	log, _ := captureLogger()
+	client := server.client(log)
+	openLDAP, _ := UserTemplateForDirectoryType(DirectoryTypeOpenLDAP)
+	client.SetUserTemplate(openLDAP)
+
+	directoryType, err := client.DetectDirectoryType()
+	if err != nil || directoryType != DirectoryTypeADLDS {
+		t.Fatalf("DetectDirectoryType = %s, %v", directoryType, err)
+	}
+	if client.GetUserTemplate().Name != openLDAP.Name {
+		t.Errorf("AD LDS不应切换模板，当前模板：%s", client.GetUserTemplate().Name)
+	}
+	if client.isActiveDirectory() {
+		t.Error("AD LDS不应按AD处理")
+	}
+	filtersMu.RLock()
+	defer filtersMu.RUnlock()
+	if filterDirectoryType != DirectoryTypeOpenLDAP {
+		t.Errorf("内置过滤器目录类型 = %s，应随模板切换为 %s", filterDirectoryType, DirectoryTypeOpenLDAP)
+	}
+}
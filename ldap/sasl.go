@@ -0,0 +1,102 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// 绑定方式
+const (
+	BindMethodSimple    = "Simple"
+	BindMethodExternal  = "EXTERNAL"
+	BindMethodDigestMD5 = "DIGEST-MD5"
+)
+
+// BindMethods 返回可选的绑定方式
+func BindMethods() []string {
+	return []string{BindMethodSimple, BindMethodExternal, BindMethodDigestMD5}
+}
+
+// ClientCertificate SASL EXTERNAL使用的客户端证书，为nil时不发送证书
+var ClientCertificate *tls.Certificate
+
+// LoadClientCertificate 加载PEM格式的客户端证书和私钥
+func LoadClientCertificate(certFile string, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("加载客户端证书失败: %v", err)
+	}
+	ClientCertificate = &cert
+	return nil
+}
+
+// SetBindMethod 设置绑定方式（Simple、EXTERNAL或DIGEST-MD5）
+func (client *LDAPClient) SetBindMethod(method string) {
+	client.bindMethod = method
+}
+
+// hasBindCredentials 判断是否需要在建立连接后绑定
+// EXTERNAL使用客户端证书，不需要用户名和密码
+func (client *LDAPClient) hasBindCredentials() bool {
+	if client.bindMethod == BindMethodExternal {
+		return true
+	}
+	return client.BindDN != "" && client.BindPassword != ""
+}
+
+// BindSASL 在当前连接上使用SASL机制绑定
+// DIGEST-MD5的user为登录名（如sAMAccountName），而不是DN
+func (client *LDAPClient) BindSASL(mechanism string, user string, pass string) error {
+	if client.conn == nil {
+		return errors.New("未连接到LDAP服务器")
+	}
+	return client.saslBind(client.conn, mechanism, user, pass)
+}
+
+// saslBind 在指定连接上执行SASL绑定
+func (client *LDAPClient) saslBind(conn *ldap.Conn, mechanism string, user string, pass string) error {
+	client.Debug("使用SASL %s 绑定", mechanism)
+	switch mechanism {
+	case BindMethodExternal:
+		if !client.isSSLMode {
+			return errors.New("SASL EXTERNAL需要SSL连接和客户端证书")
+		}
+		if ClientCertificate == nil {
+			client.Warn("未配置客户端证书，SASL EXTERNAL可能失败")
+		}
+		return conn.ExternalBind()
+	case BindMethodDigestMD5:
+		return conn.MD5Bind(client.Host, user, pass)
+	default:
+		return fmt.Errorf("不支持的SASL机制: %s", mechanism)
+	}
+}
+
+// bindConn 按所选绑定方式在连接上绑定
+func (client *LDAPClient) bindConn(conn *ldap.Conn, user string, pass string) error {
+	switch client.bindMethod {
+	case BindMethodExternal, BindMethodDigestMD5:
+		return client.saslBind(conn, client.bindMethod, user, pass)
+	default:
+		return conn.Bind(user, pass)
+	}
+}
+
+// SupportedSASLMechanisms 不绑定直接读取RootDSE中的supportedSASLMechanisms
+func (client *LDAPClient) SupportedSASLMechanisms() ([]string, error) {
+	conn, err := client.dial()
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer conn.Close()
+	defer client.closeOnCancel(conn)()
+
+	entry, err := readEntry(conn, "", []string{"supportedSASLMechanisms"})
+	if err != nil {
+		return nil, fmt.Errorf("读取RootDSE失败: %s", ParseLDAPError(err))
+	}
+	return entry.GetAttributeValues("supportedSASLMechanisms"), nil
+}
@@ -0,0 +1,236 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// searchPageSize 分页搜索时每页的条目数（AD默认单次最多返回1000条）
+const searchPageSize = 500
+
+// DefaultSearchScope 默认搜索范围（整个子树）
+const DefaultSearchScope = ldap.ScopeWholeSubtree
+
+// searchScopeNames 搜索范围名称，与ldapsearch的-s参数一致
+var searchScopeNames = map[int]string{
+	ldap.ScopeBaseObject:   "base",
+	ldap.ScopeSingleLevel:  "one",
+	ldap.ScopeWholeSubtree: "sub",
+}
+
+// SearchScopes 返回可选的搜索范围名称
+func SearchScopes() []string {
+	return []string{"base", "one", "sub"}
+}
+
+// ParseSearchScope 将范围名称转换为搜索范围
+func ParseSearchScope(name string) (int, bool) {
+	for scope, n := range searchScopeNames {
+		if n == name {
+			return scope, true
+		}
+	}
+	return 0, false
+}
+
+// SearchScopeName 返回搜索范围的名称
+func SearchScopeName(scope int) string {
+	if name, ok := searchScopeNames[scope]; ok {
+		return name
+	}
+	return ldap.ScopeMap[scope]
+}
+
+// SetSearchScope 设置用户、组和条目搜索使用的范围
+func (client *LDAPClient) SetSearchScope(scope int) {
+	client.searchScope = scope
+}
+
+// SetSearchLimits 设置搜索的最大结果数和超时（秒），0表示不限制
+func (client *LDAPClient) SetSearchLimits(sizeLimit int, timeLimit int) {
+	client.sizeLimit = sizeLimit
+	client.timeLimit = timeLimit
+}
+
+// ErrSizeLimitExceeded 搜索结果超过最大结果数
+var ErrSizeLimitExceeded = errors.New("搜索结果超过最大结果数限制，请使用更精确的过滤器或提高最大结果数（超过服务器限制时需分页搜索）")
+
+// ErrTimeLimitExceeded 搜索超过超时时间
+var ErrTimeLimitExceeded = errors.New("搜索超时，请缩小搜索范围或提高超时时间")
+
+// isSizeLimitExceeded 判断错误是否为超过结果数限制（服务器返回或客户端截断）
+func isSizeLimitExceeded(err error) bool {
+	return ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) || errors.Is(err, ldap.ErrSizeLimitExceeded)
+}
+
+// 搜索返回属性中的特殊标记
+const (
+	AllUserAttributes        = "*" // 所有用户属性
+	AllOperationalAttributes = "+" // 所有操作属性（如whenCreated、modifyTimestamp）
+)
+
+// isAttributeWildcard 判断属性名是否为"*"或"+"
+func isAttributeWildcard(attr string) bool {
+	return attr == AllUserAttributes || attr == AllOperationalAttributes
+}
+
+// SortKey 服务器端排序的排序键
+type SortKey struct {
+	Attribute string // 排序属性
+	Reverse   bool   // 是否降序
+}
+
+// String 返回排序键的描述
+func (key SortKey) String() string {
+	if key.Reverse {
+		return key.Attribute + " 降序"
+	}
+	return key.Attribute + " 升序"
+}
+
+// NewSortControl 创建服务器端排序控制（RFC 2891，OID 1.2.840.113556.1.4.473）
+// 控制为非关键，服务器不支持时会忽略它并返回未排序的结果
+func NewSortControl(key SortKey) ldap.Control {
+	return ldap.NewControlServerSideSortingWithSortKeys([]*ldap.SortKey{{AttributeType: key.Attribute, Reverse: key.Reverse}})
+}
+
+// isSortRejected 判断搜索错误是否为服务器拒绝了排序控制（不接受该控制或无法按该属性排序）
+func isSortRejected(err error) bool {
+	return ldap.IsErrorWithCode(err, ldap.LDAPResultUnavailableCriticalExtension) ||
+		ldap.IsErrorWithCode(err, ldap.LDAPResultProtocolError) ||
+		ldap.IsErrorWithCode(err, ldap.LDAPResultInappropriateMatching) ||
+		ldap.IsErrorWithCode(err, ldap.LDAPResultUnwillingToPerform)
+}
+
+// isSortResultDecodeError 判断搜索错误是否为go-ldap无法解析服务器返回的排序结果控制
+// go-ldap解析排序结果时不解码控制值，服务器返回该控制时搜索总会以此错误结束，
+// 但该控制位于SearchResultDone中，此时条目已全部读取，只是无法得知排序结果码
+func isSortResultDecodeError(err error) bool {
+	var ldapErr *ldap.Error
+	return err != nil && !errors.As(err, &ldapErr) && strings.HasPrefix(err.Error(), "failed to decode child control")
+}
+
+// SearchResult 通用搜索结果
+type SearchResult struct {
+	BaseDN     string        // 搜索基准
+	Filter     string        // 搜索过滤器
+	Attributes []string      // 请求的属性，为空表示全部用户属性，可包含"*"和"+"
+	Sort       *SortKey      // 服务器端排序键，为nil表示未排序
+	Entries    []*ldap.Entry // 返回的条目
+	Truncated  error         // 结果被截断时的原因（超过结果数或超时），否则为nil
+	SortError  error         // 请求了排序但服务器无法排序时的原因，此时结果未排序
+}
+
+// SearchEntries 在baseDN下按过滤器搜索条目，attributes为空时返回全部用户属性
+// attributes中的"*"表示所有用户属性，"+"表示所有操作属性
+// sort不为nil时使用服务器端排序控制，服务器不支持时记录SortError并返回未排序的结果
+// 排序搜索不分页：go-ldap无法解析排序结果控制，分页搜索会在第一页后中断
+func (client *LDAPClient) SearchEntries(baseDN string, filter string, attributes []string, sort *SortKey) (*SearchResult, error) {
+	if _, err := ldap.CompileFilter(filter); err != nil {
+		return nil, fmt.Errorf("过滤器语法错误: %v", err)
+	}
+	client.Debug("搜索条目：base=%s, scope=%s, filter=%s, attributes=%v, sort=%v, sizeLimit=%d, timeLimit=%d",
+		baseDN, SearchScopeName(client.searchScope), filter, attributes, sort, client.sizeLimit, client.timeLimit)
+
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	newRequest := func(sort *SortKey) *ldap.SearchRequest {
+		var controls []ldap.Control
+		if sort != nil {
+			controls = append(controls, NewSortControl(*sort))
+		}
+		return ldap.NewSearchRequest(
+			baseDN,
+			client.searchScope, ldap.NeverDerefAliases,
+			client.sizeLimit, client.timeLimit, false,
+			filter,
+			attributes,
+			controls,
+		)
+	}
+
+	result := &SearchResult{
+		BaseDN:     baseDN,
+		Filter:     filter,
+		Attributes: attributes,
+		Sort:       sort,
+	}
+
+	searchRequest := newRequest(sort)
+	var sr *ldap.SearchResult
+	if sort == nil {
+		sr, err = conn.SearchWithPaging(searchRequest, searchPageSize)
+	} else {
+		sr, err = conn.Search(searchRequest)
+		switch {
+		case isSortResultDecodeError(err):
+			err = nil
+		case err == nil && ldap.FindControl(sr.Controls, ldap.ControlTypeServerSideSortingResult) == nil:
+			result.SortError = fmt.Errorf("服务器未返回排序结果，可能未启用服务器端排序控制，无法按 %s 排序", sort)
+			client.Warn("%v，结果未排序", result.SortError)
+			result.Sort = nil
+		case isSortRejected(err):
+			result.SortError = fmt.Errorf("服务器无法按 %s 排序（可能未启用服务器端排序控制或该属性不可排序）: %s", sort, ParseLDAPError(err))
+			client.Warn("%v，改为不排序搜索", result.SortError)
+			result.Sort = nil
+			searchRequest = newRequest(nil)
+			sr, err = conn.SearchWithPaging(searchRequest, searchPageSize)
+		}
+	}
+	switch {
+	case err == nil:
+	case isSizeLimitExceeded(err):
+		result.Truncated = ErrSizeLimitExceeded
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultTimeLimitExceeded):
+		result.Truncated = ErrTimeLimitExceeded
+	default:
+		return nil, fmt.Errorf("搜索失败: %s", ParseLDAPError(err))
+	}
+	if sr != nil {
+		result.Entries = sr.Entries
+		result.Entries = append(result.Entries, client.handleReferrals(sr.Referrals, searchRequest)...)
+	}
+
+	if result.Truncated != nil {
+		client.Warn("搜索结果不完整（已返回 %d 个条目）：%v", len(result.Entries), result.Truncated)
+	}
+	client.Debug("搜索返回 %d 个条目", len(result.Entries))
+	return result, nil
+}
+
+// Columns 返回结果的属性列：请求了具体属性时使用请求的属性，
+// 未指定或包含"*"、"+"时在具体属性之后按出现顺序汇总所有条目的属性
+func (result *SearchResult) Columns() []string {
+	seen := make(map[string]bool)
+	var columns []string
+	wildcard := len(result.Attributes) == 0
+	for _, attr := range result.Attributes {
+		if isAttributeWildcard(attr) {
+			wildcard = true
+			continue
+		}
+		if !seen[strings.ToLower(attr)] {
+			seen[strings.ToLower(attr)] = true
+			columns = append(columns, attr)
+		}
+	}
+	if !wildcard {
+		return columns
+	}
+	for _, entry := range result.Entries {
+		for _, attr := range entry.Attributes {
+			if !seen[strings.ToLower(attr.Name)] {
+				seen[strings.ToLower(attr.Name)] = true
+				columns = append(columns, attr.Name)
+			}
+		}
+	}
+	return columns
+}
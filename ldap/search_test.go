@@ -0,0 +1,65 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// TestNewSortControl 排序控制使用go-ldap的服务器端排序控制，且为非关键
+func TestNewSortControl(t *testing.T) {
+	control, ok := NewSortControl(SortKey{Attribute: "cn", Reverse: true}).(*ldap.ControlServerSideSorting)
+	if !ok {
+		t.Fatalf("控制类型 = %T", control)
+	}
+	if len(control.SortKeys) != 1 || control.SortKeys[0].AttributeType != "cn" || !control.SortKeys[0].Reverse {
+		t.Errorf("排序键 = %+v", control.SortKeys)
+	}
+	if critical := control.Encode().Children[1].Value; critical == true {
+		t.Error("排序控制不应为关键控制")
+	}
+}
+
+// TestSearchEntriesSort 服务器排序、忽略排序控制和拒绝排序控制时的搜索结果
+func TestSearchEntriesSort(t *testing.T) {
+	sort := &SortKey{Attribute: "cn"}
+	tests := []struct {
+		name      string
+		supported bool
+		rejected  uint16
+		sorted    bool // 结果是否按请求排序
+		requests  int  // 服务器收到的带排序控制的搜索数
+	}{
+		{name: "服务器已排序", supported: true, sorted: true, requests: 1},
+		{name: "服务器忽略排序", requests: 1},
+		{name: "服务器拒绝排序", rejected: ldap.LDAPResultUnwillingToPerform, requests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t)
+			server.sortSupported = tt.supported
+			server.sortRejected = tt.rejected
+			log, _ := captureLogger()
+			client := server.client(log)
+
+			result, err := client.SearchEntries("dc=example,dc=com", "(objectClass=*)", nil, sort)
+			if err != nil {
+				t.Fatalf("搜索失败: %v", err)
+			}
+			if len(result.Entries) != 1 {
+				t.Errorf("返回 %d 个条目，应为1个", len(result.Entries))
+			}
+			if sorted := result.Sort != nil && result.SortError == nil; sorted != tt.sorted {
+				t.Errorf("Sort = %v, SortError = %v", result.Sort, result.SortError)
+			}
+			if (result.Sort == nil) != (result.SortError != nil) {
+				t.Errorf("未排序时应记录原因: Sort = %v, SortError = %v", result.Sort, result.SortError)
+			}
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			if server.sortRequests != tt.requests {
+				t.Errorf("收到 %d 个排序搜索，应为 %d 个", server.sortRequests, tt.requests)
+			}
+		})
+	}
+}
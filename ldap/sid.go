@@ -0,0 +1,124 @@
+package ldap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// validateSID 校验二进制objectSid的结构：版本、子授权数量、6字节标识符授权
+func validateSID(sid []byte) error {
+	if len(sid) < 8 {
+		return fmt.Errorf("objectSid长度无效: %d", len(sid))
+	}
+	subAuthorityCount := int(sid[1])
+	if subAuthorityCount == 0 || len(sid) != 8+4*subAuthorityCount {
+		return fmt.Errorf("objectSid格式无效: 子授权数量 %d，长度 %d", subAuthorityCount, len(sid))
+	}
+	return nil
+}
+
+// SIDRID 从二进制objectSid中提取RID（最后一个子授权，小端序）
+func SIDRID(sid []byte) (uint32, error) {
+	if err := validateSID(sid); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(sid[len(sid)-4:]), nil
+}
+
+// DecodeSID 将二进制objectSid格式化为"S-1-5-21-...-RID"形式，格式无效时返回空字符串
+func DecodeSID(raw []byte) string {
+	if validateSID(raw) != nil {
+		return ""
+	}
+
+	// 标识符授权为6字节大端序
+	var authority uint64
+	for _, b := range raw[2:8] {
+		authority = authority<<8 | uint64(b)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("S-")
+	sb.WriteString(strconv.Itoa(int(raw[0])))
+	sb.WriteString("-")
+	sb.WriteString(strconv.FormatUint(authority, 10))
+	for i := 8; i < len(raw); i += 4 {
+		sb.WriteString("-")
+		sb.WriteString(strconv.FormatUint(uint64(binary.LittleEndian.Uint32(raw[i:i+4])), 10))
+	}
+	return sb.String()
+}
+
+// EncodeSID 将"S-1-5-21-...-RID"形式的SID转换为二进制objectSid
+func EncodeSID(sid string) ([]byte, error) {
+	parts := strings.Split(strings.TrimSpace(sid), "-")
+	if len(parts) < 4 || !strings.EqualFold(parts[0], "S") {
+		return nil, fmt.Errorf("SID格式无效: %s", sid)
+	}
+	revision, err := strconv.ParseUint(parts[1], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("SID版本无效: %s", parts[1])
+	}
+	authority, err := strconv.ParseUint(parts[2], 10, 48)
+	if err != nil {
+		return nil, fmt.Errorf("SID标识符授权无效: %s", parts[2])
+	}
+	subAuthorities := parts[3:]
+	if len(subAuthorities) > 15 {
+		return nil, fmt.Errorf("SID子授权过多: %d", len(subAuthorities))
+	}
+
+	raw := make([]byte, 8, 8+4*len(subAuthorities))
+	raw[0] = byte(revision)
+	raw[1] = byte(len(subAuthorities))
+	for i := 7; i >= 2; i-- {
+		raw[i] = byte(authority)
+		authority >>= 8
+	}
+	for _, part := range subAuthorities {
+		value, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("SID子授权无效: %s", part)
+		}
+		raw = binary.LittleEndian.AppendUint32(raw, uint32(value))
+	}
+	return raw, nil
+}
+
+// EncodeSIDForFilter 将SID转换为过滤器中使用的转义形式（\xx\xx...）
+func EncodeSIDForFilter(sid string) (string, error) {
+	raw, err := EncodeSID(sid)
+	if err != nil {
+		return "", err
+	}
+	return escapeBinaryForFilter(raw), nil
+}
+
+// escapeBinaryForFilter 将二进制值的每个字节转义为\xx形式
+func escapeBinaryForFilter(raw []byte) string {
+	var sb strings.Builder
+	for _, b := range raw {
+		fmt.Fprintf(&sb, "\\%02x", b)
+	}
+	return sb.String()
+}
+
+// AttributeDisplayValues 返回属性的可读值，objectSid解码为字符串形式
+func AttributeDisplayValues(attr *ldap.EntryAttribute) []string {
+	if !strings.EqualFold(attr.Name, "objectSid") {
+		return attr.Values
+	}
+	values := make([]string, 0, len(attr.ByteValues))
+	for _, raw := range attr.ByteValues {
+		if sid := DecodeSID(raw); sid != "" {
+			values = append(values, sid)
+		} else {
+			values = append(values, fmt.Sprintf("%x", raw))
+		}
+	}
+	return values
+}
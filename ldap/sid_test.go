@@ -0,0 +1,61 @@
+package ldap
+
+import (
+	"bytes"
+	"testing"
+)
+
+// knownSID S-1-5-21-3623811015-3361044348-30300820-1013 的二进制objectSid
+var knownSID = []byte{
+	0x01, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+	0x15, 0x00, 0x00, 0x00,
+	0xc7, 0xf7, 0xfe, 0xd7,
+	0x7c, 0x77, 0x55, 0xc8,
+	0x94, 0x5a, 0xce, 0x01,
+	0xf5, 0x03, 0x00, 0x00,
+}
+
+const knownSIDString = "S-1-5-21-3623811015-3361044348-30300820-1013"
+
+func TestDecodeSID(t *testing.T) {
+	if got := DecodeSID(knownSID); got != knownSIDString {
+		t.Errorf("DecodeSID = %q, want %q", got, knownSIDString)
+	}
+	rid, err := SIDRID(knownSID)
+	if err != nil || rid != 1013 {
+		t.Errorf("SIDRID = %d, %v, want 1013", rid, err)
+	}
+
+	for _, raw := range [][]byte{nil, knownSID[:7], knownSID[:len(knownSID)-1], {0x01, 0x00, 0, 0, 0, 0, 0, 5}} {
+		if got := DecodeSID(raw); got != "" {
+			t.Errorf("DecodeSID(%x) = %q, want \"\"", raw, got)
+		}
+	}
+}
+
+func TestEncodeSIDRoundTrip(t *testing.T) {
+	raw, err := EncodeSID(knownSIDString)
+	if err != nil {
+		t.Fatalf("EncodeSID失败: %v", err)
+	}
+	if !bytes.Equal(raw, knownSID) {
+		t.Errorf("EncodeSID = %x, want %x", raw, knownSID)
+	}
+
+	for _, sid := range []string{"S-1-5-18", "S-1-5-32-544", knownSIDString} {
+		raw, err := EncodeSID(sid)
+		if err != nil {
+			t.Errorf("EncodeSID(%q)失败: %v", sid, err)
+			continue
+		}
+		if got := DecodeSID(raw); got != sid {
+			t.Errorf("往返转换 %q 得到 %q", sid, got)
+		}
+	}
+
+	for _, sid := range []string{"", "S-1-5", "X-1-5-18", "S-1-5-abc", "S-256-5-18"} {
+		if _, err := EncodeSID(sid); err == nil {
+			t.Errorf("EncodeSID(%q) 应返回错误", sid)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package ldap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// SSOCheck 单项SSO配置检查：期望值与实际值
+type SSOCheck struct {
+	Name     string
+	Expected string
+	Actual   string
+	Matched  bool
+}
+
+// SSOVerification 组的SSO配置验证结果
+type SSOVerification struct {
+	GroupDN  string
+	SearchDN string
+	Checks   []SSOCheck
+}
+
+// add 添加一项检查结果
+func (v *SSOVerification) add(name string, expected string, actual string, matched bool) {
+	v.Checks = append(v.Checks, SSOCheck{Name: name, Expected: expected, Actual: actual, Matched: matched})
+}
+
+// Passed 返回所有检查项是否都匹配
+func (v *SSOVerification) Passed() bool {
+	for _, check := range v.Checks {
+		if !check.Matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary 返回验证结果的可读描述，每项一行
+func (v *SSOVerification) Summary() []string {
+	lines := []string{"组：" + v.GroupDN}
+	for _, check := range v.Checks {
+		if check.Matched {
+			lines = append(lines, fmt.Sprintf("[匹配] %s：%s", check.Name, check.Actual))
+		} else {
+			lines = append(lines, fmt.Sprintf("[不匹配] %s：期望 %s，实际 %s", check.Name, check.Expected, check.Actual))
+		}
+	}
+	return lines
+}
+
+// valueOrEmpty 空值显示为"（未设置）"
+func valueOrEmpty(value string) string {
+	if value == "" {
+		return "（未设置）"
+	}
+	return value
+}
+
+// VerifyGroupSSO 读回组的groupType、description，AD目录还检查searchDN的DACL是否授予组读取权限
+// groupType为期望的属性值（见GroupType）
+func (client *LDAPClient) VerifyGroupSSO(groupDN string, searchDN string, groupType string) (*SSOVerification, error) {
+	client.Debug("验证SSO配置：%s", groupDN)
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer release()
+
+	entry, err := readEntry(conn, groupDN, []string{"groupType", "description", "objectSid"})
+	if err != nil {
+		return nil, fmt.Errorf("读取组失败: %s", ParseLDAPError(err))
+	}
+
+	result := &SSOVerification{GroupDN: groupDN, SearchDN: searchDN}
+	actualType := entry.GetAttributeValue("groupType")
+	result.add("groupType", groupType, valueOrEmpty(actualType), actualType == groupType)
+	description := entry.GetAttributeValue("description")
+	result.add("description", SSOGroupDescription, valueOrEmpty(description), description == SSOGroupDescription)
+
+	if !client.isActiveDirectory() {
+		return result, nil
+	}
+
+	// 检查搜索DN上的DACL
+	name := "DACL（" + searchDN + "）"
+	expectedACE := "允许 " + describeAccessMask(ssoReadRights)
+	sid := entry.GetRawAttributeValue("objectSid")
+	if err := validateSID(sid); err != nil {
+		result.add(name, expectedACE, "无法读取组objectSid："+err.Error(), false)
+		return result, nil
+	}
+	sd, err := readSecurityDescriptor(conn, searchDN, DACL_SECURITY_INFORMATION)
+	if err != nil {
+		result.add(name, expectedACE, "读取安全描述符失败："+ParseLDAPError(err), false)
+		return result, nil
+	}
+	_, aces, err := parseDACL(sd)
+	if err != nil {
+		result.add(name, expectedACE, err.Error(), false)
+		return result, nil
+	}
+
+	if hasAllowedACE(aces, sid, ssoReadRights) {
+		result.add(name, expectedACE, "已授予 "+DecodeSID(sid)+" "+describeAccessMask(ssoReadRights), true)
+		return result, nil
+	}
+
+	// 列出已授予该组的权限，便于判断缺少哪些
+	var granted []string
+	for _, ace := range aces {
+		if ace[0] == ACCESS_ALLOWED_ACE_TYPE && bytes.Equal(aceSID(ace), sid) {
+			granted = append(granted, describeAccessMask(binary.LittleEndian.Uint32(ace[4:8])))
+		}
+	}
+	actual := "未找到授予 " + DecodeSID(sid) + " 的ACE"
+	if len(granted) > 0 {
+		actual = "仅授予 " + strings.Join(granted, "、")
+	}
+	result.add(name, expectedACE, actual, false)
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// RootCAs 用于验证服务器证书的受信任CA，为nil时使用系统证书库
+var RootCAs *x509.CertPool
+
+// CAFile 当前加载的CA证书文件路径
+var CAFile string
+
+// LoadCAFile 加载PEM格式的CA证书文件，加载后将执行证书验证
+func LoadCAFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取CA证书失败: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return errors.New("CA证书文件中没有有效的PEM证书")
+	}
+	RootCAs = pool
+	CAFile = path
+	SkipTLSVerify = false
+	return nil
+}
+
+// ClearCAFile 清除已加载的CA证书，恢复使用系统证书库
+func ClearCAFile() {
+	RootCAs = nil
+	CAFile = ""
+}
+
+// logServerCertificate 记录TLS握手后服务器证书的主题、颁发者和有效期（每个客户端只记录一次）
+func (client *LDAPClient) logServerCertificate(conn *ldap.Conn) {
+	if client.certLogged {
+		return
+	}
+	state, ok := conn.TLSConnectionState()
+	if !ok || len(state.PeerCertificates) == 0 {
+		return
+	}
+	client.certLogged = true
+	client.Info("服务器证书：%s", certificateSummary(state.PeerCertificates[0]))
+}
+
+// certificateSummary 返回证书主题、颁发者和有效期的简要描述
+func certificateSummary(cert *x509.Certificate) string {
+	return strings.Join([]string{
+		"主题：" + cert.Subject.String(),
+		"颁发者：" + cert.Issuer.String(),
+		"有效期至：" + cert.NotAfter.Format("2006-01-02 15:04:05"),
+	}, "，")
+}
+
+// TLSConnectionState 建立SSL连接并返回TLS握手状态
+func (client *LDAPClient) TLSConnectionState() (tls.ConnectionState, error) {
+	if !client.isSSLMode {
+		return tls.ConnectionState{}, errors.New("仅SSL连接才有证书信息")
+	}
+	conn, err := client.dial()
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	defer client.closeOnCancel(conn)()
+
+	state, ok := conn.TLSConnectionState()
+	if !ok {
+		return tls.ConnectionState{}, errors.New("连接未使用TLS")
+	}
+	return state, nil
+}
+
+// InspectServerCertificate 返回服务器证书链的详细信息
+// 证书验证失败时改为不验证握手，仍然返回证书信息并附上验证失败原因
+func (client *LDAPClient) InspectServerCertificate() ([]string, error) {
+	state, err := client.TLSConnectionState()
+	var verifyErr error
+	if err != nil {
+		if errors.Is(err, ErrOperationCanceled) || !isCertificateError(err) {
+			return nil, err
+		}
+		verifyErr = err
+		client.Debug("证书验证失败，改为不验证握手以读取证书：%v", err)
+		if state, err = client.unverifiedHandshake(); err != nil {
+			return nil, err
+		}
+	}
+	if len(state.PeerCertificates) == 0 {
+		return nil, errors.New("服务器未提供证书")
+	}
+
+	var lines []string
+	for i, cert := range state.PeerCertificates {
+		lines = append(lines, fmt.Sprintf("证书 #%d：", i))
+		lines = append(lines, "  CN："+cert.Subject.CommonName)
+		if len(cert.DNSNames) > 0 || len(cert.IPAddresses) > 0 {
+			sans := append([]string{}, cert.DNSNames...)
+			for _, ip := range cert.IPAddresses {
+				sans = append(sans, ip.String())
+			}
+			lines = append(lines, "  SAN："+strings.Join(sans, ", "))
+		}
+		lines = append(lines, "  颁发者："+cert.Issuer.String())
+		lines = append(lines, "  生效时间："+cert.NotBefore.Format("2006-01-02 15:04:05"))
+		lines = append(lines, "  过期时间："+cert.NotAfter.Format("2006-01-02 15:04:05"))
+	}
+
+	leaf := state.PeerCertificates[0]
+	if err := leaf.VerifyHostname(client.Host); err != nil {
+		lines = append(lines, "主机名 "+client.Host+" 与证书不匹配："+err.Error())
+	} else {
+		lines = append(lines, "主机名 "+client.Host+" 与证书匹配")
+	}
+	if time.Now().After(leaf.NotAfter) {
+		lines = append(lines, "证书已过期")
+	}
+	if verifyErr != nil {
+		lines = append(lines, "证书验证失败："+verifyErr.Error())
+	}
+	return lines, nil
+}
+
+// unverifiedHandshake 不验证证书完成TLS握手，仅用于读取证书信息
+func (client *LDAPClient) unverifiedHandshake() (tls.ConnectionState, error) {
+	config := client.GetTLSConfig()
+	config.InsecureSkipVerify = true
+
+	conn, err := client.dialContext(client.Address(), config, 10*time.Second)
+	if err != nil {
+		return tls.ConnectionState{}, fmt.Errorf("TLS握手失败: %v", err)
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState(), nil
+}
+
+// isCertificateError 判断错误是否由证书验证失败引起
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameError x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var verifyError *tls.CertificateVerificationError
+	return errors.As(err, &unknownAuthority) || errors.As(err, &hostnameError) ||
+		errors.As(err, &invalidCert) || errors.As(err, &verifyError) ||
+		strings.Contains(err.Error(), "certificate")
+}
@@ -0,0 +1,59 @@
+package ldap
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// LDAP和LDAPS的默认端口
+const (
+	DefaultLDAPPort  = 389
+	DefaultLDAPSPort = 636
+)
+
+// ParseLDAPURL 解析"ldap://host:port"或"ldaps://host:port"形式的连接URL
+// 返回主机、端口和是否使用SSL，未指定端口时使用对应的默认端口
+func ParseLDAPURL(raw string) (string, int, bool, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", 0, false, fmt.Errorf("URL格式无效: %v", err)
+	}
+
+	var ssl bool
+	switch strings.ToLower(u.Scheme) {
+	case "ldap":
+	case "ldaps":
+		ssl = true
+	default:
+		return "", 0, false, fmt.Errorf("不支持的协议 %q，请使用ldap://或ldaps://", u.Scheme)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return "", 0, false, fmt.Errorf("URL中缺少主机地址")
+	}
+
+	port := DefaultLDAPPort
+	if ssl {
+		port = DefaultLDAPSPort
+	}
+	if u.Port() != "" {
+		port, err = strconv.Atoi(u.Port())
+		if err != nil || port < 1 || port > 65535 {
+			return "", 0, false, fmt.Errorf("端口无效: %s", u.Port())
+		}
+	}
+	return host, port, ssl, nil
+}
+
+// FormatLDAPURL 根据主机、端口和SSL设置生成连接URL
+func FormatLDAPURL(host string, port int, ssl bool) string {
+	scheme := "ldap"
+	if ssl {
+		scheme = "ldaps"
+	}
+	return scheme + "://" + net.JoinHostPort(NormalizeHost(host), strconv.Itoa(port))
+}
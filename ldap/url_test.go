@@ -0,0 +1,47 @@
+package ldap
+
+import "testing"
+
+func TestFormatLDAPURL(t *testing.T) {
+	tests := []struct {
+		host string
+		port int
+		ssl  bool
+		want string
+	}{
+		{"::1", 636, true, "ldaps://[::1]:636"},
+		{"[::1]", 636, true, "ldaps://[::1]:636"},
+		{"fe80::1", 389, false, "ldap://[fe80::1]:389"},
+		{"dc01.example.com", 389, false, "ldap://dc01.example.com:389"},
+		{" 192.168.1.10 ", 636, true, "ldaps://192.168.1.10:636"},
+	}
+	for _, tt := range tests {
+		if got := FormatLDAPURL(tt.host, tt.port, tt.ssl); got != tt.want {
+			t.Errorf("FormatLDAPURL(%q, %d, %v) = %q, want %q", tt.host, tt.port, tt.ssl, got, tt.want)
+		}
+	}
+}
+
+// TestGetURLIPv6 IPv6地址作为主机时GetURL应加上方括号
+func TestGetURLIPv6(t *testing.T) {
+	log, _ := captureLogger()
+	client := NewLDAPClient("::1", 636, "", "", log, nil, true, false)
+	if got := client.GetURL(); got != "ldaps://[::1]:636" {
+		t.Errorf("GetURL = %q, want ldaps://[::1]:636", got)
+	}
+	client = NewLDAPClient("[::1]", 389, "", "", log, nil, false, false)
+	if got := client.GetURL(); got != "ldap://[::1]:389" {
+		t.Errorf("GetURL = %q, want ldap://[::1]:389", got)
+	}
+}
+
+func TestParseLDAPURLIPv6(t *testing.T) {
+	host, port, ssl, err := ParseLDAPURL(FormatLDAPURL("::1", 636, true))
+	if err != nil || host != "::1" || port != 636 || !ssl {
+		t.Errorf("ParseLDAPURL = %q, %d, %v, %v", host, port, ssl, err)
+	}
+	host, port, ssl, err = ParseLDAPURL("ldap://[::1]")
+	if err != nil || host != "::1" || port != DefaultLDAPPort || ssl {
+		t.Errorf("ParseLDAPURL默认端口 = %q, %d, %v, %v", host, port, ssl, err)
+	}
+}
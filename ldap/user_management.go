@@ -2,25 +2,44 @@ package ldap
 
 import (
 	"errors"
+	"strconv"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"github.com/go-ldap/ldap/v3"
 )
 
+// userAccountControl 标志位
+const (
+	UAC_ACCOUNTDISABLE       uint32 = 0x00000002
+	UAC_LOCKOUT              uint32 = 0x00000010
+	UAC_NORMAL_ACCOUNT       uint32 = 0x00000200
+	UAC_DONT_EXPIRE_PASSWORD uint32 = 0x00010000
+	UAC_PASSWORD_EXPIRED     uint32 = 0x00800000
+)
+
 // SearchUserInDomain 在域中搜索用户
 func (client *LDAPClient) SearchUserInDomain(username string) (bool, string) {
 	// 确保连接有效
-	conn, err := client.GetConnection()
+	conn, release, err := client.acquireConnection()
 	if err != nil {
 		client.Error("搜索用户时连接失败: %v", err)
 		return false, ""
 	}
+	defer release()
+
+	// 自动检测搜索基准
+	baseDN, err := client.DetectBaseDN()
+	if err != nil {
+		client.Error("检测基准DN失败: %v", err)
+		return false, ""
+	}
 
 	// 构建搜索请求
 	searchRequest := ldap.NewSearchRequest(
-		client.BindDN, // 使用绑定DN作为搜索基准
-		ldap.ScopeWholeSubtree,
+		baseDN,
+		client.searchScope,
 		ldap.NeverDerefAliases,
 		0, 0, false,
 		"(&(objectClass=user)(sAMAccountName="+ldap.EscapeFilter(username)+"))",
@@ -47,19 +66,20 @@ func (client *LDAPClient) SearchUserInDomain(username string) (bool, string) {
 func (client *LDAPClient) SearchUser(userName string, searchBase string) (bool, string) {
 	client.Debug("正在搜索用户：%s，搜索范围：%s", userName, searchBase)
 	// 获取有效连接
-	conn, err := client.GetConnection()
+	conn, release, err := client.acquireConnection()
 	if err != nil {
 		client.Error("连接失败: %v", err)
 		return false, ""
 	}
+	defer release()
 
 	// 构建搜索请求，按CN进行模糊查询
 	searchRequest := ldap.NewSearchRequest(
 		searchBase,
-		ldap.ScopeWholeSubtree,
+		client.searchScope,
 		ldap.NeverDerefAliases,
 		0, 0, false,
-		"(&(objectClass=user)(cn="+ldap.EscapeFilter(userName)+"))",
+		"(&(objectClass="+client.userTemplate.UserObjectClass+")(cn="+ldap.EscapeFilter(userName)+"))",
 		[]string{"dn"},
 		nil,
 	)
@@ -79,72 +99,177 @@ func (client *LDAPClient) SearchUser(userName string, searchBase string) (bool,
 	return false, ""
 }
 
-// TestUserAuth 测试用户认证
-func (client *LDAPClient) TestUserAuth(testUser string, testPassword string, searchDN string, filterPattern string) bool {
-	client.Debug("正在测试用户认证：%s，搜索范围：%s", testUser, searchDN)
-	// 获取有效连接
-	conn, err := client.GetConnection()
+// FindUserEntries 使用过滤器模式搜索用户，返回所有匹配的条目
+func (client *LDAPClient) FindUserEntries(testUser string, searchDN string, filterPattern string) ([]*ldap.Entry, error) {
+	return client.findUserEntries(testUser, searchDN, filterPattern, []string{"dn"})
+}
+
+// findUserEntries 使用过滤器模式搜索用户，并返回指定的属性
+func (client *LDAPClient) findUserEntries(testUser string, searchDN string, filterPattern string, attributes []string) ([]*ldap.Entry, error) {
+	conn, release, err := client.acquireConnection()
 	if err != nil {
-		client.Error("测试用户认证时连接失败: %v", err)
-		return false
+		return nil, err
 	}
+	defer release()
 
 	// 构建搜索请求
 	searchRequest := ldap.NewSearchRequest(
-		searchDN,                                       // 基准DN
-		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, // 搜索范围和别名处理
-		0, 0, false, // 大小限制，时间限制，仅类型
+		searchDN,                                   // 基准DN
+		client.searchScope, ldap.NeverDerefAliases, // 搜索范围和别名处理
+		client.sizeLimit, client.timeLimit, false, // 大小限制，时间限制，仅类型
 		strings.Replace(filterPattern, "%s", ldap.EscapeFilter(testUser), 1), // 搜索过滤器
-		[]string{"dn"}, // 返回属性
+		attributes, // 返回属性
 		nil,
 	)
 
 	// 执行搜索
 	sr, err := conn.Search(searchRequest)
+	switch {
+	case isSizeLimitExceeded(err):
+		return nil, ErrSizeLimitExceeded
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultTimeLimitExceeded):
+		return nil, ErrTimeLimitExceeded
+	case err != nil:
+		return nil, err
+	}
+	return append(sr.Entries, client.handleReferrals(sr.Referrals, searchRequest)...), nil
+}
+
+// userAuthAttributes 用户验证时读取的属性，用于排查问题
+var userAuthAttributes = []string{"userAccountControl", "memberOf"}
+
+// AuthResult 用户验证的详细结果，区分未找到用户和绑定失败
+type AuthResult struct {
+	Found     bool        // 是否找到用户
+	MatchedDN string      // 匹配到的用户DN
+	BindOK    bool        // 用户绑定是否成功
+	ErrCode   int         // 失败时的LDAP结果码，非LDAP错误为0
+	ErrDetail string      // 失败原因
+	Category  string      // 失败类别，用于汇总统计
+	Entry     *ldap.Entry // 匹配到的用户条目
+}
+
+// 用户验证的失败类别
+const (
+	AuthCategorySearchFailed  = "搜索失败"
+	AuthCategoryNotFound      = "未找到用户"
+	AuthCategoryConnectFailed = "连接失败"
+)
+
+// Success 返回用户是否找到且绑定成功
+func (result *AuthResult) Success() bool {
+	return result.Found && result.BindOK
+}
+
+// fail 记录失败类别、原因和对应的LDAP结果码
+func (result *AuthResult) fail(category string, detail string, err error) {
+	result.ErrCode = LDAPResultCode(err)
+	result.ErrDetail = detail
+	result.Category = category
+}
+
+// bindFailureCategory 返回绑定失败的类别：优先使用AD子错误码的含义，其次使用LDAP结果码名称
+func bindFailureCategory(err error) string {
+	if _, explanation, ok := ParseADErrorCode(err); ok {
+		return explanation
+	}
+	if code := LDAPResultCode(err); code != 0 {
+		if name, ok := ldap.LDAPResultCodeMap[uint16(code)]; ok {
+			return name
+		}
+	}
+	return "绑定失败"
+}
+
+// Summary 返回验证结果的可读描述，每项一行
+func (result *AuthResult) Summary() []string {
+	if !result.Found {
+		return []string{result.ErrDetail}
+	}
+	lines := []string{"匹配DN：" + result.MatchedDN}
+	if value := result.Entry.GetAttributeValue("userAccountControl"); value != "" {
+		state := "启用"
+		if uac, err := strconv.ParseUint(value, 10, 32); err == nil && uint32(uac)&UAC_ACCOUNTDISABLE != 0 {
+			state = "停用"
+		}
+		lines = append(lines, "账户状态："+state+"（userAccountControl="+value+"）")
+	}
+	groups := result.Entry.GetAttributeValues("memberOf")
+	lines = append(lines, "所属组（"+strconv.Itoa(len(groups))+"）：")
+	for _, group := range groups {
+		lines = append(lines, "  "+group)
+	}
+	if !result.BindOK {
+		line := "绑定失败：" + result.ErrDetail
+		if result.ErrCode != 0 {
+			line += "（LDAP结果码 " + strconv.Itoa(result.ErrCode) + "）"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// TestUserAuthDetailed 搜索用户并使用其凭据绑定，返回的结果区分未找到用户、搜索失败和绑定失败
+func (client *LDAPClient) TestUserAuthDetailed(testUser string, testPassword string, searchDN string, filterPattern string) *AuthResult {
+	client.Debug("正在测试用户认证：%s，搜索范围：%s", testUser, searchDN)
+	result := &AuthResult{}
+
+	attributes := append([]string{client.userTemplate.NamingAttribute}, userAuthAttributes...)
+	entries, err := client.findUserEntries(testUser, searchDN, filterPattern, attributes)
 	if err != nil {
-		client.Error("搜索用户失败: %v", err)
-		return false
+		result.fail(AuthCategorySearchFailed, "搜索用户失败: "+ParseLDAPError(err), err)
+		return result
 	}
 
 	// 检查结果
-	if len(sr.Entries) == 0 {
-		client.Warn("未找到用户: %s", testUser)
-		return false
+	if len(entries) == 0 {
+		result.ErrCode = ldap.LDAPResultNoSuchObject
+		result.ErrDetail = "未找到用户: " + testUser
+		result.Category = AuthCategoryNotFound
+		return result
 	}
-
-	// 获取用户DN
-	userDN := sr.Entries[0].DN
+	result.Found = true
+	result.Entry = entries[0]
+	result.MatchedDN = entries[0].DN
 
 	// 创建新的连接用于认证
-	authConn, connErr := client.GetConnection()
-	if connErr != nil {
-		client.Error("创建认证连接失败: %v", connErr)
-		return false
+	authConn, err := client.GetConnection()
+	if err != nil {
+		result.fail(AuthCategoryConnectFailed, "创建认证连接失败: "+ParseLDAPError(err), err)
+		return result
 	}
 	defer authConn.Close()
+	defer client.closeOnCancel(authConn)()
 
 	// 尝试使用用户凭据绑定
-	err = authConn.Bind(userDN, testPassword)
-	if err != nil {
-		client.Error("用户认证失败: %v", err)
+	if err := authConn.Bind(result.MatchedDN, testPassword); err != nil {
+		result.fail(bindFailureCategory(err), ParseLDAPError(err), err)
+		return result
+	}
+	result.BindOK = true
+	return result
+}
+
+// TestUserAuth 测试用户认证，返回是否成功
+func (client *LDAPClient) TestUserAuth(testUser string, testPassword string, searchDN string, filterPattern string) bool {
+	result := client.TestUserAuthDetailed(testUser, testPassword, searchDN, filterPattern)
+	if !result.Success() {
+		client.Error("用户认证失败: %s", strings.Join(result.Summary(), "; "))
 		return false
 	}
 
-	client.Info("用户认证成功: %s", userDN)
+	client.Info("用户认证成功: %s", result.MatchedDN)
 	return true
 }
 
 // CreateUserWithoutSSL 在非SSL模式下创建用户（禁用状态）
 func (client *LDAPClient) CreateUserWithoutSSL(userDN string, userName string, host string) error {
 	client.Debug("开始创建用户：%s", userDN)
-	// 获取有效连接
-	conn, err := client.GetConnection()
+	// 确保父容器存在
+	parentDN, err := ParentDN(userDN)
 	if err != nil {
-		return errors.New("获取连接失败: " + err.Error())
+		return errors.New("用户DN无效: " + err.Error())
 	}
-
-	// 确保父容器存在
-	parentDN := strings.SplitN(userDN, ",", 2)[1]
 	if err := client.EnsureDNExists(parentDN); err != nil {
 		return errors.New("创建路径失败: " + ParseLDAPError(err))
 	}
@@ -153,12 +278,15 @@ func (client *LDAPClient) CreateUserWithoutSSL(userDN string, userName string, h
 	addRequest := ldap.NewAddRequest(userDN, nil)
 
 	// 设置必要的属性
-	addRequest.Attribute("objectClass", []string{"top", "person", "organizationalPerson", "user"})
-	addRequest.Attribute("sAMAccountName", []string{userName})
+	addRequest.Attribute("objectClass", client.userTemplate.ObjectClasses)
+	addRequest.Attribute(client.userTemplate.NamingAttribute, []string{userName})
 	addRequest.Attribute("userAccountControl", []string{"514"}) // 禁用账户
 
 	// 执行创建
-	if err := conn.Add(addRequest); err != nil {
+	err = client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.add(conn, addRequest)
+	})
+	if err != nil {
 		return errors.New("创建用户失败: " + err.Error())
 	}
 
@@ -170,14 +298,11 @@ func (client *LDAPClient) CreateUserWithoutSSL(userDN string, userName string, h
 func (client *LDAPClient) CreateUserWithSSL(userDN string, userName string, password string, host string, myWindow fyne.Window) error {
 	client.Debug("开始创建用户，用户DN: %s", userDN)
 
-	// 获取有效连接
-	conn, err := client.GetConnection()
+	// 确保父容器存在
+	parentDN, err := ParentDN(userDN)
 	if err != nil {
-		return errors.New("获取连接失败: " + err.Error())
+		return errors.New("用户DN无效: " + err.Error())
 	}
-
-	// 确保父容器存在
-	parentDN := strings.SplitN(userDN, ",", 2)[1]
 	if err := client.EnsureDNExists(parentDN); err != nil {
 		return errors.New("创建路径失败: " + ParseLDAPError(err))
 	}
@@ -188,8 +313,8 @@ func (client *LDAPClient) CreateUserWithSSL(userDN string, userName string, pass
 
 	// 设置必要的属性
 	client.Debug("开始设置用户属性")
-	addRequest.Attribute("objectClass", []string{"top", "person", "organizationalPerson", "user"})
-	addRequest.Attribute("sAMAccountName", []string{userName})
+	addRequest.Attribute("objectClass", client.userTemplate.ObjectClasses)
+	addRequest.Attribute(client.userTemplate.NamingAttribute, []string{userName})
 	addRequest.Attribute("userAccountControl", []string{"512"}) // 启用账户
 
 	// 设置其他推荐属性
@@ -219,12 +344,18 @@ func (client *LDAPClient) CreateUserWithSSL(userDN string, userName string, pass
 
 	// 设置密码
 	client.Debug("开始设置用户密码")
-	encodedPassword := EncodePassword(password)
-	addRequest.Attribute("unicodePwd", []string{encodedPassword})
+	passwordAttr, encodedPassword, err := client.encodePasswordAttribute(password)
+	if err != nil {
+		return errors.New("创建用户失败: " + err.Error())
+	}
+	addRequest.Attribute(passwordAttr, []string{encodedPassword})
 
 	// 执行创建
 	client.Debug("执行创建用户操作")
-	if err := conn.Add(addRequest); err != nil {
+	err = client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.add(conn, addRequest)
+	})
+	if err != nil {
 		return errors.New("创建用户失败: " + err.Error())
 	}
 
@@ -235,6 +366,10 @@ func (client *LDAPClient) CreateUserWithSSL(userDN string, userName string, pass
 // CreateOrUpdateUser 创建或更新用户
 func (client *LDAPClient) CreateOrUpdateUser(userDN string, userName string, password string, isSSL bool) error {
 	client.Debug("开始创建/更新用户：%s", userDN)
+	if !client.isActiveDirectory() {
+		// 非AD目录：按模板创建，userPassword不依赖SSL
+		return client.CreateUserFromTemplate(userDN, userName, password)
+	}
 	if isSSL {
 		// SSL模式：创建启用账号并设置密码
 		return client.CreateUserWithSSL(userDN, userName, password, client.Host, nil)
@@ -252,7 +387,10 @@ func (client *LDAPClient) MoveUserToNewLocation(currentDN string, targetDN strin
 	}
 
 	// 确保目标路径存在
-	parentDN := strings.SplitN(targetDN, ",", 2)[1]
+	parentDN, err := ParentDN(targetDN)
+	if err != nil {
+		return errors.New("目标DN无效: " + err.Error())
+	}
 	if err := client.EnsureDNExists(parentDN); err != nil {
 		return errors.New("创建目标路径失败: " + ParseLDAPError(err))
 	}
@@ -264,3 +402,135 @@ func (client *LDAPClient) MoveUserToNewLocation(currentDN string, targetDN strin
 
 	return nil
 }
+
+// AccountEnabled 读取账户是否已启用（userAccountControl未设置ACCOUNTDISABLE位）
+func (client *LDAPClient) AccountEnabled(userDN string) (bool, error) {
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return false, errors.New("获取连接失败: " + err.Error())
+	}
+	defer release()
+
+	uac, err := client.readUserAccountControl(conn, userDN)
+	if err != nil {
+		return false, err
+	}
+	return uac&UAC_ACCOUNTDISABLE == 0, nil
+}
+
+// SetAccountEnabled 启用或停用账户（修改userAccountControl的ACCOUNTDISABLE位）
+func (client *LDAPClient) SetAccountEnabled(userDN string, enabled bool) error {
+	client.Debug("正在设置账户状态：%s，启用：%v", userDN, enabled)
+	conn, release, err := client.acquireConnection()
+	if err != nil {
+		return errors.New("获取连接失败: " + err.Error())
+	}
+	defer release()
+
+	uac, err := client.readUserAccountControl(conn, userDN)
+	if err != nil {
+		return err
+	}
+	client.Debug("当前userAccountControl：%d", uac)
+
+	if enabled {
+		uac &^= UAC_ACCOUNTDISABLE
+	} else {
+		uac |= UAC_ACCOUNTDISABLE
+	}
+
+	modifyRequest := ldap.NewModifyRequest(userDN, nil)
+	modifyRequest.Replace("userAccountControl", []string{strconv.FormatUint(uint64(uac), 10)})
+	if err := client.modify(conn, modifyRequest); err != nil {
+		return errors.New("修改账户状态失败: " + ParseLDAPError(err))
+	}
+
+	client.Info("账户状态已更新：%s，userAccountControl=%d", userDN, uac)
+	return nil
+}
+
+// readUserAccountControl 读取用户当前的userAccountControl，属性缺失时按普通账户处理
+func (client *LDAPClient) readUserAccountControl(conn *ldap.Conn, userDN string) (uint32, error) {
+	searchRequest := ldap.NewSearchRequest(
+		userDN,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0, 0, false,
+		"(objectClass=*)",
+		[]string{"userAccountControl"},
+		nil,
+	)
+
+	sr, err := conn.Search(searchRequest)
+	if err != nil {
+		return 0, errors.New("读取账户状态失败: " + ParseLDAPError(err))
+	}
+	if len(sr.Entries) == 0 {
+		return 0, errors.New("未找到用户: " + userDN)
+	}
+
+	uac := UAC_NORMAL_ACCOUNT
+	if value := sr.Entries[0].GetAttributeValue("userAccountControl"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, errors.New("无效的userAccountControl值: " + value)
+		}
+		uac = uint32(parsed)
+	} else {
+		client.Warn("用户缺少userAccountControl属性，按NORMAL_ACCOUNT处理")
+	}
+	return uac, nil
+}
+
+// ForcePasswordChangeAtNextLogon 要求用户下次登录时修改密码（仅AD，设置pwdLastSet=0）
+func (client *LDAPClient) ForcePasswordChangeAtNextLogon(userDN string) error {
+	return client.setPwdLastSet(userDN, "0")
+}
+
+// ClearPasswordChangeAtNextLogon 取消下次登录修改密码的要求（仅AD，设置pwdLastSet=-1）
+func (client *LDAPClient) ClearPasswordChangeAtNextLogon(userDN string) error {
+	return client.setPwdLastSet(userDN, "-1")
+}
+
+// SetAccountExpiry 设置账户过期时间（仅AD，替换accountExpires），零值时间表示永不过期
+// AD把0和0x7FFFFFFFFFFFFFFF都视为永不过期，这里与新建账户一样写入后者
+func (client *LDAPClient) SetAccountExpiry(userDN string, expiry time.Time) error {
+	if !client.isActiveDirectory() {
+		return errors.New("accountExpires仅适用于Active Directory")
+	}
+
+	value := strconv.FormatInt(fileTimeNever, 10)
+	if !expiry.IsZero() {
+		value = strconv.FormatInt(TimeToFileTime(expiry), 10)
+	}
+	client.Debug("正在设置accountExpires：%s = %s", userDN, value)
+
+	modifyRequest := ldap.NewModifyRequest(userDN, nil)
+	modifyRequest.Replace("accountExpires", []string{value})
+	err := client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modify(conn, modifyRequest)
+	})
+	if err != nil {
+		return errors.New("设置账户有效期失败: " + ParseLDAPError(err))
+	}
+	return nil
+}
+
+// setPwdLastSet 替换用户的pwdLastSet属性
+func (client *LDAPClient) setPwdLastSet(userDN string, value string) error {
+	if !client.isActiveDirectory() {
+		return errors.New("pwdLastSet仅适用于Active Directory")
+	}
+	client.Debug("正在设置pwdLastSet：%s = %s", userDN, value)
+	modifyRequest := ldap.NewModifyRequest(userDN, nil)
+	modifyRequest.Replace("pwdLastSet", []string{value})
+	err := client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.modify(conn, modifyRequest)
+	})
+	if err != nil {
+		return errors.New("设置pwdLastSet失败: " + ParseLDAPError(err))
+	}
+
+	client.Info("已设置pwdLastSet=%s：%s", value, userDN)
+	return nil
+}
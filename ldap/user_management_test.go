@@ -0,0 +1,64 @@
+package ldap
+
+import (
+	"testing"
+	"time"
+)
+
+// waitForOpenConnections 等待测试服务器上打开的连接数降到want，超时返回实际连接数
+func waitForOpenConnections(server *fakeServer, want int32) int32 {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		open := server.open.Load()
+		if open == want || time.Now().After(deadline) {
+			return open
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSearchUserDoesNotLeakConnections 连续搜索100次后服务器上不应残留打开的连接
+func TestSearchUserDoesNotLeakConnections(t *testing.T) {
+	server := newFakeServer(t)
+	server.entryAttributes = map[string][]string{"cn": {"user1"}}
+	log, _ := captureLogger()
+	client := server.client(log)
+
+	for i := 0; i < 100; i++ {
+		if found, _ := client.SearchUser("user1", "dc=example,dc=com"); !found {
+			t.Fatalf("第 %d 次搜索未找到用户", i+1)
+		}
+	}
+
+	if accepted := server.accepted.Load(); accepted != 100 {
+		t.Errorf("建立了 %d 个连接, want 100", accepted)
+	}
+	if open := waitForOpenConnections(server, 0); open != 0 {
+		t.Errorf("搜索结束后仍有 %d 个连接未关闭", open)
+	}
+}
+
+// TestSearchUserReusesSharedConnection 共享连接期间的搜索复用同一个连接，释放后连接关闭
+func TestSearchUserReusesSharedConnection(t *testing.T) {
+	server := newFakeServer(t)
+	server.entryAttributes = map[string][]string{"cn": {"user1"}}
+	log, _ := captureLogger()
+	client := server.client(log)
+
+	if err := client.UseSharedConnection(); err != nil {
+		t.Fatalf("建立共享连接失败: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if found, _ := client.SearchUser("user1", "dc=example,dc=com"); !found {
+			t.Fatalf("第 %d 次搜索未找到用户", i+1)
+		}
+	}
+	if accepted := server.accepted.Load(); accepted != 1 {
+		t.Errorf("建立了 %d 个连接, want 1", accepted)
+	}
+
+	client.ReleaseSharedConnection()
+	if open := waitForOpenConnections(server, 0); open != 0 {
+		t.Errorf("释放共享连接后仍有 %d 个连接未关闭", open)
+	}
+}
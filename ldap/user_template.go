@@ -0,0 +1,139 @@
+package ldap
+
+import (
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// 目录类型
+const (
+	DirectoryTypeAD       = "ActiveDirectory"
+	DirectoryTypeADLDS    = "ADLDS" // AD轻型目录服务，没有域账户属性，使用所选的通用模板
+	DirectoryTypeOpenLDAP = "OpenLDAP"
+	DirectoryTypeUnknown  = "Unknown"
+)
+
+// UserTemplate 定义创建用户时使用的对象类和属性模板
+type UserTemplate struct {
+	Name               string   // 模板名称
+	DirectoryType      string   // 目录类型
+	ObjectClasses      []string // 对象类
+	UserObjectClass    string   // 搜索用户时使用的对象类
+	NamingAttribute    string   // 登录名属性
+	PasswordAttribute  string   // 密码属性
+	RequiredAttributes []string // 必需属性（值使用用户名）
+}
+
+// UserTemplates 返回可选的用户创建模板
+func UserTemplates() []UserTemplate {
+	return []UserTemplate{
+		{
+			Name:              "Active Directory",
+			DirectoryType:     DirectoryTypeAD,
+			ObjectClasses:     []string{"top", "person", "organizationalPerson", "user"},
+			UserObjectClass:   "user",
+			NamingAttribute:   "sAMAccountName",
+			PasswordAttribute: "unicodePwd",
+		},
+		{
+			Name:               "OpenLDAP (inetOrgPerson)",
+			DirectoryType:      DirectoryTypeOpenLDAP,
+			ObjectClasses:      []string{"top", "person", "organizationalPerson", "inetOrgPerson"},
+			UserObjectClass:    "inetOrgPerson",
+			NamingAttribute:    "uid",
+			PasswordAttribute:  "userPassword",
+			RequiredAttributes: []string{"cn", "sn"},
+		},
+	}
+}
+
+// DefaultUserTemplate 返回默认模板（Active Directory）
+func DefaultUserTemplate() UserTemplate {
+	return UserTemplates()[0]
+}
+
+// UserTemplateByName 根据名称查找模板
+func UserTemplateByName(name string) (UserTemplate, bool) {
+	for _, t := range UserTemplates() {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return UserTemplate{}, false
+}
+
+// UserTemplateForDirectoryType 返回目录类型对应的第一个模板
+func UserTemplateForDirectoryType(directoryType string) (UserTemplate, bool) {
+	for _, t := range UserTemplates() {
+		if t.DirectoryType == directoryType {
+			return t, true
+		}
+	}
+	return UserTemplate{}, false
+}
+
+// IsActiveDirectory 判断模板是否用于Active Directory
+func (t UserTemplate) IsActiveDirectory() bool {
+	return t.DirectoryType == DirectoryTypeAD
+}
+
+// SetUserTemplate 设置创建用户时使用的模板
+func (client *LDAPClient) SetUserTemplate(template UserTemplate) {
+	client.userTemplate = template
+}
+
+// GetUserTemplate 获取当前使用的模板
+func (client *LDAPClient) GetUserTemplate() UserTemplate {
+	return client.userTemplate
+}
+
+// isActiveDirectory 判断目标是否为AD：优先使用自动检测结果，未检测或无法识别时按模板判断
+func (client *LDAPClient) isActiveDirectory() bool {
+	switch client.directoryType {
+	case DirectoryTypeAD:
+		return true
+	case DirectoryTypeOpenLDAP:
+		return false
+	}
+	return client.userTemplate.IsActiveDirectory()
+}
+
+// CreateUserFromTemplate 按模板创建用户（用于非AD目录）
+func (client *LDAPClient) CreateUserFromTemplate(userDN string, userName string, password string) error {
+	template := client.userTemplate
+	client.Debug("使用模板 %s 创建用户：%s", template.Name, userDN)
+
+	// 确保父容器存在
+	parentDN, err := ParentDN(userDN)
+	if err != nil {
+		return fmt.Errorf("用户DN无效: %v", err)
+	}
+	if err := client.EnsureDNExists(parentDN); err != nil {
+		return fmt.Errorf("创建路径失败: %s", ParseLDAPError(err))
+	}
+
+	addRequest := ldap.NewAddRequest(userDN, nil)
+	addRequest.Attribute("objectClass", template.ObjectClasses)
+	addRequest.Attribute(template.NamingAttribute, []string{userName})
+	for _, attr := range template.RequiredAttributes {
+		addRequest.Attribute(attr, []string{userName})
+	}
+	if password != "" {
+		passwordAttr, encodedPassword, err := client.encodePasswordAttribute(password)
+		if err != nil {
+			return fmt.Errorf("创建用户失败: %v", err)
+		}
+		addRequest.Attribute(passwordAttr, []string{encodedPassword})
+	}
+
+	err = client.doWithRetry(func(conn *ldap.Conn) error {
+		return client.add(conn, addRequest)
+	})
+	if err != nil {
+		return fmt.Errorf("创建用户失败: %v", err)
+	}
+
+	client.Info("成功创建用户: %s", userDN)
+	return nil
+}
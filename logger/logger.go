@@ -1,7 +1,11 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -21,18 +25,36 @@ const (
 
 // LogEntry 定义结构化日志条目
 type LogEntry struct {
-	Timestamp string
-	Level     string
-	Message   string
-	Fields    map[string]interface{}
+	Timestamp string                 `json:"timestamp"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"message"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
 }
 
+// maxHistory 内存中保留的日志条目上限，用于导出JSON日志
+const maxHistory = 5000
+
+// repeatFlushDelay 合并重复日志后，最长等待多久输出"（重复 N 次）"提示
+var repeatFlushDelay = 2 * time.Second
+
 // Logger 定义日志记录器结构
 type Logger struct {
-	debugMode       bool
 	statusArea      *widget.TextGrid
 	statusContainer *fyne.Container
 	updateFunc      func(string)
+
+	mu        sync.Mutex
+	debugMode bool       // 是否输出调试日志
+	minLevel  LogLevel   // 低于该级别的日志被丢弃
+	history   []LogEntry // 最近的日志条目
+	jsonOut   io.Writer  // 非nil时每条日志额外以JSON行写入
+
+	dedupe       bool         // 是否合并连续重复的日志
+	lastKey      string       // 上一条显示日志的内容（不含时间戳）
+	repeats      int          // 被合并的重复次数
+	lastRepeat   string       // 最后一条被合并的日志
+	repeatUpdate func(string) // 输出合并提示使用的更新函数
+	repeatTimer  *time.Timer  // 到期时输出尚未显示的重复提示
 }
 
 // New 创建新的日志记录器
@@ -40,37 +62,168 @@ func New(debugMode bool, updateFunc func(string)) *Logger {
 	return &Logger{
 		debugMode:  debugMode,
 		updateFunc: updateFunc,
+		dedupe:     true,
 	}
 }
 
 // log 统一的日志记录方法
 func (b *BaseLogger) log(level LogLevel, format string, args ...interface{}) {
+	// 低于最低级别的日志直接丢弃
+	if b.logger != nil && !b.logger.levelEnabled(level) {
+		return
+	}
+
 	// 创建日志条目
 	entry := LogEntry{
 		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
 		Level:     level.String(),
 		Message:   fmt.Sprintf(format, args...),
-		Fields:    make(map[string]interface{}),
+		Fields:    make(map[string]interface{}, len(b.fields)),
+	}
+	for k, v := range b.fields {
+		entry.Fields[k] = v
 	}
 
 	// 构建日志消息
-	message := fmt.Sprintf("[%s] %s: %s", entry.Timestamp, entry.Level, entry.Message)
+	body := fmt.Sprintf("%s: %s", entry.Level, entry.Message)
 
-	// 如果有额外字段，添加到消息中
+	// 如果有额外字段，按名称排序后添加到消息中
 	if len(entry.Fields) > 0 {
-		message += " | "
-		for k, v := range entry.Fields {
-			message += fmt.Sprintf("%s=%v ", k, v)
+		keys := make([]string, 0, len(entry.Fields))
+		for k := range entry.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		body += " |"
+		for _, k := range keys {
+			body += fmt.Sprintf(" %s=%v", k, entry.Fields[k])
 		}
 	}
+	message := fmt.Sprintf("[%s] %s", entry.Timestamp, body)
 
+	if b.logger == nil {
+		b.updateFunc(message)
+		return
+	}
+
+	b.logger.record(entry)
 	// 更新状态区域
-	b.updateFunc(message)
+	b.logger.display(body, message, b.updateFunc)
+}
+
+// display 输出日志到状态区域；开启合并时，内容完全相同的连续日志只输出一条"（重复 N 次）"提示，
+// 提示在出现不同的日志、调用Flush或等待repeatFlushDelay后输出
+func (l *Logger) display(key string, message string, update func(string)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.dedupe && key == l.lastKey {
+		l.repeats++
+		l.lastRepeat = message
+		l.repeatUpdate = update
+		if l.repeatTimer == nil {
+			l.repeatTimer = time.AfterFunc(repeatFlushDelay, l.Flush)
+		}
+		return
+	}
+
+	l.flushRepeatsLocked()
+	l.lastKey = key
+	update(message)
+}
+
+// flushRepeatsLocked 输出尚未显示的重复提示，调用方需持有l.mu
+func (l *Logger) flushRepeatsLocked() {
+	if l.repeatTimer != nil {
+		l.repeatTimer.Stop()
+		l.repeatTimer = nil
+	}
+	if l.repeats > 0 {
+		l.repeatUpdate(fmt.Sprintf("%s（重复 %d 次）", l.lastRepeat, l.repeats))
+	}
+	l.repeats = 0
+	l.lastRepeat = ""
+	l.repeatUpdate = nil
+}
+
+// Flush 立即输出尚未显示的重复提示，操作结束时调用
+func (l *Logger) Flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushRepeatsLocked()
+}
+
+// Clear 调用clearStatus清空状态区域，同时丢弃尚未输出的重复提示并重置合并状态，
+// 清空后与清空前最后一条相同的日志仍会显示
+func (l *Logger) Clear(clearStatus func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.repeatTimer != nil {
+		l.repeatTimer.Stop()
+		l.repeatTimer = nil
+	}
+	l.repeats = 0
+	l.lastRepeat = ""
+	l.repeatUpdate = nil
+	l.lastKey = ""
+	clearStatus()
+}
+
+// SetDedupe 设置是否合并连续重复的日志，关闭时立即输出尚未显示的重复提示
+func (l *Logger) SetDedupe(dedupe bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dedupe = dedupe
+	if !dedupe {
+		l.flushRepeatsLocked()
+		l.lastKey = ""
+	}
+}
+
+// record 保存日志条目，并在设置了JSON输出时写入一行JSON
+func (l *Logger) record(entry LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.history = append(l.history, entry)
+	if len(l.history) > maxHistory {
+		l.history = l.history[len(l.history)-maxHistory:]
+	}
+
+	if l.jsonOut != nil {
+		if data, err := json.Marshal(entry); err == nil {
+			l.jsonOut.Write(append(data, '\n'))
+		}
+	}
+}
+
+// SetJSONOutput 设置JSON日志输出，之后每条日志都会以一行JSON写入w，传入nil关闭
+func (l *Logger) SetJSONOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.jsonOut = w
+}
+
+// WriteJSON 将内存中保留的日志条目按JSON行格式写入w
+func (l *Logger) WriteJSON(w io.Writer) (int, error) {
+	l.mu.Lock()
+	entries := append([]LogEntry(nil), l.history...)
+	l.mu.Unlock()
+
+	encoder := json.NewEncoder(w)
+	for i, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
 }
 
 // Debug 记录调试级别日志
 func (b *BaseLogger) Debug(format string, args ...interface{}) {
-	if b.logger.debugMode {
+	if b.logger.isDebugMode() {
 		b.log(DEBUG, format, args...)
 	}
 }
@@ -106,41 +259,113 @@ func (l LogLevel) String() string {
 	}
 }
 
-// WithFields 添加结构化字段到日志条目
+// WithFields 返回附带结构化字段的日志记录器，之后记录的每条日志都会包含这些字段
 func (b *BaseLogger) WithFields(fields map[string]interface{}) *BaseLogger {
-	// 创建一个新的BaseLogger，继承原有功能但添加字段
+	// 合并已有字段，同名字段以新值为准
+	merged := make(map[string]interface{}, len(b.fields)+len(fields))
+	for k, v := range b.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
 	return &BaseLogger{
-		logger: b.logger,
-		updateFunc: func(message string) {
-			entry := LogEntry{
-				Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-				Level:     INFO.String(),
-				Message:   message,
-				Fields:    fields,
-			}
-			formattedMessage := fmt.Sprintf("[%s] %s: %s", entry.Timestamp, entry.Level, entry.Message)
-			if len(entry.Fields) > 0 {
-				formattedMessage += " | "
-				for k, v := range entry.Fields {
-					formattedMessage += fmt.Sprintf("%s=%v ", k, v)
-				}
-			}
-			b.updateFunc(formattedMessage)
-		},
+		logger:     b.logger,
+		updateFunc: b.updateFunc,
+		fields:     merged,
 	}
 }
 
 // SetDebugMode 设置调试模式
 func (b *BaseLogger) SetDebugMode(debug bool) {
 	if b.logger != nil {
-		b.logger.debugMode = debug
+		b.logger.SetDebugMode(debug)
+	}
+}
+
+// WriteJSON 将内存中保留的日志条目按JSON行格式写入w，返回写入的条目数
+func (b *BaseLogger) WriteJSON(w io.Writer) (int, error) {
+	if b.logger == nil {
+		return 0, nil
+	}
+	return b.logger.WriteJSON(w)
+}
+
+// SetDedupe 设置是否合并连续重复的日志
+func (b *BaseLogger) SetDedupe(dedupe bool) {
+	if b.logger != nil {
+		b.logger.SetDedupe(dedupe)
+	}
+}
+
+// Flush 立即输出尚未显示的重复提示
+func (b *BaseLogger) Flush() {
+	if b.logger != nil {
+		b.logger.Flush()
+	}
+}
+
+// SetMinLevel 设置最低日志级别（与调试模式相互独立）
+func (b *BaseLogger) SetMinLevel(level LogLevel) {
+	if b.logger != nil {
+		b.logger.SetMinLevel(level)
 	}
 }
 
+// LogLevels 返回所有日志级别名称
+func LogLevels() []string {
+	return []string{DEBUG.String(), INFO.String(), WARN.String(), ERROR.String()}
+}
+
+// ParseLogLevel 将级别名称解析为LogLevel
+func ParseLogLevel(name string) (LogLevel, bool) {
+	for _, level := range []LogLevel{DEBUG, INFO, WARN, ERROR} {
+		if level.String() == name {
+			return level, true
+		}
+	}
+	return DEBUG, false
+}
+
+// ReadOnlyEntry 是可选择、可复制但不可编辑的文本框
+type ReadOnlyEntry struct {
+	widget.Entry
+}
+
+// NewReadOnlyEntry 创建新的只读多行文本框
+func NewReadOnlyEntry() *ReadOnlyEntry {
+	entry := &ReadOnlyEntry{}
+	entry.MultiLine = true
+	entry.ExtendBaseWidget(entry)
+	return entry
+}
+
+// TypedRune 忽略字符输入
+func (e *ReadOnlyEntry) TypedRune(r rune) {}
+
+// TypedKey 只处理光标移动类按键
+func (e *ReadOnlyEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyUp, fyne.KeyDown, fyne.KeyLeft, fyne.KeyRight,
+		fyne.KeyHome, fyne.KeyEnd, fyne.KeyPageUp, fyne.KeyPageDown:
+		e.Entry.TypedKey(key)
+	}
+}
+
+// TypedShortcut 只允许复制和全选
+func (e *ReadOnlyEntry) TypedShortcut(shortcut fyne.Shortcut) {
+	switch shortcut.(type) {
+	case *fyne.ShortcutCopy, *fyne.ShortcutSelectAll:
+		e.Entry.TypedShortcut(shortcut)
+	}
+}
+
+// TappedSecondary 禁用右键菜单，避免通过菜单剪切或粘贴
+func (e *ReadOnlyEntry) TappedSecondary(*fyne.PointEvent) {}
+
 // CreateStatusArea 创建状态显示区域
-func CreateStatusArea() (*widget.Entry, *container.Scroll) {
-	statusArea := widget.NewMultiLineEntry()
-	statusArea.Disable()                    // 设置为只读模式
+func CreateStatusArea() (*ReadOnlyEntry, *container.Scroll) {
+	statusArea := NewReadOnlyEntry()        // 只读但可选择复制
 	statusArea.Wrapping = fyne.TextWrapWord // 启用自动换行
 
 	// 设置初始文本样式
@@ -156,9 +381,19 @@ func CreateStatusArea() (*widget.Entry, *container.Scroll) {
 	return statusArea, statusContainer
 }
 
-// CreateUpdateStatusFunc 创建状态更新函数
-func CreateUpdateStatusFunc(statusArea *widget.Entry, statusContainer *container.Scroll) func(string) {
-	return func(status string) {
+// CreateUpdateStatusFunc 创建状态更新函数及清空状态区域的函数，两者使用同一把锁
+func CreateUpdateStatusFunc(statusArea *ReadOnlyEntry, statusContainer *container.Scroll) (update func(string), clearStatus func()) {
+	// 后台goroutine也会写日志，使用互斥锁保证更新顺序
+	var mu sync.Mutex
+	clearStatus = func() {
+		mu.Lock()
+		defer mu.Unlock()
+		statusArea.SetText("")
+	}
+	update = func(status string) {
+		mu.Lock()
+		defer mu.Unlock()
+
 		// 将新状态添加到文本开头而不是末尾
 		if statusArea.Text == "" {
 			statusArea.SetText(status + "\n")
@@ -181,13 +416,37 @@ func CreateUpdateStatusFunc(statusArea *widget.Entry, statusContainer *container
 			statusContainer.ScrollToTop() // 滚动到顶部
 		}()
 	}
+	return update, clearStatus
 }
 
 // SetDebugMode 设置调试模式
 func (l *Logger) SetDebugMode(debug bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.debugMode = debug
 }
 
+// isDebugMode 返回是否处于调试模式
+func (l *Logger) isDebugMode() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.debugMode
+}
+
+// SetMinLevel 设置最低日志级别
+func (l *Logger) SetMinLevel(level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.minLevel = level
+}
+
+// levelEnabled 返回该级别的日志是否不低于最低级别
+func (l *Logger) levelEnabled(level LogLevel) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return level >= l.minLevel
+}
+
 // Loggable 定义可记录日志的接口
 type Loggable interface {
 	Debug(format string, args ...interface{})
@@ -200,6 +459,7 @@ type Loggable interface {
 type BaseLogger struct {
 	logger     *Logger
 	updateFunc func(string)
+	fields     map[string]interface{} // 附加到每条日志的结构化字段
 }
 
 // NewBaseLogger 创建新的基础日志记录器
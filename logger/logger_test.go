@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// capture 返回记录状态区域输出的更新函数及读取函数
+func capture() (func(string), func() []string) {
+	var mu sync.Mutex
+	var lines []string
+	return func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, line)
+		}, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string(nil), lines...)
+		}
+}
+
+func TestDedupeIdenticalMessages(t *testing.T) {
+	update, lines := capture()
+	log := New(true, nil).NewBaseLogger(update)
+
+	log.Info("连接失败")
+	log.Info("连接失败")
+	log.Info("连接失败")
+	log.Info("连接成功")
+
+	got := lines()
+	if len(got) != 3 {
+		t.Fatalf("输出 %d 行, want 3: %q", len(got), got)
+	}
+	if !strings.HasSuffix(got[1], "连接失败（重复 2 次）") {
+		t.Errorf("重复提示 = %q", got[1])
+	}
+}
+
+// TestDedupeComparesDigits 只有数字不同的日志不是重复日志
+func TestDedupeComparesDigits(t *testing.T) {
+	update, lines := capture()
+	log := New(true, nil).NewBaseLogger(update)
+
+	log.Warn("连接失败 (尝试 1/3)")
+	log.Warn("连接失败 (尝试 2/3)")
+	log.Warn("连接失败 (尝试 3/3)")
+
+	if got := lines(); len(got) != 3 {
+		t.Fatalf("输出 %d 行, want 3: %q", len(got), got)
+	}
+}
+
+func TestFlushRepeats(t *testing.T) {
+	update, lines := capture()
+	log := New(true, nil).NewBaseLogger(update)
+
+	log.Info("等待响应")
+	log.Info("等待响应")
+	log.Flush()
+
+	got := lines()
+	if len(got) != 2 || !strings.HasSuffix(got[1], "等待响应（重复 1 次）") {
+		t.Fatalf("Flush后输出 %q", got)
+	}
+	log.Flush()
+	if len(lines()) != 2 {
+		t.Error("重复调用Flush不应再次输出")
+	}
+}
+
+// TestRepeatsFlushedByTimer 没有新日志时，重复提示在repeatFlushDelay后自动输出
+func TestRepeatsFlushedByTimer(t *testing.T) {
+	saved := repeatFlushDelay
+	repeatFlushDelay = 10 * time.Millisecond
+	defer func() { repeatFlushDelay = saved }()
+
+	update, lines := capture()
+	log := New(true, nil).NewBaseLogger(update)
+	log.Info("等待响应")
+	log.Info("等待响应")
+
+	deadline := time.Now().Add(time.Second)
+	for len(lines()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("重复提示未自动输出")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestWithFields 附加字段同时出现在状态区域的日志行和JSON日志的fields中
+func TestWithFields(t *testing.T) {
+	update, lines := capture()
+	log := New(true, nil)
+	var jsonOut bytes.Buffer
+	log.SetJSONOutput(&jsonOut)
+
+	base := log.NewBaseLogger(update).WithFields(map[string]interface{}{"operation": "search"})
+	base.WithFields(map[string]interface{}{"host": "dc01", "count": 3}).Info("搜索完成")
+
+	got := lines()
+	if len(got) != 1 || !strings.HasSuffix(got[0], "INFO: 搜索完成 | count=3 host=dc01 operation=search") {
+		t.Fatalf("日志行 = %q", got)
+	}
+
+	var entry LogEntry
+	if err := json.Unmarshal(jsonOut.Bytes(), &entry); err != nil {
+		t.Fatalf("解析JSON日志失败: %v: %s", err, jsonOut.String())
+	}
+	if entry.Message != "搜索完成" || entry.Level != "INFO" {
+		t.Errorf("JSON日志 = %+v", entry)
+	}
+	want := map[string]interface{}{"operation": "search", "host": "dc01", "count": float64(3)}
+	if len(entry.Fields) != len(want) {
+		t.Errorf("fields = %v, want %v", entry.Fields, want)
+	}
+	for k, v := range want {
+		if entry.Fields[k] != v {
+			t.Errorf("fields[%s] = %v, want %v", k, entry.Fields[k], v)
+		}
+	}
+
+	// 原日志记录器不受WithFields影响
+	log.NewBaseLogger(update).Info("无字段")
+	if got := lines(); !strings.HasSuffix(got[len(got)-1], "INFO: 无字段") {
+		t.Errorf("无字段的日志行 = %q", got[len(got)-1])
+	}
+}
+
+// TestLevelSettersConcurrentWithLogging 记录日志时可以同时切换调试模式和最低级别（使用-race运行）
+func TestLevelSettersConcurrentWithLogging(t *testing.T) {
+	update, _ := capture()
+	log := New(false, nil).NewBaseLogger(update)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			log.SetDebugMode(i%2 == 0)
+			log.SetMinLevel(LogLevel(i % 4))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			log.Debug("调试 %d", i)
+			log.Info("信息 %d", i)
+		}
+	}()
+	wg.Wait()
+}
+
+// TestClearResetsDedupe 清空后与清空前最后一条相同的日志仍会显示，未输出的重复提示被丢弃
+func TestClearResetsDedupe(t *testing.T) {
+	update, lines := capture()
+	log := New(true, nil)
+	base := log.NewBaseLogger(update)
+
+	base.Info("连接失败")
+	base.Info("连接失败")
+	cleared := false
+	log.Clear(func() { cleared = true })
+	base.Info("连接失败")
+	base.Flush()
+
+	if !cleared {
+		t.Error("Clear未清空状态区域")
+	}
+	got := lines()
+	if len(got) != 2 || !strings.HasSuffix(got[1], "INFO: 连接失败") {
+		t.Fatalf("输出 = %q, 清空后应再显示一次原日志且不输出重复提示", got)
+	}
+}
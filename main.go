@@ -3,14 +3,17 @@ package main
 import (
 	"flag"
 	"os"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"LdapTest/config"
 	"LdapTest/ldap"
 	"LdapTest/logger"
 	"LdapTest/ui"
@@ -25,19 +28,23 @@ func main() {
 	flag.BoolVar(&debugMode, "debug", false, "启用调试模式")
 	flag.Parse()
 
-	// 设置中文字体路径（仅Windows系统）
-	os.Setenv("FYNE_FONT", "C:\\Windows\\Fonts\\SIMYOU.TTF")
+	// 加载配置文件（字体需要在创建应用前设置）
+	appConfig, configErr := config.Load()
+
+	// 设置中文字体路径，找不到时使用Fyne默认字体
+	fontPath := ui.ConfigureCJKFont(appConfig.FontPath)
 
 	// 创建应用程序实例
 	myApp := app.New()
 	// 应用自定义主题
-	myApp.Settings().SetTheme(ui.NewMyTheme())
+	appTheme := ui.NewMyTheme()
+	myApp.Settings().SetTheme(appTheme)
 	// 创建主窗口
 	myWindow := myApp.NewWindow("LDAP Client")
 
 	// 创建状态区域
 	statusArea, statusContainer := logger.CreateStatusArea()
-	updateStatus := logger.CreateUpdateStatusFunc(statusArea, statusContainer)
+	updateStatus, clearStatus := logger.CreateUpdateStatusFunc(statusArea, statusContainer)
 
 	// 初始化日志记录器
 	baseLogger := logger.New(debugMode, updateStatus)
@@ -50,17 +57,38 @@ func main() {
 	}
 	appLogger.Info("应用程序启动，调试模式：" + debugModeStr)
 
+	if configErr != nil {
+		appLogger.Warn("加载配置文件失败：%v", configErr)
+	}
+	if appConfig.JSONLogFile != "" {
+		jsonLogFile, err := os.OpenFile(appConfig.JSONLogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			appLogger.Warn("打开JSON日志文件失败：%v", err)
+		} else {
+			defer jsonLogFile.Close()
+			baseLogger.SetJSONOutput(jsonLogFile)
+			appLogger.Debug("JSON日志写入：%s", appConfig.JSONLogFile)
+		}
+	}
+	if fontPath != "" {
+		appLogger.Debug("使用字体：%s", fontPath)
+	} else {
+		appLogger.Debug("未找到中文字体，使用默认字体")
+	}
+	for _, f := range appConfig.CustomFilters {
+		ldap.AddCustomFilter(ldap.LDAPFilter{Name: f.Name, Pattern: f.Pattern})
+	}
+	if appConfig.TextSize == "" {
+		appConfig.TextSize = ui.TextSizeMedium
+	}
+	if appTheme.SetTextSize(appConfig.TextSize) {
+		myApp.Settings().SetTheme(appTheme)
+	}
+
 	// 创建过滤器选择框
 	appLogger.Debug("初始化过滤器选择框")
-	filterList := func() []string {
-		var names []string
-		names = append(names, "(Select one)")
-		for _, f := range ldap.CommonFilters() {
-			names = append(names, f.Name)
-		}
-		appLogger.Debug("加载过滤器列表：%s", strings.Join(names, ", "))
-		return names
-	}()
+	filterList := ui.FilterOptions()
+	appLogger.Debug("加载过滤器列表：%s", strings.Join(filterList, ", "))
 
 	// 创建过滤器描述标签
 	appLogger.Debug("创建过滤器描述标签")
@@ -78,21 +106,21 @@ func main() {
 		}
 
 		filterDescLabel.Show()
-		for _, f := range ldap.CommonFilters() {
-			if f.Name == filterName {
-				appLogger.Debug("设置过滤器描述：%s", f.Pattern)
-				filterDescLabel.Enable() // 临时启用以设置文本
-				filterDescLabel.SetText(f.Pattern)
-				filterDescLabel.Disable() // 重新禁用以保持只读状态
-				break
-			}
+		if f, ok := ldap.FilterByName(filterName); ok {
+			appLogger.Debug("设置过滤器描述：%s", f.Pattern)
+			filterDescLabel.Enable() // 临时启用以设置文本
+			filterDescLabel.SetText(f.Pattern)
+			filterDescLabel.Disable() // 重新禁用以保持只读状态
 		}
 	}
 
 	// 创建LDAP操作处理器
-	ldapOps := ui.NewLDAPOperations(myWindow, appLogger, updateStatus, debugMode, ui.NewCustomFilterSelect(filterList, func(selected string) {
+	filterSelect := ui.NewCustomFilterSelect(filterList, func(selected string) {
+		appLogger.Debug("选择过滤器：%s", selected)
 		updateFilterDescription(selected)
-	}))
+	})
+	ldapOps := ui.NewLDAPOperations(myWindow, appLogger, updateStatus, debugMode, filterSelect)
+	ldapOps.SetConfig(appConfig)
 
 	// 创建输入框
 	var domainEntry *ui.CustomDomainEntry
@@ -107,7 +135,7 @@ func main() {
 
 	// 初始化输入框
 	adminEntry = widget.NewEntry()
-	adminEntry.SetPlaceHolder("请输入管理员DN")
+	adminEntry.SetPlaceHolder("管理员DN、UPN（user@domain）或 DOMAIN\\user")
 
 	passwordEntry = widget.NewPasswordEntry()
 	passwordEntry.SetPlaceHolder("请输入管理员密码")
@@ -116,7 +144,7 @@ func main() {
 	ldapPasswordEntry.SetPlaceHolder("请输入LDAP密码")
 
 	ldapDNEntry = widget.NewEntry()
-	ldapDNEntry.SetPlaceHolder("请输入LDAP DN")
+	ldapDNEntry.SetPlaceHolder("LDAP DN（验证用户时也可用UPN或 DOMAIN\\user）")
 
 	// 创建LDAP权限组输入框（既可以输入又可以选择）
 	ldapGroupEntry = widget.NewSelectEntry([]string{""})
@@ -125,81 +153,360 @@ func main() {
 	searchDNEntry = widget.NewEntry()
 	searchDNEntry.SetPlaceHolder("CN=Users,DC=example,DC=com")
 
+	ldapOps.SetSearchDNEntry(searchDNEntry)
+
 	testUserEntry = widget.NewEntry()
 	testUserEntry.SetPlaceHolder("请输入测试用户名")
 
 	testPasswordEntry = widget.NewPasswordEntry()
 	testPasswordEntry.SetPlaceHolder("请输入测试密码")
 
-	domainEntry = ui.NewCustomDomainEntry(func() {
+	// 按DN模板自动填充管理员DN、搜索DN、LDAP用户DN和权限组DN
+	fillDefaultDNs := func() {
 		if domainEntry.Text == "" {
 			appLogger.Debug("域名为空，跳过自动填充")
 			return
 		}
 		appLogger.Debug("处理域名：%s", domainEntry.Text)
-		domainParts := strings.Split(domainEntry.Text, ".")
-		var dnParts []string
-		for _, part := range domainParts {
-			dnParts = append(dnParts, "DC="+part)
-		}
-		domainDN := strings.Join(dnParts, ",")
+		domainDN := ui.DomainToDN(domainEntry.Text)
 		appLogger.Debug("生成域DN：%s", domainDN)
+		templates := ldapOps.DNTemplates()
 
-		// 自动生成管理员DN和搜索DN
-		adminDN := "CN=Administrator,CN=Users," + domainDN
+		adminDN := ui.ExpandDNTemplate(templates.AdminDN, domainDN)
 		appLogger.Debug("设置管理员DN：%s", adminDN)
 		adminEntry.SetText(adminDN)
 
-		appLogger.Debug("设置搜索DN：%s", domainDN)
-		searchDNEntry.SetText(domainDN)
+		searchDN := ui.ExpandDNTemplate(templates.SearchDN, domainDN)
+		appLogger.Debug("设置搜索DN：%s", searchDN)
+		searchDNEntry.SetText(searchDN)
 
-		ldapUserDN := "CN=Ldap,CN=Ldap," + domainDN
+		ldapUserDN := ui.ExpandDNTemplate(templates.LdapUserDN, domainDN)
 		appLogger.Debug("设置LDAP用户DN：%s", ldapUserDN)
 		ldapDNEntry.SetText(ldapUserDN)
 
-		ldapGroupDN := "CN=LdapGroup,CN=Users," + domainDN
+		ldapGroupDN := ui.ExpandDNTemplate(templates.LdapGroupDN, domainDN)
 		appLogger.Debug("设置LDAP组DN：%s", ldapGroupDN)
 		ldapGroupEntry.SetText(ldapGroupDN)
-	})
+	}
+	domainEntry = ui.NewCustomDomainEntry(fillDefaultDNs)
 	domainEntry.SetPlaceHolder("example.com")
 
 	portEntry := ui.NewCustomPortEntry()
 
+	// 注册输入框，用于统一校验和标记错误
+	ldapOps.SetUIEntries(&ui.UIEntries{
+		DomainEntry:       domainEntry,
+		AdminEntry:        adminEntry,
+		PasswordEntry:     passwordEntry,
+		LdapPasswordEntry: ldapPasswordEntry,
+		LdapDNEntry:       ldapDNEntry,
+		LdapGroupEntry:    ldapGroupEntry,
+		SearchDNEntry:     searchDNEntry,
+		TestUserEntry:     testUserEntry,
+		TestPasswordEntry: testPasswordEntry,
+		PortEntry:         portEntry,
+	})
+
 	// SSL支持标志
 	isSSLEnabled := false
 
+	// 目录类型选择框（决定创建用户时使用的模板）
+	var templateNames []string
+	for _, t := range ldap.UserTemplates() {
+		templateNames = append(templateNames, t.Name)
+	}
+	// 要求下次登录修改密码复选框（仅AD）
+	forcePasswordChangeCheck := widget.NewCheck("要求下次登录修改密码", func(checked bool) {
+		ldapOps.SetForcePasswordChange(checked)
+	})
+
+	// 账户有效期按钮（仅AD）
+	var accountExpiryButton *widget.Button
+	accountExpiryButton = widget.NewButton("有效期：永不过期", func() {
+		ldapOps.ShowAccountExpiryDialog(accountExpiryButton.SetText)
+	})
+
+	directoryTypeSelect := widget.NewSelect(templateNames, func(selected string) {
+		appLogger.Debug("选择目录类型：%s", selected)
+		ldapOps.SetUserTemplate(selected)
+		if template, ok := ldap.UserTemplateByName(selected); ok && template.IsActiveDirectory() {
+			forcePasswordChangeCheck.Show()
+			accountExpiryButton.Show()
+		} else {
+			forcePasswordChangeCheck.Hide()
+			accountExpiryButton.Hide()
+		}
+	})
+	directoryTypeSelect.SetSelected(ldap.DefaultUserTemplate().Name)
+
+	// 创建或重新授权组时使用的组范围和类型
+	groupScopeSelect := widget.NewSelect(ldap.GroupScopes(), func(selected string) {
+		ldapOps.SetGroupScope(selected)
+	})
+	groupScopeSelect.SetSelected(ldap.GroupScopeName(ldap.DefaultGroupScope))
+	securityGroupCheck := widget.NewCheck("安全组", func(checked bool) {
+		ldapOps.SetSecurityGroup(checked)
+	})
+	securityGroupCheck.SetChecked(true)
+
+	// 搜索范围选择框
+	searchScopeSelect := widget.NewSelect(ldap.SearchScopes(), func(selected string) {
+		ldapOps.SetSearchScope(selected)
+	})
+	searchScopeSelect.SetSelected(ldap.SearchScopeName(ldap.DefaultSearchScope))
+
+	// 高级设置：超时、重试和跟随引用，点击应用后对新建的连接生效并保存到配置文件
+	advanced := ldapOps.AdvancedSettings()
+	connectTimeoutEntry := widget.NewEntry()
+	connectTimeoutEntry.SetText(strconv.Itoa(advanced.TimeoutSeconds))
+	maxRetriesEntry := widget.NewEntry()
+	maxRetriesEntry.SetText(strconv.Itoa(advanced.MaxRetries))
+	retryDelayEntry := widget.NewEntry()
+	retryDelayEntry.SetText(strconv.Itoa(advanced.RetryDelayMs))
+	followReferralsCheck := widget.NewCheck("跟随引用", nil)
+	followReferralsCheck.SetChecked(advanced.FollowReferrals)
+	applyAdvancedButton := widget.NewButton("应用", func() {
+		if err := ldapOps.SetAdvancedSettings(connectTimeoutEntry.Text, maxRetriesEntry.Text, retryDelayEntry.Text, followReferralsCheck.Checked); err != nil {
+			appLogger.Error("应用高级设置失败：%v", err)
+		}
+	})
+
+	// 搜索限制输入框（留空表示不限制）
+	sizeLimitEntry := widget.NewEntry()
+	sizeLimitEntry.SetPlaceHolder("不限制")
+	timeLimitEntry := widget.NewEntry()
+	timeLimitEntry.SetPlaceHolder("不限制")
+	onLimitChanged := func(string) {
+		if err := ldapOps.SetSearchLimits(sizeLimitEntry.Text, timeLimitEntry.Text); err != nil {
+			appLogger.Debug("%v", err)
+		}
+	}
+	sizeLimitEntry.OnChanged = onLimitChanged
+	timeLimitEntry.OnChanged = onLimitChanged
+
+	// 连接服务器的按钮在执行前检查输入是否仍为示例值
+	confirmPlaceholders := func(run func()) func() {
+		return func() {
+			ldapOps.ConfirmPlaceholders(run)
+		}
+	}
+
 	// 创建按钮
-	pingButton := widget.NewButton("连接测试", func() {
-		ldapOps.HandlePing(domainEntry.Text)
+	pingButton := widget.NewButton("连接测试", confirmPlaceholders(func() {
+		ldapOps.HandlePing(domainEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 发现多个域控制器时通过下拉框选择
+	discoveredSelect := widget.NewSelect(nil, func(selected string) {
+		if selected != "" {
+			domainEntry.SetText(selected)
+		}
+	})
+	discoveredSelect.PlaceHolder = "选择域控制器"
+	discoveredSelect.Hide()
+
+	discoverButton := widget.NewButton("自动发现", func() {
+		ldapOps.HandleDiscoverServers(domainEntry.Text, func(servers []string) {
+			domainEntry.SetText(servers[0])
+			if len(servers) > 1 {
+				discoveredSelect.Options = servers
+				discoveredSelect.SetSelected(servers[0])
+				discoveredSelect.Show()
+			} else {
+				discoveredSelect.Hide()
+			}
+		})
+	})
+
+	// DN模板按钮：编辑自动生成的默认DN，保存后按新模板重新填充
+	dnTemplatesButton := widget.NewButton("DN模板", func() {
+		ldapOps.ShowDNTemplatesDialog(fillDefaultDNs)
 	})
 
-	portTestButton := widget.NewButton("测试服务", func() {
+	viewCertButton := widget.NewButton("查看证书", confirmPlaceholders(func() {
+		ldapOps.HandleViewCertificate(domainEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	portTestButton := widget.NewButton("测试服务", confirmPlaceholders(func() {
 		ldapOps.HandlePortTest(domainEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	probePortsButton := widget.NewButton("探测端口", confirmPlaceholders(func() {
+		ldapOps.HandleProbePorts(domainEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 绑定方式选择框及客户端证书按钮
+	bindMethodSelect := widget.NewSelect(ldap.BindMethods(), func(selected string) {
+		ldapOps.SetBindMethod(selected)
 	})
+	bindMethodSelect.SetSelected(ldap.BindMethodSimple)
+	clientCertButton := widget.NewButton("客户端证书", func() {
+		ldapOps.ShowClientCertificateDialog()
+	})
+
+	// 匿名访问复选框：勾选后测试管理员改为测试匿名绑定和搜索
+	anonymousCheck := widget.NewCheck("匿名访问", nil)
 
-	adminTestButton := widget.NewButton("测试管理员", func() {
+	adminTestButton := widget.NewButton("测试管理员", confirmPlaceholders(func() {
+		if anonymousCheck.Checked {
+			ldapOps.HandleAnonymousTest(domainEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+			return
+		}
 		ldapOps.HandleAdminTest(domainEntry.Text, adminEntry.Text, passwordEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 服务器信息按钮
+	serverInfoButton := widget.NewButton("服务器信息", confirmPlaceholders(func() {
+		ldapOps.HandleServerInfo(domainEntry.Text, adminEntry.Text, passwordEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 当前身份按钮
+	whoAmIButton := widget.NewButton("当前身份", confirmPlaceholders(func() {
+		ldapOps.HandleWhoAmI(domainEntry.Text, adminEntry.Text, passwordEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 比较属性按钮
+	compareAttributeButton := widget.NewButton("比较属性", confirmPlaceholders(func() {
+		ldapOps.HandleCompareAttribute(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 修改属性按钮
+	modifyAttributeButton := widget.NewButton("修改属性", confirmPlaceholders(func() {
+		ldapOps.HandleModifyAttribute(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 重命名按钮
+	renameButton := widget.NewButton("重命名", confirmPlaceholders(func() {
+		ldapOps.HandleRenameEntry(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 搜索条目及导出按钮
+	searchEntriesButton := widget.NewButton("搜索条目", confirmPlaceholders(func() {
+		ldapOps.HandleSearchEntries(domainEntry.Text, adminEntry.Text, passwordEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+	exportCSVButton := widget.NewButton("导出CSV", func() {
+		ldapOps.ShowExportCSVDialog()
+	})
+	findByObjectIDButton := widget.NewButton("按GUID/SID查找", confirmPlaceholders(func() {
+		ldapOps.HandleFindByObjectID(domainEntry.Text, adminEntry.Text, passwordEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+	copyLdapsearchButton := widget.NewButton("复制为ldapsearch命令", confirmPlaceholders(func() {
+		ldapOps.HandleCopyLdapsearch(domainEntry.Text, adminEntry.Text, passwordEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+	exportLDIFButton := widget.NewButton("导出LDIF", func() {
+		ldapOps.ShowExportLDIFDialog()
 	})
+	importLDIFButton := widget.NewButton("导入LDIF", confirmPlaceholders(func() {
+		ldapOps.HandleImportLDIF(domainEntry.Text, adminEntry.Text, passwordEntry.Text, portEntry, isSSLEnabled)
+	}))
 
+	// 使用LDAP账号测试能否读取搜索DN
+	readAccessButton := widget.NewButton("测试读取权限", confirmPlaceholders(func() {
+		ldapOps.HandleCheckReadAccess(domainEntry.Text, ldapDNEntry.Text, ldapPasswordEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
 	// 创建LDAP用户按钮
-	createLdapButton := widget.NewButton("创建LDAP用户", func() {
+	createLdapButton := widget.NewButton("创建LDAP用户", confirmPlaceholders(func() {
 		ldapOps.HandleCreateLdap(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, ldapPasswordEntry.Text, ldapGroupEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 生成随机密码按钮
+	generatePasswordButton := widget.NewButton("生成", func() {
+		ldapOps.HandleGeneratePassword(ldapPasswordEntry, ldapDNEntry.Text)
 	})
 
+	// 启用/停用账户按钮
+	toggleAccountButton := widget.NewButton("启用/停用账户", confirmPlaceholders(func() {
+		ldapOps.HandleToggleAccount(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 账户状态及解锁按钮：查询到账户被锁定后才能解锁
+	var unlockAccountButton *widget.Button
+	unlockAccountButton = widget.NewButton("解锁账户", confirmPlaceholders(func() {
+		ldapOps.HandleUnlockAccount(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, portEntry, isSSLEnabled, func() {
+			unlockAccountButton.Disable()
+		})
+	}))
+	unlockAccountButton.Disable()
+	// 更换账户后需要重新查询状态
+	ldapDNEntry.OnChanged = func(string) {
+		unlockAccountButton.Disable()
+	}
+	viewEntryButton := widget.NewButton("查看条目", confirmPlaceholders(func() {
+		ldapOps.HandleViewEntry(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+	// 对比条目按钮：逐属性对比两个条目，默认条目A为Ldap DN
+	compareEntriesButton := widget.NewButton("对比条目", confirmPlaceholders(func() {
+		ldapOps.HandleCompareEntries(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+	// 查看权限按钮：列出条目nTSecurityDescriptor中的DACL（仅AD）
+	viewPermissionsButton := widget.NewButton("查看权限", confirmPlaceholders(func() {
+		ldapOps.HandleViewPermissions(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+	accountStatusButton := widget.NewButton("账户状态", confirmPlaceholders(func() {
+		ldapOps.HandleAccountStatus(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, portEntry, isSSLEnabled, func(locked bool) {
+			if locked && !ldapOps.ReadOnly() {
+				unlockAccountButton.Enable()
+			} else {
+				unlockAccountButton.Disable()
+			}
+		})
+	}))
+
+	// 批量创建用户按钮
+	bulkCreateButton := widget.NewButton("批量创建用户", confirmPlaceholders(func() {
+		ldapOps.HandleBulkCreateUsers(domainEntry.Text, adminEntry.Text, passwordEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 验证SSO配置按钮
+	verifySSOButton := widget.NewButton("验证SSO配置", confirmPlaceholders(func() {
+		ldapOps.HandleVerifySSO(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapGroupEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
 	// 检查权限组按钮
-	groupButton := widget.NewButton("检查权限组", func() {
+	groupButton := widget.NewButton("检查权限组", confirmPlaceholders(func() {
 		ldapOps.HandleGroupCheck(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapGroupEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
-	})
+	}))
+
+	// 查看组成员按钮及嵌套展开选项
+	expandNestedCheck := widget.NewCheck("展开嵌套组", nil)
+	listMembersButton := widget.NewButton("查看组成员", confirmPlaceholders(func() {
+		ldapOps.HandleListGroupMembers(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapGroupEntry.Text, expandNestedCheck.Checked, portEntry, isSSLEnabled)
+	}))
+
+	// 检查Ldap DN是否属于权限组（包括嵌套组）
+	memberOfButton := widget.NewButton("是否属于组", confirmPlaceholders(func() {
+		ldapOps.HandleCheckMembership(domainEntry.Text, adminEntry.Text, passwordEntry.Text, ldapDNEntry.Text, ldapGroupEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 批量验证用户按钮（CSV：username,password）
+	bulkTestUsersButton := widget.NewButton("批量验证用户", confirmPlaceholders(func() {
+		ldapOps.HandleBulkTestUsers(domainEntry.Text, adminEntry.Text, passwordEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 性能测试按钮：使用测试账号反复绑定和搜索
+	benchmarkButton := widget.NewButton("性能测试", confirmPlaceholders(func() {
+		ldapOps.HandleBenchmark(domainEntry.Text, adminEntry.Text, passwordEntry.Text, testUserEntry.Text, testPasswordEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
 
 	// 管理员验证用户按钮
-	adminTestUserButton := widget.NewButton("admin账号验证用户", func() {
+	adminTestUserButton := widget.NewButton("admin账号验证用户", confirmPlaceholders(func() {
 		ldapOps.HandleAdminTestUser(domainEntry.Text, adminEntry.Text, passwordEntry.Text, testUserEntry.Text, testPasswordEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 测试所有过滤器按钮
+	testAllFiltersButton := widget.NewButton("测试所有过滤器", confirmPlaceholders(func() {
+		ldapOps.HandleTestAllFilters(domainEntry.Text, adminEntry.Text, passwordEntry.Text, testUserEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
+	}))
+
+	// 添加自定义过滤器按钮
+	addFilterButton := widget.NewButton("添加过滤器", func() {
+		ldapOps.ShowAddFilterDialog()
 	})
 
 	// LDAP账号验证用户按钮
-	ldapTestUserButton := widget.NewButton("LDAP账号验证用户", func() {
+	ldapTestUserButton := widget.NewButton("LDAP账号验证用户", confirmPlaceholders(func() {
 		ldapOps.HandleLdapTestUser(domainEntry.Text, ldapDNEntry.Text, ldapPasswordEntry.Text, testUserEntry.Text, testPasswordEntry.Text, searchDNEntry.Text, portEntry, isSSLEnabled)
-	})
+	}))
 
 	// SSL支持复选框
 	widget.NewCheck("SSL支持", func(checked bool) {
@@ -217,10 +524,6 @@ func main() {
 	})
 
 	// 过滤器选择框
-	filterSelect := widget.NewSelect(filterList, func(selected string) {
-		appLogger.Debug("选择过滤器：%s", selected)
-		updateFilterDescription(selected) // 使用现有的更新函数
-	})
 	filterSelect.SetSelected(filterList[0]) // 设置默认选择第一个过滤器
 	appLogger.Debug("初始化过滤器选择框，默认选择：%s", filterList[0])
 	updateFilterDescription(filterList[0]) // 初始化描述
@@ -241,59 +544,240 @@ func main() {
 		)
 	}
 
+	// SSL支持复选框（与连接URL同步）
+	var syncConnectionURL func()
+	sslCheck := widget.NewCheck("SSL支持", func(checked bool) {
+		isSSLEnabled = checked // 更新SSL状态
+		if checked {
+			portEntry.SetDefaultPort(true)                         // SSL端口
+			ldapPasswordEntry.SetPlaceHolder("SSL模式下创建的用户是可以直接用的") // 更新占位符提示
+		} else {
+			portEntry.SetDefaultPort(false)                          // 标准端口
+			ldapPasswordEntry.SetPlaceHolder("非SSL模式创建的用户是没有密码停用的）") // 更新占位符提示
+		}
+		syncConnectionURL()
+	})
+
+	// 全局编录复选框：切换到3268/3269端口并从林根搜索
+	globalCatalogCheck := widget.NewCheck("全局编录", func(checked bool) {
+		ldapOps.SetGlobalCatalog(checked)
+		portEntry.SetGlobalCatalog(checked, isSSLEnabled)
+		syncConnectionURL()
+	})
+
+	// 连接URL输入框：填写后解析并覆盖服务器地址、端口和SSL，修改这些字段时URL随之更新
+	connectionURLEntry := widget.NewEntry()
+	connectionURLEntry.SetPlaceHolder("ldaps://dc1.corp.local:636（可选，填写后自动设置地址、端口和SSL）")
+	connectionURLEntry.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		_, _, _, err := ldap.ParseLDAPURL(text)
+		return err
+	}
+	syncingURL := false
+	connectionURLEntry.OnChanged = func(text string) {
+		if syncingURL || strings.TrimSpace(text) == "" {
+			return
+		}
+		host, port, ssl, err := ldap.ParseLDAPURL(text)
+		if err != nil {
+			return // 输入过程中URL可能不完整，由Validator提示
+		}
+		syncingURL = true
+		defer func() { syncingURL = false }()
+		domainEntry.SetText(host)
+		sslCheck.SetChecked(ssl)
+		portEntry.SetText(strconv.Itoa(port))
+		appLogger.Debug("按连接URL设置：%s:%d，SSL：%v", host, port, ssl)
+	}
+	syncConnectionURL = func() {
+		if syncingURL || strings.TrimSpace(connectionURLEntry.Text) == "" || domainEntry.Text == "" {
+			return
+		}
+		port, err := strconv.Atoi(portEntry.Text)
+		if err != nil || port < 1 || port > 65535 {
+			return
+		}
+		syncingURL = true
+		defer func() { syncingURL = false }()
+		connectionURLEntry.SetText(ldap.FormatLDAPURL(domainEntry.Text, port, isSSLEnabled))
+	}
+	domainEntry.OnChanged = func(string) { syncConnectionURL() }
+	portEntry.OnChanged = func(string) { syncConnectionURL() }
+
 	// 使用 Border 布局来实现自动拉伸
 	formContainer := container.NewVBox(
-		container.NewBorder(nil, nil, makeLabel("服务器地址:"), pingButton,
+		container.NewBorder(nil, nil, makeLabel("连接URL:"), nil,
+			connectionURLEntry,
+		),
+		container.NewBorder(nil, nil, makeLabel("服务器地址:"), container.NewHBox(discoveredSelect, discoverButton, pingButton, dnTemplatesButton),
 			domainEntry,
 		),
 		container.NewBorder(nil, nil, makeLabel("服务器端口:"), container.NewHBox(
-			widget.NewCheck("SSL支持", func(checked bool) {
-				isSSLEnabled = checked // 更新SSL状态
-				if checked {
-					portEntry.SetDefaultPort(true)                         // SSL端口
-					ldapPasswordEntry.SetPlaceHolder("SSL模式下创建的用户是可以直接用的") // 更新占位符提示
-				} else {
-					portEntry.SetDefaultPort(false)                          // 标准端口
-					ldapPasswordEntry.SetPlaceHolder("非SSL模式创建的用户是没有密码停用的）") // 更新占位符提示
-				}
-			}),
+			sslCheck,
+			globalCatalogCheck,
+			viewCertButton,
 			portTestButton,
+			probePortsButton,
 		),
 			portEntry,
 		),
-		container.NewBorder(nil, nil, makeLabel("Admin DN:"), nil,
+		container.NewBorder(nil, nil, makeLabel("目录类型:"), nil,
+			directoryTypeSelect,
+		),
+		container.NewBorder(nil, nil, makeLabel("Admin DN:"), container.NewHBox(serverInfoButton, whoAmIButton, compareAttributeButton, modifyAttributeButton, renameButton),
 			adminEntry,
 		),
-		container.NewBorder(nil, nil, makeLabel("Admin密码:"), adminTestButton,
+		container.NewBorder(nil, nil, makeLabel("Admin密码:"), container.NewHBox(anonymousCheck, adminTestButton),
 			passwordEntry,
 		),
+		container.NewBorder(nil, nil, makeLabel("绑定方式:"), clientCertButton,
+			bindMethodSelect,
+		),
 		// Add the LDAP permissions group entry here
-		container.NewBorder(nil, nil, makeLabel("Ldap权限组:"), groupButton,
+		container.NewBorder(nil, nil, makeLabel("Ldap权限组:"), container.NewHBox(expandNestedCheck, listMembersButton, memberOfButton, groupScopeSelect, securityGroupCheck, groupButton, verifySSOButton),
 			ldapGroupEntry,
 		),
-		container.NewBorder(nil, nil, makeLabel("Ldap DN:"), nil,
+		container.NewBorder(nil, nil, makeLabel("Ldap DN:"), container.NewHBox(viewEntryButton, compareEntriesButton, viewPermissionsButton, accountStatusButton, unlockAccountButton, toggleAccountButton, bulkCreateButton),
 			ldapDNEntry,
 		),
-		container.NewBorder(nil, nil, makeLabel("Ldap密码:"), createLdapButton,
+		container.NewBorder(nil, nil, makeLabel("Ldap密码:"), container.NewHBox(forcePasswordChangeCheck, accountExpiryButton, generatePasswordButton, readAccessButton, createLdapButton),
 			ldapPasswordEntry,
 		),
-		container.NewBorder(nil, nil, makeLabel("搜索DN:"), nil,
+		container.NewBorder(nil, nil, makeLabel("搜索DN:"), container.NewHBox(searchScopeSelect, searchEntriesButton, findByObjectIDButton, copyLdapsearchButton, exportCSVButton, exportLDIFButton, importLDIFButton),
 			searchDNEntry,
 		),
-		container.NewBorder(nil, nil, makeLabel("过滤器:"), nil,
+		container.NewBorder(nil, nil, makeLabel("搜索限制:"), nil,
+			container.NewGridWithColumns(4,
+				widget.NewLabel("最大结果数"), sizeLimitEntry,
+				widget.NewLabel("超时(秒)"), timeLimitEntry,
+			),
+		),
+		widget.NewAccordion(widget.NewAccordionItem("高级设置",
+			container.NewBorder(nil, nil, nil, applyAdvancedButton,
+				container.NewGridWithColumns(7,
+					widget.NewLabel("连接超时(秒)"), connectTimeoutEntry,
+					widget.NewLabel("最大重试次数"), maxRetriesEntry,
+					widget.NewLabel("重试间隔(毫秒)"), retryDelayEntry,
+					followReferralsCheck,
+				),
+			),
+		)),
+		container.NewBorder(nil, nil, makeLabel("过滤器:"), testAllFiltersButton,
 			container.NewVBox(
-				filterSelect,
+				container.NewBorder(nil, nil, nil, addFilterButton, filterSelect.Select),
 				filterDescLabel,
 			),
 		),
-		container.NewBorder(nil, nil, makeLabel("测试用户名:"), adminTestUserButton,
+		container.NewBorder(nil, nil, makeLabel("测试用户名:"), container.NewHBox(bulkTestUsersButton, adminTestUserButton),
 			testUserEntry,
 		),
-		container.NewBorder(nil, nil, makeLabel("测试密码:"), ldapTestUserButton,
+		container.NewBorder(nil, nil, makeLabel("测试密码:"), container.NewHBox(benchmarkButton, ldapTestUserButton),
 			testPasswordEntry,
 		),
 	)
 
+	// 日志级别选择框
+	logLevelSelect := widget.NewSelect(logger.LogLevels(), func(selected string) {
+		if level, ok := logger.ParseLogLevel(selected); ok {
+			appLogger.SetMinLevel(level)
+			appLogger.Info("日志级别已设置为：%s", selected)
+		}
+	})
+	logLevelSelect.SetSelected(logger.DEBUG.String())
+
+	// 字号选择框，选择结果保存到配置文件
+	textSizeSelect := widget.NewSelect(ui.TextSizeNames(), func(selected string) {
+		if selected == appConfig.TextSize || !appTheme.SetTextSize(selected) {
+			return
+		}
+		myApp.Settings().SetTheme(appTheme)
+		appConfig.TextSize = selected
+		if err := appConfig.Save(); err != nil {
+			appLogger.Error("保存字号设置失败：%v", err)
+			return
+		}
+		appLogger.Info("字号已设置为：%s", selected)
+	})
+	textSizeSelect.SetSelected(appConfig.TextSize)
+
+	// 清空日志按钮，通过日志记录器清空以同时重置重复日志的合并状态
+	clearLogButton := widget.NewButton("清空日志", func() {
+		baseLogger.Clear(clearStatus)
+	})
+
+	// 复制日志按钮
+	copyLogButton := widget.NewButton("复制日志", func() {
+		myWindow.Clipboard().SetContent(statusArea.Text)
+		appLogger.Info("日志已复制到剪贴板")
+	})
+
+	// 导出JSON日志按钮
+	exportJSONLogButton := widget.NewButton("导出JSON日志", func() {
+		ldapOps.ShowExportJSONLogDialog()
+	})
+
+	// 合并重复日志开关，重试时连续相同的日志只显示一次
+	dedupeCheck := widget.NewCheck("合并重复日志", func(checked bool) {
+		appLogger.SetDedupe(checked)
+	})
+	dedupeCheck.SetChecked(true)
+
+	// 后台操作进度条及取消按钮
+	progressBar := widget.NewProgressBarInfinite()
+	ldapOps.SetProgressBar(progressBar)
+	cancelButton := widget.NewButton("取消", func() {
+		ldapOps.CancelOperations()
+	})
+
+	// 撤销最近一次修改操作
+	undoButton := widget.NewButton("撤销", func() {
+		ldapOps.HandleUndo()
+	})
+	ldapOps.SetUndoButton(undoButton)
+
+	// 只读模式复选框：禁用所有写操作按钮，解锁按钮需要重新查询账户状态后才启用
+	readOnlyCheck := widget.NewCheck("只读模式", func(checked bool) {
+		ldapOps.SetReadOnly(checked)
+		if checked {
+			unlockAccountButton.Disable()
+		}
+	})
+	ldapOps.SetMutatingButtons(modifyAttributeButton, renameButton, importLDIFButton, createLdapButton,
+		toggleAccountButton, bulkCreateButton, groupButton)
+
+	// 保持连接复选框
+	keepAliveCheck := widget.NewCheck("保持连接", func(checked bool) {
+		ldapOps.SetKeepAlive(checked)
+	})
+
+	// 跳过TLS验证复选框，与CA证书互斥
+	skipTLSCheck := widget.NewCheck("跳过TLS验证", func(checked bool) {
+		appLogger.Debug("TLS验证状态改变：%v", checked)
+		// 更新所有LDAP客户端实例的TLS验证设置
+		ldap.SetSkipTLSVerify(checked)
+
+		// 记录TLS验证状态变化的明确提示
+		if checked {
+			appLogger.Info("将跳过TLS证书验证")
+		} else {
+			appLogger.Info("将执行TLS证书验证")
+		}
+	})
+
+	// CA证书按钮：加载CA后强制验证证书
+	caFileButton := widget.NewButton("CA证书", func() {
+		ldapOps.ShowCAFileDialog(func(configured bool) {
+			if configured {
+				skipTLSCheck.SetChecked(false)
+				skipTLSCheck.Disable()
+			} else {
+				skipTLSCheck.Enable()
+			}
+		})
+	})
+
 	// 修改窗口布局
 	appLogger.Debug("构建窗口布局")
 	content := container.NewBorder(
@@ -302,6 +786,21 @@ func main() {
 			container.NewHBox(
 				widget.NewLabel("LDAP 服务测试"),
 				layout.NewSpacer(),
+				logLevelSelect,
+				widget.NewLabel("字号"),
+				textSizeSelect,
+				copyLogButton,
+				exportJSONLogButton,
+				clearLogButton,
+				widget.NewCheck("深色模式", func(checked bool) {
+					variant := theme.VariantLight
+					if checked {
+						variant = theme.VariantDark
+					}
+					appTheme.SetVariant(variant)
+					myApp.Settings().SetTheme(appTheme)
+					appLogger.Debug("切换主题：深色模式=%v", checked)
+				}),
 				widget.NewCheck("调试模式", func(checked bool) {
 					appLogger.Debug("调试模式状态改变：%v", checked)
 					debugMode = checked
@@ -313,20 +812,17 @@ func main() {
 						appLogger.Info("已关闭调试模式，将只输出重要日志")
 					}
 				}),
-				widget.NewCheck("跳过TLS验证", func(checked bool) {
-					appLogger.Debug("TLS验证状态改变：%v", checked)
-					// 更新所有LDAP客户端实例的TLS验证设置
-					ldap.SetSkipTLSVerify(checked)
-
-					// 记录TLS验证状态变化的明确提示
-					if checked {
-						appLogger.Info("将跳过TLS证书验证")
-					} else {
-						appLogger.Info("将执行TLS证书验证")
-					}
+				dedupeCheck,
+				widget.NewCheck("协议调试", func(checked bool) {
+					ldapOps.SetProtocolDebug(checked)
 				}),
+				readOnlyCheck,
+				keepAliveCheck,
+				skipTLSCheck,
+				caFileButton,
 			),
 			formContainer,
+			container.NewBorder(nil, nil, nil, container.NewHBox(undoButton, cancelButton), progressBar),
 		),
 		nil, // 底部
 		nil, // 左侧
@@ -344,6 +840,7 @@ func main() {
 	// 设置窗口关闭事件
 	myWindow.SetOnClosed(func() {
 		appLogger.Info("应用程序正在关闭，清理资源...")
+		ldapOps.Close()
 		appLogger.Info("资源清理完成，应用程序退出")
 	})
 
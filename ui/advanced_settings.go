@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"LdapTest/config"
+)
+
+// AdvancedSettings 返回当前的高级设置，用于填充界面
+func (ops *LDAPOperations) AdvancedSettings() config.AdvancedConfig {
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+	return config.AdvancedConfig{
+		TimeoutSeconds:  int(ops.ldapConfig.Timeout / time.Second),
+		MaxRetries:      ops.ldapConfig.MaxRetries,
+		RetryDelayMs:    int(ops.ldapConfig.RetryDelay / time.Millisecond),
+		FollowReferrals: ops.followReferrals,
+	}
+}
+
+// SetAdvancedSettings 根据输入设置连接超时（秒）、最大重试次数和重试间隔（毫秒），
+// 只影响之后新建的客户端，设置成功后保存到配置文件
+func (ops *LDAPOperations) SetAdvancedSettings(timeoutText string, retriesText string, delayText string, followReferrals bool) error {
+	timeout, err := parseBoundedInt(timeoutText, 1)
+	if err != nil {
+		return fmt.Errorf("超时时间无效: %v", err)
+	}
+	retries, err := parseBoundedInt(retriesText, 1)
+	if err != nil {
+		return fmt.Errorf("最大重试次数无效: %v", err)
+	}
+	delay, err := parseBoundedInt(delayText, 0)
+	if err != nil {
+		return fmt.Errorf("重试间隔无效: %v", err)
+	}
+
+	settings := config.AdvancedConfig{
+		TimeoutSeconds:  timeout,
+		MaxRetries:      retries,
+		RetryDelayMs:    delay,
+		FollowReferrals: followReferrals,
+	}
+	ops.applyAdvancedSettings(settings)
+	ops.logger.Info("高级设置已更新（对之后新建的连接生效）：超时=%d秒，最大重试=%d次，重试间隔=%d毫秒，跟随引用=%v",
+		timeout, retries, delay, followReferrals)
+
+	if ops.config == nil {
+		return nil
+	}
+	ops.config.Advanced = &settings
+	if err := ops.config.Save(); err != nil {
+		return fmt.Errorf("保存配置失败: %v", err)
+	}
+	ops.logger.Debug("高级设置已保存到：%s", ops.config.Path())
+	return nil
+}
+
+// applyAdvancedSettings 将高级设置应用到新建客户端使用的配置，无效值保留默认值
+func (ops *LDAPOperations) applyAdvancedSettings(settings config.AdvancedConfig) {
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+	if settings.TimeoutSeconds > 0 {
+		ops.ldapConfig.Timeout = time.Duration(settings.TimeoutSeconds) * time.Second
+	}
+	if settings.MaxRetries > 0 {
+		ops.ldapConfig.MaxRetries = settings.MaxRetries
+	}
+	if settings.RetryDelayMs >= 0 {
+		ops.ldapConfig.RetryDelay = time.Duration(settings.RetryDelayMs) * time.Millisecond
+	}
+	ops.followReferrals = settings.FollowReferrals
+}
+
+// parseBoundedInt 解析不小于min的整数
+func parseBoundedInt(text string, min int) (int, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || value < min {
+		return 0, fmt.Errorf("请输入不小于%d的整数：%s", min, text)
+	}
+	return value, nil
+}
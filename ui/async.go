@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"context"
+	"sync"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// busyIndicator 在后台操作进行时显示无限进度条
+type busyIndicator struct {
+	mu      sync.Mutex
+	count   int
+	bar     *widget.ProgressBarInfinite
+	running map[string]bool
+}
+
+// acquire 标记命名操作开始，操作已在进行中时返回false
+func (b *busyIndicator) acquire(name string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.running == nil {
+		b.running = make(map[string]bool)
+	}
+	if b.running[name] {
+		return false
+	}
+	b.running[name] = true
+	return true
+}
+
+// release 标记命名操作结束
+func (b *busyIndicator) release(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.running, name)
+}
+
+// begin 开始一个后台操作
+func (b *busyIndicator) begin() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.count++
+	if b.count == 1 && b.bar != nil {
+		b.bar.Show()
+		b.bar.Start()
+	}
+}
+
+// end 结束一个后台操作
+func (b *busyIndicator) end() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.count > 0 {
+		b.count--
+	}
+	if b.count == 0 && b.bar != nil {
+		b.bar.Stop()
+		b.bar.Hide()
+	}
+}
+
+// SetProgressBar 设置用于显示忙碌状态的进度条
+func (ops *LDAPOperations) SetProgressBar(bar *widget.ProgressBarInfinite) {
+	ops.busy.mu.Lock()
+	defer ops.busy.mu.Unlock()
+	ops.busy.bar = bar
+	if ops.busy.count == 0 {
+		bar.Stop()
+		bar.Hide()
+	}
+}
+
+// runAsync 在后台goroutine中执行耗时的LDAP操作，期间显示进度条
+func (ops *LDAPOperations) runAsync(fn func()) {
+	ops.busy.begin()
+	go func() {
+		defer ops.busy.end()
+		// 操作结束时输出合并中的重复日志提示
+		defer ops.logger.Flush()
+		fn()
+	}()
+}
+
+// runOperation 在后台执行命名操作，同一操作完成前不允许重复启动
+func (ops *LDAPOperations) runOperation(name string, fn func()) {
+	if !ops.busy.acquire(name) {
+		ops.logger.Warn("%s正在进行中，请等待完成", name)
+		return
+	}
+	ops.runAsync(func() {
+		defer ops.busy.release(name)
+		fn()
+	})
+}
+
+// operationContext 返回当前操作使用的上下文
+func (ops *LDAPOperations) operationContext() context.Context {
+	ops.busy.mu.Lock()
+	defer ops.busy.mu.Unlock()
+	if ops.ctx == nil {
+		ops.ctx, ops.cancel = context.WithCancel(context.Background())
+	}
+	return ops.ctx
+}
+
+// CancelOperations 取消所有进行中的LDAP操作
+func (ops *LDAPOperations) CancelOperations() {
+	ops.busy.mu.Lock()
+	cancel := ops.cancel
+	busy := ops.busy.count > 0
+	// 为后续操作准备新的上下文
+	ops.ctx, ops.cancel = context.WithCancel(context.Background())
+	ops.busy.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	if busy {
+		ops.logger.Warn("操作已取消")
+	} else {
+		ops.logger.Info("当前没有进行中的操作")
+	}
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"LdapTest/ldap"
+)
+
+// HandleBulkCreateUsers 从CSV文件（cn,password,ou）批量创建用户
+func (ops *LDAPOperations) HandleBulkCreateUsers(domain string, adminDN string, adminPassword string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始批量创建用户")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			ops.logger.Error("打开CSV文件失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		if reader == nil {
+			return // 用户取消
+		}
+		defer reader.Close()
+
+		users, err := ldap.ParseBulkUsersCSV(reader)
+		if err != nil {
+			ops.logger.Error("解析CSV失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		if len(users) == 0 {
+			ops.logger.Warn("CSV文件中没有用户数据")
+			return
+		}
+		ops.logger.Info("从 %s 读取到 %d 个用户", reader.URI().Path(), len(users))
+
+		ops.runOperation("批量创建用户", func() {
+			if !client.TestLDAPService() {
+				ops.logger.Error("管理员认证失败，BindDN: %s", adminDN)
+				dialog.ShowError(fmt.Errorf("管理员认证失败"), ops.window)
+				return
+			}
+			if _, err := ops.detectDirectoryType(client); err != nil {
+				ops.logger.Warn("检测目录类型失败，沿用模板 %s：%v", client.GetUserTemplate().Name, err)
+			}
+
+			baseDN, err := ops.resolveSearchDN(client, searchDN)
+			if err != nil {
+				ops.logger.Error("检测基准DN失败：%v", err)
+				dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+				return
+			}
+
+			result, err := client.BulkCreateUsers(users, baseDN, isSSL)
+			if err != nil {
+				ops.logger.Error("批量创建中断：%v", err)
+			}
+			ops.logger.Info("批量创建完成：创建 %d 个，跳过 %d 个，失败 %d 个", result.Created, result.Skipped, result.Failed)
+			if result.Created > 0 {
+				ops.clearUndo()
+			}
+		})
+	}, ops.window)
+}
+
+// HandleBulkTestUsers 从CSV文件（username,password）批量验证用户，使用当前过滤器和搜索DN
+func (ops *LDAPOperations) HandleBulkTestUsers(domain string, adminDN string, adminPassword string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始批量验证用户")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword) {
+		return
+	}
+
+	filter, _ := ops.filterSelect.SelectedFilter()
+	if err := ldap.ValidateFilterPattern(filter.Pattern); err != nil {
+		ops.logger.Error("过滤器无效：%v", err)
+		dialog.ShowError(fmt.Errorf("请选择有效的过滤器: %v", err), ops.window)
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			ops.logger.Error("打开CSV文件失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		if reader == nil {
+			return // 用户取消
+		}
+		defer reader.Close()
+
+		credentials, err := ldap.ParseCredentialsCSV(reader)
+		if err != nil {
+			ops.logger.Error("解析CSV失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		if len(credentials) == 0 {
+			ops.logger.Warn("CSV文件中没有账号数据")
+			return
+		}
+		ops.logger.Info("从 %s 读取到 %d 个账号，使用 %s 过滤器验证", reader.URI().Path(), len(credentials), ops.filterSelect.Selected())
+
+		ops.runOperation("批量验证用户", func() {
+			baseDN, err := ops.resolveSearchDN(client, searchDN)
+			if err != nil {
+				ops.logger.Error("检测基准DN失败：%v", err)
+				dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+				return
+			}
+
+			report, err := client.BulkTestUserAuth(credentials, baseDN, filter.Pattern)
+			if err != nil {
+				ops.logger.Error("批量验证中断：%v", err)
+			}
+			ops.logger.Info("%s", strings.Join(append([]string{"批量验证完成："}, report.Summary()...), "\n"))
+		})
+	}, ops.window)
+}
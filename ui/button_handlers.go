@@ -8,7 +8,7 @@ import (
 func CreateButtonHandlers(ops *LDAPOperations, entries *UIEntries) map[string]func() {
 	return map[string]func(){
 		"ping": func() {
-			ops.HandlePing(entries.DomainEntry.Text)
+			ops.HandlePing(entries.DomainEntry.Text, entries.PortEntry, ops.isSSLMode)
 		},
 		"portTest": func() {
 			ops.HandlePortTest(entries.DomainEntry.Text, entries.PortEntry, ops.isSSLMode)
@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"LdapTest/config"
+	"LdapTest/ldap"
+)
+
+// domainDNPlaceholder DN模板中代表域DN的占位符
+const domainDNPlaceholder = "{domainDN}"
+
+// DefaultDNTemplates 返回内置的默认DN模板，LDAP服务账号和权限组都放在CN=Users下
+func DefaultDNTemplates() config.DNTemplates {
+	return config.DNTemplates{
+		AdminDN:     "CN=Administrator,CN=Users," + domainDNPlaceholder,
+		SearchDN:    domainDNPlaceholder,
+		LdapUserDN:  "CN=LdapService,CN=Users," + domainDNPlaceholder,
+		LdapGroupDN: "CN=LdapGroup,CN=Users," + domainDNPlaceholder,
+	}
+}
+
+// DomainToDN 将域名按"."拆分为DC组件，如corp.local转换为DC=corp,DC=local
+func DomainToDN(domain string) string {
+	var dnParts []string
+	for _, part := range strings.Split(domain, ".") {
+		if part != "" {
+			dnParts = append(dnParts, ldap.RDN("DC", part))
+		}
+	}
+	return strings.Join(dnParts, ",")
+}
+
+// ExpandDNTemplate 将模板中的{domainDN}替换为域DN
+func ExpandDNTemplate(template string, domainDN string) string {
+	return strings.ReplaceAll(template, domainDNPlaceholder, domainDN)
+}
+
+// DNTemplates 返回当前使用的DN模板，配置文件中未设置的模板使用内置默认值
+func (ops *LDAPOperations) DNTemplates() config.DNTemplates {
+	templates := DefaultDNTemplates()
+	if ops.config == nil || ops.config.DNTemplates == nil {
+		return templates
+	}
+	saved := ops.config.DNTemplates
+	if saved.AdminDN != "" {
+		templates.AdminDN = saved.AdminDN
+	}
+	if saved.SearchDN != "" {
+		templates.SearchDN = saved.SearchDN
+	}
+	if saved.LdapUserDN != "" {
+		templates.LdapUserDN = saved.LdapUserDN
+	}
+	if saved.LdapGroupDN != "" {
+		templates.LdapGroupDN = saved.LdapGroupDN
+	}
+	return templates
+}
+
+// ShowDNTemplatesDialog 编辑自动生成默认DN的模板并保存到配置文件，保存成功后调用onSaved
+func (ops *LDAPOperations) ShowDNTemplatesDialog(onSaved func()) {
+	current := ops.DNTemplates()
+	newEntry := func(text string) *widget.Entry {
+		entry := widget.NewEntry()
+		entry.SetText(text)
+		return entry
+	}
+	adminEntry := newEntry(current.AdminDN)
+	searchEntry := newEntry(current.SearchDN)
+	ldapUserEntry := newEntry(current.LdapUserDN)
+	ldapGroupEntry := newEntry(current.LdapGroupDN)
+	resetButton := widget.NewButton("恢复默认", func() {
+		defaults := DefaultDNTemplates()
+		adminEntry.SetText(defaults.AdminDN)
+		searchEntry.SetText(defaults.SearchDN)
+		ldapUserEntry.SetText(defaults.LdapUserDN)
+		ldapGroupEntry.SetText(defaults.LdapGroupDN)
+	})
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("管理员DN", adminEntry),
+		widget.NewFormItem("搜索DN", searchEntry),
+		widget.NewFormItem("LDAP用户DN", ldapUserEntry),
+		widget.NewFormItem("LDAP权限组DN", ldapGroupEntry),
+		widget.NewFormItem("", widget.NewLabel(domainDNPlaceholder+" 会替换为服务器地址对应的域DN，如 DC=corp,DC=local")),
+		widget.NewFormItem("", resetButton),
+	}
+
+	dialog.ShowForm("默认DN模板", "保存", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		templates := config.DNTemplates{
+			AdminDN:     strings.TrimSpace(adminEntry.Text),
+			SearchDN:    strings.TrimSpace(searchEntry.Text),
+			LdapUserDN:  strings.TrimSpace(ldapUserEntry.Text),
+			LdapGroupDN: strings.TrimSpace(ldapGroupEntry.Text),
+		}
+		for label, template := range map[string]string{
+			"管理员DN":     templates.AdminDN,
+			"搜索DN":      templates.SearchDN,
+			"LDAP用户DN":  templates.LdapUserDN,
+			"LDAP权限组DN": templates.LdapGroupDN,
+		} {
+			if err := validateDNTemplate(template); err != nil {
+				ops.logger.Error("DN模板无效：%s：%v", label, err)
+				dialog.ShowError(fmt.Errorf("%s模板无效: %v", label, err), ops.window)
+				return
+			}
+		}
+
+		if ops.config == nil {
+			ops.logger.Warn("未加载配置文件，DN模板无法保存")
+			return
+		}
+		ops.config.DNTemplates = &templates
+		if err := ops.config.Save(); err != nil {
+			ops.logger.Error("保存配置失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		ops.logger.Info("DN模板已保存到：%s", ops.config.Path())
+		if onSaved != nil {
+			onSaved()
+		}
+	}, ops.window)
+}
+
+// validateDNTemplate 用示例域DN展开模板后校验DN语法
+func validateDNTemplate(template string) error {
+	if template == "" {
+		return fmt.Errorf("模板不能为空")
+	}
+	if !strings.Contains(template, domainDNPlaceholder) {
+		return fmt.Errorf("模板必须包含%s", domainDNPlaceholder)
+	}
+	return ldap.ValidateDN(ExpandDNTemplate(template, "DC=corp,DC=local"))
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"LdapTest/ldap"
+)
+
+// combineNone 只使用第一个条件
+const combineNone = "仅条件1"
+
+// conditionInputs 过滤器构建对话框中一个条件的输入控件
+type conditionInputs struct {
+	attribute *widget.SelectEntry
+	operator  *widget.Select
+	value     *widget.Entry
+}
+
+// newConditionInputs 创建一组条件输入控件，内容变化时调用onChanged
+func newConditionInputs(defaultAttribute string, onChanged func()) *conditionInputs {
+	c := &conditionInputs{
+		attribute: widget.NewSelectEntry(ldap.FilterBuilderAttributes()),
+		operator:  widget.NewSelect(ldap.FilterOperators(), nil),
+		value:     widget.NewEntry(),
+	}
+	c.attribute.SetText(defaultAttribute)
+	c.operator.SetSelected(ldap.FilterOpEquals)
+	c.value.SetPlaceHolder("值（存在判断时忽略）")
+
+	c.attribute.OnChanged = func(string) { onChanged() }
+	c.operator.OnChanged = func(op string) {
+		if op == ldap.FilterOpPresent {
+			c.value.Disable()
+		} else {
+			c.value.Enable()
+		}
+		onChanged()
+	}
+	c.value.OnChanged = func(string) { onChanged() }
+	return c
+}
+
+// condition 返回输入控件对应的条件
+func (c *conditionInputs) condition() ldap.FilterCondition {
+	return ldap.FilterCondition{
+		Attribute: c.attribute.Text,
+		Operator:  c.operator.Selected,
+		Value:     c.value.Text,
+	}
+}
+
+// ShowFilterBuilderDialog 显示过滤器构建对话框，确认后将生成的过滤器传给onBuilt
+func (ops *LDAPOperations) ShowFilterBuilderDialog(onBuilt func(filter string)) {
+	preview := widget.NewLabel("")
+	preview.Wrapping = fyne.TextWrapBreak
+
+	var first, second *conditionInputs
+	combineSelect := widget.NewSelect([]string{combineNone, ldap.FilterCombineAnd, ldap.FilterCombineOr}, nil)
+
+	build := func() (string, error) {
+		conditions := []ldap.FilterCondition{first.condition()}
+		if combineSelect.Selected != combineNone {
+			conditions = append(conditions, second.condition())
+		}
+		return ldap.BuildFilter(conditions, combineSelect.Selected)
+	}
+	updatePreview := func() {
+		if first == nil || second == nil {
+			return // 控件尚未创建完成
+		}
+		filter, err := build()
+		if err != nil {
+			preview.SetText("（" + err.Error() + "）")
+			return
+		}
+		preview.SetText(filter)
+	}
+
+	first = newConditionInputs("cn", updatePreview)
+	second = newConditionInputs("objectClass", updatePreview)
+	combineSelect.OnChanged = func(combine string) {
+		for _, w := range []fyne.Disableable{second.attribute, second.operator, second.value} {
+			if combine == combineNone {
+				w.Disable()
+			} else {
+				w.Enable()
+			}
+		}
+		if combine != combineNone && second.operator.Selected == ldap.FilterOpPresent {
+			second.value.Disable()
+		}
+		updatePreview()
+	}
+	combineSelect.SetSelected(combineNone)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("条件1 属性", first.attribute),
+		widget.NewFormItem("条件1 比较", first.operator),
+		widget.NewFormItem("条件1 值", first.value),
+		widget.NewFormItem("组合方式", combineSelect),
+		widget.NewFormItem("条件2 属性", second.attribute),
+		widget.NewFormItem("条件2 比较", second.operator),
+		widget.NewFormItem("条件2 值", second.value),
+		widget.NewFormItem("过滤器", preview),
+	}
+
+	dialog.ShowForm("构建过滤器", "使用", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		filter, err := build()
+		if err != nil {
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		ops.logger.Debug("生成过滤器：%s", filter)
+		onBuilt(filter)
+	}, ops.window)
+}
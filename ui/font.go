@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"os"
+	"runtime"
+)
+
+// cjkFontCandidates 各平台常见的中文字体路径，按优先级排列
+var cjkFontCandidates = map[string][]string{
+	"windows": {
+		`C:\Windows\Fonts\SIMYOU.TTF`,
+		`C:\Windows\Fonts\simhei.ttf`,
+		`C:\Windows\Fonts\msyh.ttc`,
+	},
+	"darwin": {
+		"/System/Library/Fonts/PingFang.ttc",
+		"/System/Library/Fonts/STHeiti Light.ttc",
+		"/Library/Fonts/Arial Unicode.ttf",
+	},
+	"linux": {
+		"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+		"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+		"/usr/share/fonts/google-noto-cjk/NotoSansCJK-Regular.ttc",
+		"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+		"/usr/share/fonts/wqy-microhei/wqy-microhei.ttc",
+		"/usr/share/fonts/truetype/droid/DroidSansFallbackFull.ttf",
+	},
+}
+
+// fileExists 判断路径是否为存在的文件
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// ConfigureCJKFont 设置FYNE_FONT以正确显示中文，返回使用的字体路径
+// 优先级：已设置的FYNE_FONT环境变量 > 配置文件中的字体 > 当前平台的常见字体；都不存在时使用Fyne默认字体并返回空字符串
+func ConfigureCJKFont(configured string) string {
+	if font := os.Getenv("FYNE_FONT"); font != "" {
+		return font
+	}
+
+	candidates := cjkFontCandidates[runtime.GOOS]
+	if configured != "" {
+		candidates = append([]string{configured}, candidates...)
+	}
+	for _, path := range candidates {
+		if fileExists(path) {
+			os.Setenv("FYNE_FONT", path)
+			return path
+		}
+	}
+	return ""
+}
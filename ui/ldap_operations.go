@@ -1,15 +1,21 @@
 package ui
 
 import (
+	"context"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 
+	"LdapTest/config"
 	"LdapTest/ldap"
 	"LdapTest/logger"
 	"fmt"
-	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // CustomFilterSelect 自定义过滤器选择框
@@ -29,51 +35,127 @@ func (c *CustomFilterSelect) Selected() string {
 	return c.Select.Selected
 }
 
+// SelectedFilter 获取当前选中的过滤器，未选择或找不到时返回false
+func (c *CustomFilterSelect) SelectedFilter() (ldap.LDAPFilter, bool) {
+	return ldap.FilterByName(c.Select.Selected)
+}
+
+// ReloadFilters 重新加载过滤器选项（内置+自定义），当前选择不再可用时选择第一个过滤器
+func (c *CustomFilterSelect) ReloadFilters() {
+	options := FilterOptions()
+	c.Select.SetOptions(options)
+	for _, option := range options {
+		if option == c.Select.Selected {
+			return
+		}
+	}
+	if len(options) > 1 {
+		c.Select.SetSelected(options[1])
+	}
+}
+
+// FilterOptions 返回过滤器选择框的选项，合并内置和自定义过滤器
+func FilterOptions() []string {
+	names := []string{"(Select one)"}
+	for _, f := range ldap.AllFilters() {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
 // LDAPOperations 处理所有LDAP相关的界面交互
 type LDAPOperations struct {
-	window       fyne.Window
-	logger       *logger.BaseLogger
-	client       *ldap.LDAPClient
-	isSSLMode    bool
-	updateStatus func(string)
-	debugMode    bool
-	filterSelect *CustomFilterSelect
+	window        fyne.Window
+	logger        *logger.BaseLogger
+	client        *ldap.LDAPClient
+	isSSLMode     bool
+	updateStatus  func(string)
+	debugMode     bool
+	filterSelect  *CustomFilterSelect
+	userTemplate  ldap.UserTemplate
+	searchDNEntry *widget.Entry
+	config        *config.AppConfig
+	busy          busyIndicator
+	ctx           context.Context
+	cancel        context.CancelFunc
+
+	// mu 保护client、保持连接、最近一次搜索结果以及后台操作读取的设置，
+	// 这些字段在界面回调中修改，同时被后台操作读取
+	mu sync.Mutex
+
+	forcePasswordChange bool
+	accountExpiry       time.Time          // 新建AD用户的过期时间，零值表示永不过期
+	lastSearch          *ldap.SearchResult // 最近一次搜索结果，用于导出
+	searchScope         int
+	sizeLimit           int
+	timeLimit           int
+	bindMethod          string
+	groupScope          int  // 创建或重新授权组时使用的组范围
+	securityGroup       bool // 是否为安全组，否则为通讯组
+	followReferrals     bool
+	protocolDebug       bool
+	globalCatalog       bool            // 是否连接全局编录，启用后从林根搜索
+	ldapConfig          ldap.LDAPConfig // 新建客户端使用的超时和重试配置
+	readOnly            bool            // 只读模式，启用后禁用写操作按钮并拒绝所有写请求
+	mutatingButtons     []*widget.Button
+
+	entries               *UIEntries // 界面输入框，用于统一校验
+	confirmedPlaceholders string     // 用户已确认继续使用的示例值
+
+	keepAlive       bool             // 是否保持连接
+	keepAliveClient *ldap.LDAPClient // 保持连接的客户端，连接参数相同时复用
+	keepAliveKey    string           // keepAliveClient对应的连接参数
+
+	undoMu     sync.Mutex
+	undo       *undoAction    // 最近一次可撤销的修改操作
+	undoButton *widget.Button // 撤销按钮，没有可撤销操作时禁用
 }
 
 // NewLDAPOperations 创建新的LDAP操作处理器
 func NewLDAPOperations(window fyne.Window, logger *logger.BaseLogger, updateStatus func(string), debugMode bool, filterSelect *CustomFilterSelect) *LDAPOperations {
 	return &LDAPOperations{
-		window:       window,
-		logger:       logger,
-		updateStatus: updateStatus,
-		debugMode:    debugMode,
-		filterSelect: filterSelect,
+		window:        window,
+		logger:        logger,
+		updateStatus:  updateStatus,
+		debugMode:     debugMode,
+		filterSelect:  filterSelect,
+		userTemplate:  ldap.DefaultUserTemplate(),
+		searchScope:   ldap.DefaultSearchScope,
+		bindMethod:    ldap.BindMethodSimple,
+		groupScope:    ldap.DefaultGroupScope,
+		securityGroup: true,
+		ldapConfig:    ldap.DefaultLDAPConfig(),
 	}
 }
 
-// HandleExistingUser 处理已存在用户的情况
-func (ops *LDAPOperations) HandleExistingUser(userDN string, password string, groupDN string, searchDN string) {
+// HandleExistingUser 处理已存在用户的情况，后续操作使用查找到该用户的客户端
+func (ops *LDAPOperations) HandleExistingUser(client *ldap.LDAPClient, userDN string, password string, groupDN string, searchDN string) {
 	sslMode := "false"
-	if ops.isSSLMode {
+	if client.IsSSL() {
 		sslMode = "true"
 	}
 	ops.logger.Info("处理已存在用户，DN: " + userDN + ", SSL模式: " + sslMode)
-	if ops.isSSLMode {
+	if client.IsSSL() {
 		dialog.ShowConfirm("用户已存在",
 			"用户已存在且位置相同：\n"+userDN+"\n\n是否要更新用户密码？",
 			func(updatePassword bool) {
-				if updatePassword {
-					ops.logger.Info("开始更新用户密码...")
-					if err := ops.client.UpdateUserPassword(userDN, password); err != nil {
+				if !updatePassword {
+					ops.logger.Info("保持用户密码不变：" + userDN)
+					ops.PromptForGroupMembership(client, userDN, groupDN, searchDN, false)
+					return
+				}
+				ops.logger.Info("开始更新用户密码...")
+				ops.runAsync(func() {
+					if err := client.UpdateUserPassword(userDN, password); err != nil {
 						ops.logger.Error("密码更新失败：" + err.Error())
 						dialog.ShowError(err, ops.window)
 						return
 					}
 					ops.logger.Info("用户密码更新成功")
-				} else {
-					ops.logger.Info("保持用户密码不变：" + userDN)
-				}
-				ops.PromptForGroupMembership(userDN, groupDN, searchDN)
+					// 原密码无法读取，更新后之前的撤销记录不再是最近一次修改
+					ops.clearUndo()
+					ops.PromptForGroupMembership(client, userDN, groupDN, searchDN, false)
+				})
 			}, ops.window)
 	} else {
 		dialog.ShowConfirm("用户已存在",
@@ -82,7 +164,7 @@ func (ops *LDAPOperations) HandleExistingUser(userDN string, password string, gr
 				if confirmed {
 					ops.logger.Debug("用户确认继续操作")
 					ops.logger.Info("用户已存在：" + userDN)
-					ops.PromptForGroupMembership(userDN, groupDN, searchDN)
+					ops.PromptForGroupMembership(client, userDN, groupDN, searchDN, false)
 				} else {
 					ops.logger.Debug("用户取消操作")
 					ops.logger.Info("操作已取消")
@@ -91,178 +173,560 @@ func (ops *LDAPOperations) HandleExistingUser(userDN string, password string, gr
 	}
 }
 
-// HandleUserMove 处理用户移动的情况
-func (ops *LDAPOperations) HandleUserMove(currentDN string, targetDN string, password string, groupDN string, searchDN string) {
+// HandleUserMove 处理用户移动的情况，后续操作使用查找到该用户的客户端
+func (ops *LDAPOperations) HandleUserMove(client *ldap.LDAPClient, currentDN string, targetDN string, password string, groupDN string, searchDN string) {
 	sslMode := "false"
-	if ops.isSSLMode {
+	if client.IsSSL() {
 		sslMode = "true"
 	}
 	ops.logger.Debug("处理用户移动，当前DN: " + currentDN + ", 目标DN: " + targetDN + ", SSL模式: " + sslMode)
-	dialog.ShowConfirm("用户已存在",
-		"发现同名用户：\n"+currentDN+"\n\n当前输入位置：\n"+targetDN+"\n\n是否要移动用户？",
+
+	// 默认保留用户现有的组，清理现有组需要用户主动取消勾选
+	keepGroupsCheck := widget.NewCheck("保留现有组", nil)
+	keepGroupsCheck.SetChecked(true)
+	content := container.NewVBox(
+		widget.NewLabel("发现同名用户：\n"+currentDN+"\n\n当前输入位置：\n"+targetDN+"\n\n是否要移动用户？"),
+		keepGroupsCheck,
+	)
+
+	dialog.ShowCustomConfirm("用户已存在", "是", "否", content,
 		func(move bool) {
+			keepGroups := keepGroupsCheck.Checked
 			if move {
 				ops.logger.Debug("用户确认移动操作")
 				ops.logger.Info("正在移动用户 " + currentDN + " -> " + targetDN)
-				if err := ops.client.MoveUserToNewLocation(currentDN, targetDN); err != nil {
-					ops.logger.Error("用户移动失败：" + err.Error())
-					dialog.ShowError(err, ops.window)
-					return
-				}
-				ops.logger.Info("用户移动成功")
-				ops.PromptForGroupMembership(targetDN, groupDN, searchDN)
-
-				if ops.isSSLMode {
-					ops.logger.Debug("SSL模式下，准备更新密码")
-					ops.PromptForPasswordUpdate(targetDN, password)
-				}
+				ops.runAsync(func() {
+					if err := client.MoveUserToNewLocation(currentDN, targetDN); err != nil {
+						ops.logger.Error("用户移动失败：" + err.Error())
+						dialog.ShowError(err, ops.window)
+						return
+					}
+					ops.logger.Info("用户移动成功")
+					ops.recordUndo("移动用户 "+targetDN+" 回 "+currentDN, client, func(client *ldap.LDAPClient) error {
+						return client.MoveUser(targetDN, currentDN)
+					})
+					ops.PromptForGroupMembership(client, targetDN, groupDN, searchDN, keepGroups)
+
+					if client.IsSSL() {
+						ops.logger.Debug("SSL模式下，准备更新密码")
+						ops.PromptForPasswordUpdate(client, targetDN, password)
+					}
+				})
 			} else {
 				ops.logger.Debug("用户取消移动操作，使用现有位置")
 				ops.logger.Info("已使用现有用户位置：" + currentDN)
-				ops.PromptForGroupMembership(currentDN, groupDN, searchDN)
+				ops.copyDNToClipboard(currentDN)
+				ops.PromptForGroupMembership(client, currentDN, groupDN, searchDN, keepGroups)
 			}
 		}, ops.window)
 }
 
-// PromptForGroupMembership 提示是否加入LDAP组
-func (ops *LDAPOperations) PromptForGroupMembership(userDN string, groupDN string, searchDN string) {
+// PromptForGroupMembership 提示是否加入LDAP组，keepGroups为true时保留用户现有的组，不提示清理
+// 加组使用与userClient相同服务器和凭据的新客户端
+func (ops *LDAPOperations) PromptForGroupMembership(userClient *ldap.LDAPClient, userDN string, groupDN string, searchDN string, keepGroups bool) {
 	ops.logger.Debug("提示加入LDAP组，用户DN: " + userDN + ", 组DN: " + groupDN)
-	dialog.ShowConfirm("添加到组",
-		"是否要将用户加入LDAP组？\n用户: "+userDN+"\n组: "+groupDN,
+	// 主组只适用于AD
+	setPrimaryCheck := widget.NewCheck("设为主组", nil)
+	if !userClient.GetUserTemplate().IsActiveDirectory() {
+		setPrimaryCheck.Hide()
+	}
+	content := container.NewVBox(
+		widget.NewLabel("是否要将用户加入LDAP组？\n用户: "+userDN+"\n组: "+groupDN),
+		setPrimaryCheck,
+	)
+
+	dialog.ShowCustomConfirm("添加到组", "是", "否", content,
 		func(addToGroup bool) {
 			if addToGroup {
 				ops.logger.Debug("用户确认加入组操作")
 				ops.logger.Info("正在将用户添加到组 " + groupDN)
 
-				// 创建新的LDAP客户端
-				client := ldap.NewLDAPClient(
-					ops.client.Host,
-					ops.client.Port,
-					ops.client.BindDN,
-					ops.client.BindPassword,
-					ops.logger,
-					ops.updateStatus,
-					ops.isSSLMode,
-					ops.debugMode,
-				)
-
-				// 确保连接有效
-				if err := client.EnsureConnection(); err != nil {
-					ops.logger.Error("连接失败：%v", err)
-					dialog.ShowError(fmt.Errorf("连接失败: %v", err), ops.window)
-					return
-				}
+				ops.runAsync(func() {
+					// 创建新的LDAP客户端
+					client := ldap.NewLDAPClient(
+						userClient.Host,
+						userClient.Port,
+						userClient.BindDN,
+						userClient.BindPassword,
+						ops.logger,
+						ops.updateStatus,
+						userClient.IsSSL(),
+						ops.isDebugMode(),
+					)
+					ops.applyClientSettings(client)
+					client.SetUserTemplate(userClient.GetUserTemplate()) // 沿用自动检测后的模板
+
+					// 确保连接有效，查询和加组复用同一连接
+					if err := client.UseSharedConnection(); err != nil {
+						ops.logger.Error("连接失败：%v", err)
+						dialog.ShowError(fmt.Errorf("连接失败: %v", err), ops.window)
+						return
+					}
+					defer client.ReleaseSharedConnection()
 
-				// 先移除所有现有组
-				ops.logger.Info("正在移除用户的所有现有组...")
-				if err := client.RemoveUserFromAllGroups(userDN, searchDN); err != nil {
-					ops.logger.Error("移除现有组失败：" + err.Error())
-					dialog.ShowError(fmt.Errorf("移除现有组失败: %v", err), ops.window)
-					return
-				}
-				ops.logger.Info("已移除所有现有组")
+					// 查询用户当前所在的组，让用户选择需要清理的组
+					groups, err := client.GetUserGroups(userDN, searchDN)
+					if err != nil {
+						ops.logger.Error("查询现有组失败：" + err.Error())
+						dialog.ShowError(fmt.Errorf("查询现有组失败: %v", err), ops.window)
+						return
+					}
+					var otherGroups []string
+					for _, g := range groups {
+						if !strings.EqualFold(g, groupDN) {
+							otherGroups = append(otherGroups, g)
+						}
+					}
 
-				// 添加用户到新组
-				if err := client.AddUserToGroup(userDN, groupDN); err != nil {
-					ops.logger.Error("添加用户到组失败：" + err.Error())
-					dialog.ShowError(err, ops.window)
-					return
-				}
-				ops.logger.Info("用户成功添加到组")
+					if len(otherGroups) == 0 || keepGroups {
+						if len(otherGroups) > 0 {
+							ops.logger.Info("%s", strings.Join(append([]string{"保留现有组："}, otherGroups...), "\n"))
+						}
+						ops.addUserToGroup(client, userDN, groupDN, setPrimaryCheck.Checked)
+						return
+					}
+					ops.promptForGroupCleanup(client, userDN, groupDN, searchDN, otherGroups, setPrimaryCheck.Checked)
+				})
 			} else {
 				ops.logger.Debug("用户取消加入组操作")
 			}
 		}, ops.window)
 }
 
+// promptForGroupCleanup 让用户选择要移除的现有组，然后加入目标组
+func (ops *LDAPOperations) promptForGroupCleanup(client *ldap.LDAPClient, userDN string, groupDN string, searchDN string, groups []string, setPrimary bool) {
+	groupChecks := widget.NewCheckGroup(groups, nil)
+	removeAllCheck := widget.NewCheck("移除所有现有组", func(checked bool) {
+		if checked {
+			groupChecks.Disable()
+		} else {
+			groupChecks.Enable()
+		}
+	})
+	content := container.NewVBox(
+		widget.NewLabel("用户当前所在的组，勾选需要移除的组："),
+		groupChecks,
+		removeAllCheck,
+	)
+
+	dialog.ShowCustomConfirm("清理现有组", "执行", "跳过清理", content, func(cleanup bool) {
+		if cleanup && removeAllCheck.Checked {
+			ops.confirmRemoveAllGroups(client, userDN, groupDN, searchDN, setPrimary)
+			return
+		}
+
+		ops.runAsync(func() {
+			if err := client.UseSharedConnection(); err != nil {
+				ops.logger.Error("连接失败：%v", err)
+				dialog.ShowError(fmt.Errorf("连接失败: %v", err), ops.window)
+				return
+			}
+			defer client.ReleaseSharedConnection()
+
+			var removed []string
+			if cleanup {
+				for _, g := range groupChecks.Selected {
+					ops.logger.Info("正在从组 %s 移除用户", g)
+					if err := client.RemoveUserFromGroup(userDN, g); err != nil {
+						ops.logger.Error("从组 %s 移除用户失败：%v", g, err)
+						continue
+					}
+					removed = append(removed, g)
+				}
+			} else {
+				ops.logger.Debug("用户跳过现有组清理")
+			}
+			ops.logGroupChanges(groups, removed)
+			ops.addUserToGroup(client, userDN, groupDN, setPrimary)
+		})
+	}, ops.window)
+}
+
+// confirmRemoveAllGroups 先列出将被移除的组（预演），确认后再移除并加入目标组
+func (ops *LDAPOperations) confirmRemoveAllGroups(client *ldap.LDAPClient, userDN string, groupDN string, searchDN string, setPrimary bool) {
+	ops.runAsync(func() {
+		affected, err := client.RemoveUserFromAllGroups(userDN, searchDN, true)
+		if err != nil {
+			ops.logger.Error("查询将移除的组失败：%v", err)
+			dialog.ShowError(fmt.Errorf("查询现有组失败: %v", err), ops.window)
+			return
+		}
+		if len(affected) == 0 {
+			ops.logger.Info("没有需要移除的组")
+			ops.addUserToGroup(client, userDN, groupDN, setPrimary)
+			return
+		}
+
+		dialog.ShowConfirm("确认移除组",
+			fmt.Sprintf("将从以下 %d 个组中移除用户：\n%s\n\n是否继续？", len(affected), strings.Join(affected, "\n")),
+			func(confirmed bool) {
+				ops.runAsync(func() {
+					if err := client.UseSharedConnection(); err != nil {
+						ops.logger.Error("连接失败：%v", err)
+						dialog.ShowError(fmt.Errorf("连接失败: %v", err), ops.window)
+						return
+					}
+					defer client.ReleaseSharedConnection()
+
+					if confirmed {
+						ops.logger.Info("正在移除用户的所有现有组...")
+						removed, err := client.RemoveUserFromAllGroups(userDN, searchDN, false)
+						if err != nil {
+							ops.logger.Error("移除现有组失败：" + err.Error())
+							dialog.ShowError(fmt.Errorf("移除现有组失败: %v", err), ops.window)
+							return
+						}
+						ops.logger.Info("已移除 %d 个现有组", len(removed))
+						ops.logGroupChanges(affected, removed)
+					} else {
+						ops.logger.Debug("用户取消移除现有组")
+					}
+					ops.addUserToGroup(client, userDN, groupDN, setPrimary)
+				})
+			}, ops.window)
+	})
+}
+
+// logGroupChanges 记录清理现有组后被移除和保留的组
+func (ops *LDAPOperations) logGroupChanges(groups []string, removed []string) {
+	var retained []string
+	for _, g := range groups {
+		kept := true
+		for _, r := range removed {
+			if strings.EqualFold(g, r) {
+				kept = false
+				break
+			}
+		}
+		if kept {
+			retained = append(retained, g)
+		}
+	}
+
+	lines := []string{"现有组处理结果："}
+	for _, g := range removed {
+		lines = append(lines, "  [已移除] "+g)
+	}
+	for _, g := range retained {
+		lines = append(lines, "  [已保留] "+g)
+	}
+	ops.logger.Info("%s", strings.Join(lines, "\n"))
+}
+
+// addUserToGroup 添加用户到组并记录结果，setPrimary为true时同时设为主组
+func (ops *LDAPOperations) addUserToGroup(client *ldap.LDAPClient, userDN string, groupDN string, setPrimary bool) {
+	if err := client.AddUserToGroup(userDN, groupDN); err != nil {
+		ops.logger.Error("添加用户到组失败：" + err.Error())
+		dialog.ShowError(err, ops.window)
+		return
+	}
+	ops.logger.Info("用户成功添加到组")
+
+	if !setPrimary {
+		return
+	}
+	if err := client.SetPrimaryGroup(userDN, groupDN); err != nil {
+		ops.logger.Error("设为主组失败：%v", err)
+		dialog.ShowError(err, ops.window)
+	}
+}
+
+// copyDNToClipboard 将规范化后的DN复制到剪贴板
+func (ops *LDAPOperations) copyDNToClipboard(dn string) {
+	normalized := ldap.NormalizeDN(dn)
+	ops.window.Clipboard().SetContent(normalized)
+	ops.logger.Info("DN已复制到剪贴板：%s", normalized)
+}
+
 // PromptForPasswordUpdate 提示是否更新密码
-func (ops *LDAPOperations) PromptForPasswordUpdate(userDN string, password string) {
+func (ops *LDAPOperations) PromptForPasswordUpdate(client *ldap.LDAPClient, userDN string, password string) {
 	ops.logger.Debug("提示更新密码，用户DN: " + userDN)
-	dialog.ShowConfirm("更新密码",
-		"是否要更新用户密码？",
+
+	const (
+		methodReplace = "替换密码属性"
+		methodExOp    = "密码修改扩展操作（RFC 3062）"
+	)
+	methodRadio := widget.NewRadioGroup([]string{methodReplace, methodExOp}, nil)
+	oldPasswordEntry := widget.NewPasswordEntry()
+	oldPasswordEntry.SetPlaceHolder("旧密码（可选，填写后以用户本人身份修改）")
+	methodRadio.OnChanged = func(selected string) {
+		if selected == methodExOp {
+			oldPasswordEntry.Show()
+		} else {
+			oldPasswordEntry.Hide()
+		}
+	}
+	// OpenLDAP默认使用扩展操作
+	if client.GetUserTemplate().IsActiveDirectory() {
+		methodRadio.SetSelected(methodReplace)
+	} else {
+		methodRadio.SetSelected(methodExOp)
+	}
+
+	content := container.NewVBox(
+		widget.NewLabel("是否要更新用户密码？"),
+		methodRadio,
+		oldPasswordEntry,
+	)
+
+	dialog.ShowCustomConfirm("更新密码", "更新", "取消", content,
 		func(updatePassword bool) {
-			if updatePassword {
-				ops.logger.Debug("用户确认更新密码")
-				ops.logger.Info("开始更新用户密码...")
-				if err := ops.client.UpdateUserPassword(userDN, password); err != nil {
+			if !updatePassword {
+				ops.logger.Debug("用户取消密码更新")
+				return
+			}
+			ops.logger.Debug("用户确认更新密码，方式：%s", methodRadio.Selected)
+			ops.logger.Info("开始更新用户密码...")
+			oldPassword := oldPasswordEntry.Text
+			useExOp := methodRadio.Selected == methodExOp
+			ops.runAsync(func() {
+				var generated string
+				var err error
+				if useExOp {
+					generated, err = client.ChangePasswordExOp(userDN, oldPassword, password)
+				} else {
+					err = client.UpdateUserPassword(userDN, password)
+				}
+				if err != nil {
 					ops.logger.Error("密码更新失败：" + err.Error())
 					dialog.ShowError(err, ops.window)
 					return
 				}
 				ops.logger.Info("用户密码更新成功")
-			} else {
-				ops.logger.Debug("用户取消密码更新")
-			}
+				if generated != "" {
+					ops.showGeneratedPassword(userDN, generated)
+				}
+			})
 		}, ops.window)
 }
 
+// showGeneratedPassword 显示服务器生成的密码，密码只在此对话框中显示一次，不写入日志
+func (ops *LDAPOperations) showGeneratedPassword(userDN string, password string) {
+	passwordEntry := widget.NewEntry()
+	passwordEntry.SetText(password)
+	copyButton := widget.NewButton("复制", func() {
+		ops.window.Clipboard().SetContent(password)
+		ops.logger.Info("已复制生成的密码到剪贴板")
+	})
+	content := container.NewVBox(
+		widget.NewLabel("服务器已为 "+userDN+" 生成新密码，关闭后不再显示："),
+		container.NewBorder(nil, nil, nil, copyButton, passwordEntry),
+	)
+	dialog.ShowCustom("生成的密码", "关闭", content, ops.window)
+}
+
 // SetSSLMode 设置SSL模式
 func (ops *LDAPOperations) SetSSLMode(isSSL bool) {
 	ops.isSSLMode = isSSL
 }
 
-// SetClient 设置LDAP客户端
+// SetForcePasswordChange 设置创建用户后是否要求下次登录修改密码
+func (ops *LDAPOperations) SetForcePasswordChange(force bool) {
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+	ops.forcePasswordChange = force
+}
+
+// accountExpiryLabel 返回账户有效期的显示文字
+func accountExpiryLabel(expiry time.Time) string {
+	if expiry.IsZero() {
+		return "有效期：永不过期"
+	}
+	return "有效期至：" + expiry.AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+// ShowAccountExpiryDialog 设置新建用户的账户有效期，onChanged接收新的显示文字
+func (ops *LDAPOperations) ShowAccountExpiryDialog(onChanged func(label string)) {
+	dateEntry := widget.NewEntry()
+	dateEntry.SetPlaceHolder("2006-01-02")
+	neverCheck := widget.NewCheck("永不过期", func(checked bool) {
+		if checked {
+			dateEntry.Disable()
+		} else {
+			dateEntry.Enable()
+		}
+	})
+	if ops.accountExpiry.IsZero() {
+		dateEntry.SetText(time.Now().AddDate(0, 0, 30).Format("2006-01-02"))
+		neverCheck.SetChecked(true)
+	} else {
+		dateEntry.SetText(ops.accountExpiry.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("", neverCheck),
+		widget.NewFormItem("有效期至", dateEntry),
+	}
+	dialog.ShowForm("账户有效期", "确定", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		var expiry time.Time
+		if !neverCheck.Checked {
+			day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(dateEntry.Text), time.Local)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("日期格式无效，请使用YYYY-MM-DD: %s", dateEntry.Text), ops.window)
+				return
+			}
+			if !day.After(time.Now().AddDate(0, 0, -1)) {
+				dialog.ShowError(fmt.Errorf("有效期不能早于今天"), ops.window)
+				return
+			}
+			// 账户在所选日期当天结束时过期
+			expiry = day.AddDate(0, 0, 1)
+		}
+		ops.mu.Lock()
+		ops.accountExpiry = expiry
+		ops.mu.Unlock()
+		ops.logger.Info("新建用户%s", accountExpiryLabel(expiry))
+		onChanged(accountExpiryLabel(expiry))
+	}, ops.window)
+}
+
+// SetUserTemplate 根据名称设置用户创建模板
+func (ops *LDAPOperations) SetUserTemplate(name string) {
+	template, ok := ldap.UserTemplateByName(name)
+	if !ok {
+		ops.logger.Warn("未知的目录类型：%s，使用默认模板", name)
+		template = ldap.DefaultUserTemplate()
+	}
+	ops.mu.Lock()
+	ops.userTemplate = template
+	ops.mu.Unlock()
+	ops.logger.Info("目录类型已切换为：%s", template.Name)
+
+	// 过滤器列表随目录类型切换
+	ldap.SetFilterDirectoryType(template.DirectoryType)
+	if ops.filterSelect != nil {
+		ops.filterSelect.ReloadFilters()
+	}
+}
+
+// detectDirectoryType 检测目录类型，内置过滤器随检测结果切换后重新加载过滤器选择框
+func (ops *LDAPOperations) detectDirectoryType(client *ldap.LDAPClient) (string, error) {
+	directoryType, err := client.DetectDirectoryType()
+	if err == nil && ops.filterSelect != nil {
+		ops.filterSelect.ReloadFilters()
+	}
+	return directoryType, err
+}
+
+// SetConfig 设置应用程序配置，用于保存自定义设置
+func (ops *LDAPOperations) SetConfig(cfg *config.AppConfig) {
+	ops.config = cfg
+	if cfg != nil && cfg.Advanced != nil {
+		ops.applyAdvancedSettings(*cfg.Advanced)
+	}
+}
+
+// SetSearchDNEntry 设置搜索DN输入框，用于回填自动检测的基准DN
+func (ops *LDAPOperations) SetSearchDNEntry(entry *widget.Entry) {
+	ops.searchDNEntry = entry
+}
+
+// resolveSearchDN 搜索DN为空时自动检测基准DN并回填到输入框，全局编录模式下始终使用林根
+func (ops *LDAPOperations) resolveSearchDN(client *ldap.LDAPClient, searchDN string) (string, error) {
+	if client.IsGlobalCatalog() {
+		forestRoot, err := client.ForestRootDN()
+		if err != nil {
+			return "", err
+		}
+		ops.logger.Info("全局编录模式，从林根搜索：%s", forestRoot)
+		return forestRoot, nil
+	}
+	if searchDN != "" {
+		return searchDN, nil
+	}
+	ops.logger.Info("搜索DN为空，正在自动检测基准DN...")
+	baseDN, err := client.DetectBaseDN()
+	if err != nil {
+		return "", err
+	}
+	ops.logger.Info("检测到基准DN：%s", baseDN)
+	if ops.searchDNEntry != nil {
+		ops.searchDNEntry.SetText(baseDN)
+	}
+	return baseDN, nil
+}
+
+// SetClient 设置LDAP客户端，替换时关闭之前客户端的连接
+// 之前的客户端可能仍被其他操作或未关闭的对话框使用，其共享连接在最后一个使用者释放后才关闭
 func (ops *LDAPOperations) SetClient(client *ldap.LDAPClient) {
+	ops.mu.Lock()
+	previous := ops.client
 	ops.client = client
+	keepAliveClient := ops.keepAliveClient
+	ops.mu.Unlock()
+
+	if previous != nil && previous != client && previous != keepAliveClient {
+		previous.Shutdown()
+	}
+}
+
+// Close 取消进行中的操作，停止保持连接并关闭当前客户端的连接，在窗口关闭时调用
+func (ops *LDAPOperations) Close() {
+	ops.busy.mu.Lock()
+	cancel := ops.cancel
+	ops.busy.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+
+	ops.stopKeepAlive()
+	ops.mu.Lock()
+	client := ops.client
+	ops.client = nil
+	ops.mu.Unlock()
+	if client != nil {
+		client.Shutdown()
+	}
+	ops.logger.Debug("已关闭所有LDAP连接")
 }
 
 // SetDebugMode 设置调试模式
 func (ops *LDAPOperations) SetDebugMode(debug bool) {
+	ops.mu.Lock()
 	ops.debugMode = debug
+	client := ops.client
+	ops.mu.Unlock()
 	if ops.logger != nil {
 		ops.logger.SetDebugMode(debug)
 	}
-	if ops.client != nil {
-		ops.client.SetDebugMode(debug)
+	if client != nil {
+		client.SetDebugMode(debug)
 	}
 }
 
-// HandlePing 处理ping测试
-func (ops *LDAPOperations) HandlePing(host string) {
-	ops.logger.Info("开始Ping测试")
+// isDebugMode 返回是否开启调试模式，供后台操作创建客户端时使用
+func (ops *LDAPOperations) isDebugMode() bool {
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+	return ops.debugMode
+}
+
+// HandlePing 处理连接测试（TCP连接延迟）
+func (ops *LDAPOperations) HandlePing(host string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Info("开始连接测试")
 
-	if host == "" {
-		ops.logger.Warn("服务器地址为空")
-		ops.logger.Error("请输入服务器地址")
+	if !ops.validateInputs(FieldDomain, FieldPort) {
 		return
 	}
-	ops.logger.Debug("Ping目标主机：%s", host)
+	ops.logger.Debug("连接测试目标主机：%s", host)
 
-	// 创建ping命令
-	cmd := exec.Command("cmd", "/c", "chcp 65001 >nul && ping -n 4 "+host)
-	ops.logger.Debug("执行命令：%s", cmd.String())
-	output, err := cmd.CombinedOutput()
+	client, err := ops.createLDAPClient(host, "", "", portEntry, isSSL)
 	if err != nil {
-		ops.logger.Error("ping命令执行失败：%s", err.Error())
+		dialog.ShowError(err, ops.window)
 		return
 	}
 
-	// 解析ping输出
-	outputStr := string(output)
-	ops.logger.Debug("Ping输出：\n%s", outputStr)
-
-	// 在Windows中，平均延迟信息位于包含"平均"的行中
-	lines := strings.Split(outputStr, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "平均") || strings.Contains(line, "Average") {
-			ops.logger.Debug("找到平均延迟信息：%s", strings.TrimSpace(line))
-			ops.logger.Info("ping测试完成，结果: %s", strings.TrimSpace(line))
+	ops.runOperation("连接测试", func() {
+		latency, err := client.Reachable()
+		if err != nil {
+			ops.logger.Error("连接测试失败：%v", err)
 			return
 		}
-	}
-
-	// 如果没有找到平均时间行，至少显示最后一行结果
-	if len(lines) > 0 {
-		lastLine := lines[len(lines)-1]
-		if lastLine == "" && len(lines) > 1 {
-			lastLine = lines[len(lines)-2]
-		}
-		ops.logger.Debug("未找到平均延迟信息，使用最后一行：%s", strings.TrimSpace(lastLine))
-		ops.logger.Info("ping测试完成: %s", strings.TrimSpace(lastLine))
-	} else {
-		ops.logger.Debug("ping输出为空")
-		ops.logger.Warn("ping测试完成，但无法解析结果")
-	}
+		ops.logger.Info("连接测试完成，%s:%d 平均连接延迟: %v", host, client.Port, latency.Round(time.Millisecond))
+	})
 }
 
 // createLDAPClient 创建LDAP客户端
@@ -273,6 +737,21 @@ func (ops *LDAPOperations) createLDAPClient(domain string, bindDN string, bindPa
 		return nil, err
 	}
 
+	// 保持连接时复用连接参数相同的客户端
+	key := fmt.Sprintf("%s|%d|%s|%s|%v", domain, port, bindDN, bindPassword, isSSL)
+	ops.mu.Lock()
+	keepAlive := ops.keepAlive
+	keepAliveClient := ops.keepAliveClient
+	if ops.keepAliveKey != key {
+		keepAliveClient = nil
+	}
+	ops.mu.Unlock()
+	if keepAlive && keepAliveClient != nil {
+		ops.applyClientSettings(keepAliveClient)
+		ops.logger.Debug("复用保持连接的LDAP客户端，目标主机：%s", domain)
+		return keepAliveClient, nil
+	}
+
 	client := ldap.NewLDAPClient(
 		domain,
 		port,
@@ -281,115 +760,467 @@ func (ops *LDAPOperations) createLDAPClient(domain string, bindDN string, bindPa
 		ops.logger,
 		ops.updateStatus,
 		isSSL,
-		ops.debugMode,
+		ops.isDebugMode(),
 	)
+	ops.applyClientSettings(client)
 	ops.logger.Debug("创建LDAP客户端，目标主机：%s", domain)
+
+	if keepAlive {
+		ops.stopKeepAlive()
+		client.StartKeepAlive(ldap.DefaultKeepAliveInterval)
+		ops.mu.Lock()
+		ops.keepAliveClient = client
+		ops.keepAliveKey = key
+		ops.mu.Unlock()
+	}
 	return client, nil
 }
 
-// HandlePortTest 处理端口测试
-func (ops *LDAPOperations) HandlePortTest(domain string, portEntry *CustomPortEntry, isSSL bool) {
-	ops.logger.Debug("开始端口和服务测试")
-	client, err := ops.createLDAPClient(domain, "", "", portEntry, isSSL)
-	if err != nil {
-		dialog.ShowError(err, ops.window)
+// SetKeepAlive 设置是否保持连接，启用后下一次操作建立的连接会在后台定期检测
+func (ops *LDAPOperations) SetKeepAlive(enabled bool) {
+	ops.mu.Lock()
+	ops.keepAlive = enabled
+	ops.mu.Unlock()
+	if enabled {
+		ops.logger.Info("已启用保持连接，检测间隔：%v", ldap.DefaultKeepAliveInterval)
 		return
 	}
+	ops.stopKeepAlive()
+	ops.logger.Info("已关闭保持连接")
+}
 
-	protocol := "LDAP"
-	if isSSL {
-		protocol = "LDAPS"
+// stopKeepAlive 停止当前的保持连接并关闭其连接
+func (ops *LDAPOperations) stopKeepAlive() {
+	ops.mu.Lock()
+	client := ops.keepAliveClient
+	ops.keepAliveClient = nil
+	ops.keepAliveKey = ""
+	ops.mu.Unlock()
+	if client != nil {
+		client.StopKeepAlive()
 	}
+}
 
-	ops.logger.Info("开始测试 %s 服务，端口：%d", protocol, client.Port)
-
-	// 第一步：测试端口
-	ops.logger.Debug("第1步：测试 %s 端口 %d 是否开放", protocol, client.Port)
-	if client.IsPortOpen() {
-		ops.logger.Info("%s 端口 %d 已开放", protocol, client.Port)
+// applyClientSettings 将界面上的模板、搜索范围和取消上下文应用到客户端
+func (ops *LDAPOperations) applyClientSettings(client *ldap.LDAPClient) {
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+	client.SetUserTemplate(ops.userTemplate)
+	client.SetSearchScope(ops.searchScope)
+	client.SetSearchLimits(ops.sizeLimit, ops.timeLimit)
+	client.SetBindMethod(ops.bindMethod)
+	client.SetFollowReferrals(ops.followReferrals)
+	client.SetGlobalCatalog(ops.globalCatalog)
+	client.SetConfig(ops.ldapConfig)
+	client.SetReadOnly(ops.readOnly)
+	client.SetContainerConfirm(ops.confirmContainerCreation)
+	client.SetProtocolDebug(ops.protocolDebug)
+	client.SetContext(ops.operationContext())
+}
 
-		// 第二步：测试服务
-		ops.logger.Debug("第2步：测试 %s 服务连接状态", protocol)
+// confirmContainerCreation 列出将要自动创建的容器并等待用户确认，操作被取消时视为拒绝
+// 在后台操作中调用，等待期间不阻塞界面
+func (ops *LDAPOperations) confirmContainerCreation(missing []string) bool {
+	ops.logger.Info("%s", strings.Join(append([]string{"以下容器不存在，需要创建："}, missing...), "\n"))
+	answer := make(chan bool, 1)
+	dialog.ShowConfirm("创建缺失的容器",
+		"以下容器不存在，将由上至下依次创建：\n\n"+strings.Join(missing, "\n")+"\n\n是否继续？拒绝将中止当前操作。",
+		func(confirmed bool) {
+			answer <- confirmed
+		}, ops.window)
 
-		conn, err := client.TestServiceConnection()
-		if err != nil {
-			ops.logger.Info("服务测试结果: %s 端口已开放，但服务连接异常", protocol)
-		} else {
-			conn.Close() // 确保连接关闭
-			ops.logger.Info("服务测试结果: %s 端口已开放，服务正常运行", protocol)
+	select {
+	case confirmed := <-answer:
+		if !confirmed {
+			ops.logger.Warn("已拒绝创建缺失的容器，操作中止")
 		}
-	} else {
-		ops.logger.Warn("%s 端口 %d 未开放", protocol, client.Port)
-		ops.logger.Info("服务测试结果: %s 端口未开放，无法测试服务", protocol)
+		return confirmed
+	case <-ops.operationContext().Done():
+		return false
 	}
 }
 
-// HandleAdminTest 处理管理员测试
-func (ops *LDAPOperations) HandleAdminTest(domain string, adminDN string, adminPassword string, portEntry *CustomPortEntry, isSSL bool) {
-	ops.logger.Debug("开始测试管理员凭证")
-
-	// 验证管理员密码不为空
-	if adminDN == "" || adminPassword == "" {
-		ops.logger.Error("验证失败：管理员DN或密码为空")
-		dialog.ShowError(fmt.Errorf("管理员DN和密码不能为空"), ops.window)
-		return
+// SetSearchScope 根据名称设置搜索范围（base/one/sub）
+func (ops *LDAPOperations) SetSearchScope(name string) {
+	scope, ok := ldap.ParseSearchScope(name)
+	if !ok {
+		ops.logger.Warn("未知的搜索范围：%s，使用sub", name)
+		scope = ldap.DefaultSearchScope
 	}
+	ops.mu.Lock()
+	ops.searchScope = scope
+	ops.mu.Unlock()
+	ops.logger.Info("搜索范围已切换为：%s", ldap.SearchScopeName(scope))
+}
 
-	// 验证服务器地址不为空
-	if domain == "" {
-		ops.logger.Error("验证失败：服务器地址为空")
-		dialog.ShowError(fmt.Errorf("请输入服务器地址"), ops.window)
-		return
+// SetGroupScope 根据名称设置创建或重新授权组时使用的组范围
+func (ops *LDAPOperations) SetGroupScope(name string) {
+	scope, ok := ldap.ParseGroupScope(name)
+	if !ok {
+		ops.logger.Warn("未知的组范围：%s，使用%s", name, ldap.GroupScopeName(ldap.DefaultGroupScope))
+		scope = ldap.DefaultGroupScope
 	}
+	ops.mu.Lock()
+	ops.groupScope = scope
+	ops.mu.Unlock()
+	ops.logger.Info("组范围已切换为：%s（groupType=%s）", ldap.GroupScopeName(scope), ops.groupTypeValue())
+}
 
-	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
-	if err != nil {
-		dialog.ShowError(err, ops.window)
-		return
+// SetSecurityGroup 设置创建或重新授权的组是安全组还是通讯组
+func (ops *LDAPOperations) SetSecurityGroup(security bool) {
+	ops.mu.Lock()
+	ops.securityGroup = security
+	ops.mu.Unlock()
+	ops.logger.Info("安全组：%v（groupType=%s）", security, ops.groupTypeValue())
+}
+
+// groupTypeValue 返回当前选择的组范围和类型对应的groupType属性值
+func (ops *LDAPOperations) groupTypeValue() string {
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+	return ldap.GroupType(ops.groupScope, ops.securityGroup)
+}
+
+// SetBindMethod 设置管理员连接使用的绑定方式
+func (ops *LDAPOperations) SetBindMethod(method string) {
+	ops.mu.Lock()
+	ops.bindMethod = method
+	ops.mu.Unlock()
+	ops.logger.Info("绑定方式已切换为：%s", method)
+	if method == ldap.BindMethodDigestMD5 {
+		ops.logger.Info("DIGEST-MD5请在Admin DN中填写登录名（如sAMAccountName），而不是DN")
 	}
+}
 
-	protocol := "LDAP"
-	if isSSL {
-		protocol = "LDAPS"
+// ShowClientCertificateDialog 设置SASL EXTERNAL使用的客户端证书
+func (ops *LDAPOperations) ShowClientCertificateDialog() {
+	certEntry := widget.NewEntry()
+	certEntry.SetPlaceHolder("client.crt（PEM）")
+	keyEntry := widget.NewEntry()
+	keyEntry.SetPlaceHolder("client.key（PEM）")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("证书文件", certEntry),
+		widget.NewFormItem("私钥文件", keyEntry),
 	}
 
-	ops.logger.Debug("测试 %s 服务，端口：%d", protocol, client.Port)
+	dialog.ShowForm("客户端证书", "加载", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		certFile := strings.TrimSpace(certEntry.Text)
+		keyFile := strings.TrimSpace(keyEntry.Text)
+		if certFile == "" || keyFile == "" {
+			dialog.ShowError(fmt.Errorf("证书文件和私钥文件不能为空"), ops.window)
+			return
+		}
+		if err := ldap.LoadClientCertificate(certFile, keyFile); err != nil {
+			ops.logger.Error("%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		ops.logger.Info("已加载客户端证书：%s", certFile)
+	}, ops.window)
+}
+
+// ShowCAFileDialog 选择或清除用于验证服务器证书的CA文件，onChanged报告是否已配置CA
+func (ops *LDAPOperations) ShowCAFileDialog(onChanged func(configured bool)) {
+	if ldap.CAFile != "" {
+		dialog.ShowConfirm("CA证书", "当前CA证书："+ldap.CAFile+"\n\n是否清除？", func(clear bool) {
+			if !clear {
+				return
+			}
+			ldap.ClearCAFile()
+			ops.logger.Info("已清除CA证书，使用系统证书库验证")
+			onChanged(false)
+		}, ops.window)
+		return
+	}
 
-	// 分步骤测试
-	if client.IsPortOpen() {
-		ops.logger.Debug("%s 端口 %d 已开放", protocol, client.Port)
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			ops.logger.Error("选择CA证书失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		if reader == nil {
+			return // 用户取消
+		}
+		reader.Close()
 
-		if client.TestLDAPService() {
-			ops.logger.Info("%s 服务正常运行，管理员认证成功", protocol)
-		} else {
-			ops.logger.Warn("%s 服务连接异常或管理员认证失败", protocol)
+		path := reader.URI().Path()
+		if err := ldap.LoadCAFile(path); err != nil {
+			ops.logger.Error("%v", err)
+			dialog.ShowError(err, ops.window)
+			return
 		}
-	} else {
-		ops.logger.Warn("%s 端口 %d 未开放", protocol, client.Port)
+		ops.logger.Info("已加载CA证书：%s，将验证服务器证书", path)
+		onChanged(true)
+	}, ops.window)
+}
+
+// SetFollowReferrals 设置搜索返回引用时是否跟随
+func (ops *LDAPOperations) SetFollowReferrals(follow bool) {
+	ops.mu.Lock()
+	ops.followReferrals = follow
+	ops.mu.Unlock()
+	ops.logger.Info("跟随引用：%v", follow)
+}
+
+// SetGlobalCatalog 设置是否连接全局编录，启用后搜索从林根开始以查找所有域中的对象
+func (ops *LDAPOperations) SetGlobalCatalog(enabled bool) {
+	ops.mu.Lock()
+	ops.globalCatalog = enabled
+	ops.mu.Unlock()
+	if !enabled {
+		ops.logger.Info("已关闭全局编录模式")
+		return
 	}
+	ops.logger.Info("已开启全局编录模式：搜索从林根开始，覆盖林中所有域")
+	ops.logger.Warn("全局编录只读且只包含部分属性，创建、修改等写操作请连接域控制器")
 }
 
-// HandleGroupCheck 处理权限组检查
-func (ops *LDAPOperations) HandleGroupCheck(domain string, adminDN string, adminPassword string, groupDN string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
-	ops.logger.Debug("开始检查权限组操作")
-	port, err := portEntry.GetPort()
-	if err != nil {
-		ops.logger.Error("获取端口失败：%v", err)
-		dialog.ShowError(err, ops.window)
+// SetProtocolDebug 设置是否输出LDAP协议报文，报文作为调试日志输出
+func (ops *LDAPOperations) SetProtocolDebug(enabled bool) {
+	ops.mu.Lock()
+	ops.protocolDebug = enabled
+	ops.mu.Unlock()
+	if !enabled {
+		ops.logger.Info("已关闭协议调试")
 		return
 	}
-	ops.logger.Debug("使用端口：%d，SSL模式：%v", port, isSSL)
+	ops.logger.Info("已开启协议调试，之后建立的连接会输出请求和响应报文")
+	if !ops.debugMode {
+		ops.logger.Warn("协议报文作为调试日志输出，请同时开启调试模式")
+	}
+}
 
-	client := ldap.NewLDAPClient(
-		domain,
+// SetSearchLimits 根据输入设置搜索的最大结果数和超时（秒），留空表示不限制
+func (ops *LDAPOperations) SetSearchLimits(sizeText string, timeText string) error {
+	sizeLimit, err := parseLimit(sizeText)
+	if err != nil {
+		return fmt.Errorf("最大结果数无效: %v", err)
+	}
+	timeLimit, err := parseLimit(timeText)
+	if err != nil {
+		return fmt.Errorf("超时时间无效: %v", err)
+	}
+	ops.mu.Lock()
+	ops.sizeLimit = sizeLimit
+	ops.timeLimit = timeLimit
+	ops.mu.Unlock()
+	ops.logger.Debug("搜索限制：最大结果数=%d，超时=%d秒", sizeLimit, timeLimit)
+	return nil
+}
+
+// parseLimit 解析非负整数限制，空字符串表示0（不限制）
+func parseLimit(text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("请输入非负整数：%s", text)
+	}
+	return value, nil
+}
+
+// HandlePortTest 处理端口测试
+func (ops *LDAPOperations) HandlePortTest(domain string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始端口和服务测试")
+	client, err := ops.createLDAPClient(domain, "", "", portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("端口测试", func() {
+		protocol := "LDAP"
+		if isSSL {
+			protocol = "LDAPS"
+		}
+
+		ops.logger.Info("开始测试 %s 服务，端口：%d", protocol, client.Port)
+
+		// 第一步：测试端口
+		ops.logger.Debug("第1步：测试 %s 端口 %d 是否开放", protocol, client.Port)
+		if client.IsPortOpen() {
+			ops.logger.Info("%s 端口 %d 已开放", protocol, client.Port)
+
+			// 第二步：测试服务
+			ops.logger.Debug("第2步：测试 %s 服务连接状态", protocol)
+
+			conn, err := client.TestServiceConnection()
+			if err != nil {
+				ops.logger.Info("服务测试结果: %s 端口已开放，但服务连接异常", protocol)
+			} else {
+				conn.Close() // 确保连接关闭
+				ops.logger.Info("服务测试结果: %s 端口已开放，服务正常运行", protocol)
+			}
+		} else {
+			ops.logger.Warn("%s 端口 %d 未开放", protocol, client.Port)
+			ops.logger.Info("服务测试结果: %s 端口未开放，无法测试服务", protocol)
+		}
+	})
+}
+
+// HandleProbePorts 同时探测LDAP、LDAPS和可选的全局编录端口，以矩阵形式显示哪些端口开放及TLS握手结果
+func (ops *LDAPOperations) HandleProbePorts(domain string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始探测端口")
+
+	if !ops.validateInputs(FieldDomain) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, "", "", portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	includeGCCheck := widget.NewCheck(fmt.Sprintf("包含全局编录端口（%d/%d）", ldap.GlobalCatalogPort, ldap.GlobalCatalogSSLPort), nil)
+	items := []*widget.FormItem{
+		widget.NewFormItem("", includeGCCheck),
+	}
+
+	dialog.ShowForm("探测端口", "探测", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		ops.runOperation("探测端口", func() {
+			result := client.ProbePorts(includeGCCheck.Checked)
+			report := strings.Join(append([]string{"端口探测结果（" + result.Host + "）："}, result.Summary()...), "\n")
+			if len(result.OpenPorts()) == 0 {
+				ops.logger.Warn("%s", report)
+			} else {
+				ops.logger.Info("%s", report)
+			}
+			ops.showPortProbeResult(result)
+		})
+	}, ops.window)
+}
+
+// showPortProbeResult 以表格形式显示端口探测结果
+func (ops *LDAPOperations) showPortProbeResult(result *ldap.PortProbeResult) {
+	cells := []fyne.CanvasObject{
+		widget.NewLabelWithStyle("端口", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("服务", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("TCP", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("TLS握手", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	}
+	for _, probe := range result.Probes {
+		open := "关闭"
+		if probe.Open {
+			open = "开放"
+		}
+		tlsLabel := widget.NewLabel(probe.TLSStatus())
+		tlsLabel.Wrapping = fyne.TextWrapWord
+		cells = append(cells,
+			widget.NewLabel(strconv.Itoa(probe.Port)),
+			widget.NewLabel(probe.Name),
+			widget.NewLabel(open),
+			tlsLabel,
+		)
+	}
+	dialog.ShowCustom("端口探测结果："+result.Host, "关闭", container.NewGridWithColumns(4, cells...), ops.window)
+}
+
+// HandleAdminTest 处理管理员测试
+func (ops *LDAPOperations) HandleAdminTest(domain string, adminDN string, adminPassword string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始测试管理员凭证")
+
+	// 验证服务器地址和管理员凭据（SASL EXTERNAL使用客户端证书，不需要凭据）
+	bindMethod := ops.bindMethod
+	required := []string{FieldDomain, FieldPort}
+	if bindMethod != ldap.BindMethodExternal {
+		required = append(required, FieldAdminDN, FieldAdminPassword)
+	}
+	if !ops.validateInputs(required...) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("管理员测试", func() {
+		protocol := "LDAP"
+		if isSSL {
+			protocol = "LDAPS"
+		}
+
+		ops.logger.Debug("测试 %s 服务，端口：%d", protocol, client.Port)
+
+		// 分阶段诊断，明确是哪一层出现问题
+		diagnosis := client.Diagnose()
+		report := strings.Join(append([]string{protocol + " 连接诊断："}, diagnosis.Summary()...), "\n")
+		if !diagnosis.Passed() {
+			ops.logger.Warn("%s", report)
+			return
+		}
+		ops.logger.Info("%s", report)
+
+		if bindMethod != ldap.BindMethodSimple {
+			if mechanisms, err := client.SupportedSASLMechanisms(); err == nil {
+				ops.logger.Info("服务器支持的SASL机制：%s", strings.Join(mechanisms, ", "))
+			} else {
+				ops.logger.Warn("无法获取服务器支持的SASL机制：%v", err)
+			}
+		}
+	})
+}
+
+// validateDN 校验输入的DN，无效时记录日志并提示用户
+func (ops *LDAPOperations) validateDN(label string, dn string) bool {
+	if dn == "" {
+		ops.logger.Error("验证失败：%s不能为空", label)
+		dialog.ShowError(fmt.Errorf("%s不能为空", label), ops.window)
+		return false
+	}
+	if err := ldap.ValidateDN(dn); err != nil {
+		ops.logger.Error("验证失败：%s %s：%v", label, dn, err)
+		dialog.ShowError(fmt.Errorf("%s: %v", label, err), ops.window)
+		return false
+	}
+	return true
+}
+
+// HandleGroupCheck 处理权限组检查
+func (ops *LDAPOperations) HandleGroupCheck(domain string, adminDN string, adminPassword string, groupDN string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldGroupDN) {
+		return
+	}
+	ops.runOperation("检查权限组", func() {
+		ops.groupCheck(domain, adminDN, adminPassword, groupDN, searchDN, portEntry, isSSL)
+	})
+}
+
+// groupCheck 执行权限组检查流程
+func (ops *LDAPOperations) groupCheck(domain string, adminDN string, adminPassword string, groupDN string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始检查权限组操作")
+	port, err := portEntry.GetPort()
+	if err != nil {
+		ops.logger.Error("获取端口失败：%v", err)
+		dialog.ShowError(err, ops.window)
+		return
+	}
+	ops.logger.Debug("使用端口：%d，SSL模式：%v", port, isSSL)
+
+	client := ldap.NewLDAPClient(
+		domain,
 		port,
 		adminDN,
 		adminPassword,
 		ops.logger,
 		ops.updateStatus,
 		isSSL,
-		ops.debugMode,
+		ops.isDebugMode(),
 	)
+	ops.applyClientSettings(client)
 	ops.logger.Debug("创建LDAP客户端，目标主机：%s", domain)
 
 	// 先检查端口连通性
@@ -407,15 +1238,29 @@ func (ops *LDAPOperations) HandleGroupCheck(domain string, adminDN string, admin
 	}
 	ops.logger.Info("管理员认证成功")
 
-	// 从输入的组DN中提取CN
-	enteredGroupCN := strings.SplitN(groupDN, ",", 2)[0]
-	if !strings.HasPrefix(enteredGroupCN, "CN=") {
+	searchDN, err = ops.resolveSearchDN(client, searchDN)
+	if err != nil {
+		ops.logger.Error("检测基准DN失败：%v", err)
+		dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+		return
+	}
+
+	// 从输入的组DN中提取CN（值中的逗号等特殊字符已转义）
+	groupName := ldap.FirstRDNValue(groupDN, "CN")
+	if groupName == "" {
 		ops.logger.Error("组DN格式无效：%s", groupDN)
 		return
 	}
-	groupName := strings.TrimPrefix(enteredGroupCN, "CN=")
 	ops.logger.Debug("提取组名：%s", groupName)
 
+	// 检查流程复用同一个已绑定连接
+	if err := client.UseSharedConnection(); err != nil {
+		ops.logger.Error("获取连接失败：%v", err)
+		dialog.ShowError(fmt.Errorf("连接失败: %v", err), ops.window)
+		return
+	}
+	defer client.ReleaseSharedConnection()
+
 	// 检查组是否已存在
 	found, foundGroupDN := client.SearchGroup(groupName, searchDN)
 	if found {
@@ -429,37 +1274,62 @@ func (ops *LDAPOperations) HandleGroupCheck(domain string, adminDN string, admin
 					if reauth {
 						ops.logger.Debug("用户确认重新授权组：%s", foundGroupDN)
 						ops.logger.Info("开始重新授权组权限...")
-
-						// 获取有效连接
-						_, err := client.GetConnection()
-						if err != nil {
-							ops.logger.Error("获取连接失败：%v", err)
-							dialog.ShowError(fmt.Errorf("连接失败: %v", err), ops.window)
-							return
-						}
-						ops.logger.Debug("成功获取LDAP连接")
-
-						// 创建修改请求
-						attributes := map[string][]string{
-							"groupType":   {"-2147483646"},
-							"description": {"LDAP Authentication Group"},
-						}
-						ops.logger.Debug("准备修改组属性：%v", attributes)
-						if err := client.ModifyGroup(groupDN, attributes); err != nil {
-							ops.logger.Error("修改组属性失败：%v", err)
-							dialog.ShowError(fmt.Errorf("重新授权失败: %s", ldap.ParseLDAPError(err)), ops.window)
-							return
-						}
-
-						// 配置SSO所需的ACL权限
-						ops.logger.Debug("开始配置组的SSO权限")
-						if err := client.ConfigureGroupForSSO(groupDN, searchDN); err != nil {
-							ops.logger.Error("配置SSO权限失败：%v", err)
-							dialog.ShowError(fmt.Errorf("SSO权限配置失败: %s", ldap.ParseLDAPError(err)), ops.window)
-							return
-						}
-						ops.logger.Info("组属性修改成功")
-						ops.logger.Info("SSO权限配置成功：%s", groupDN)
+						ops.runAsync(func() {
+							// 获取有效连接，修改属性和配置SSO复用同一连接
+							if err := client.UseSharedConnection(); err != nil {
+								ops.logger.Error("获取连接失败：%v", err)
+								dialog.ShowError(fmt.Errorf("连接失败: %v", err), ops.window)
+								return
+							}
+							defer client.ReleaseSharedConnection()
+							ops.logger.Debug("成功获取LDAP连接")
+
+							// 创建修改请求
+							attributes := map[string][]string{
+								"groupType":   {ops.groupTypeValue()},
+								"description": {"LDAP Authentication Group"},
+							}
+
+							// 读取原属性值用于撤销
+							names := []string{"groupType", "description"}
+							old, err := client.GetEntry(groupDN, names)
+							if err != nil {
+								ops.logger.Error("读取组属性失败：%v", err)
+								dialog.ShowError(fmt.Errorf("重新授权失败: %v", err), ops.window)
+								return
+							}
+
+							ops.logger.Debug("准备修改组属性：%v", attributes)
+							if err := client.ModifyGroup(groupDN, attributes); err != nil {
+								ops.logger.Error("修改组属性失败：%v", err)
+								dialog.ShowError(fmt.Errorf("重新授权失败: %s", ldap.ParseLDAPError(err)), ops.window)
+								return
+							}
+							ops.recordUndo("恢复组 "+groupDN+" 的原属性", client, func(client *ldap.LDAPClient) error {
+								for _, name := range names {
+									values := old.GetAttributeValues(name)
+									op := ldap.ModifyReplace
+									if len(values) == 0 {
+										op = ldap.ModifyDelete
+									}
+									if err := client.ModifyEntry(groupDN, op, name, values); err != nil {
+										return err
+									}
+								}
+								return nil
+							})
+
+							// 配置SSO所需的ACL权限
+							ops.logger.Debug("开始配置组的SSO权限")
+							if err := client.ConfigureGroupForSSO(groupDN, searchDN, ops.groupTypeValue()); err != nil {
+								ops.logger.Error("配置SSO权限失败：%v", err)
+								dialog.ShowError(fmt.Errorf("SSO权限配置失败: %s", ldap.ParseLDAPError(err)), ops.window)
+								return
+							}
+							ops.logger.Info("组属性修改成功")
+							ops.logger.Info("SSO权限配置成功：%s", groupDN)
+							ops.logSSOVerification(client, groupDN, searchDN)
+						})
 					} else {
 						ops.logger.Debug("用户取消重新授权组")
 						ops.logger.Info("保持现有组权限不变：%s", foundGroupDN)
@@ -477,15 +1347,21 @@ func (ops *LDAPOperations) HandleGroupCheck(domain string, adminDN string, admin
 				if move {
 					ops.logger.Debug("用户确认移动组，从 %s 到 %s", foundGroupDN, groupDN)
 					ops.logger.Info("正在移动组 %s -> %s", foundGroupDN, groupDN)
-					if err := client.MoveUser(foundGroupDN, groupDN); err != nil {
-						ops.logger.Error("移动组失败：%v", err)
-						dialog.ShowError(fmt.Errorf("移动失败: %s", ldap.ParseLDAPError(err)), ops.window)
-						return
-					}
-					ops.logger.Info("组移动成功")
+					ops.runAsync(func() {
+						if err := client.MoveUser(foundGroupDN, groupDN); err != nil {
+							ops.logger.Error("移动组失败：%v", err)
+							dialog.ShowError(fmt.Errorf("移动失败: %s", ldap.ParseLDAPError(err)), ops.window)
+							return
+						}
+						ops.logger.Info("组移动成功")
+						ops.recordUndo("移动组 "+groupDN+" 回 "+foundGroupDN, client, func(client *ldap.LDAPClient) error {
+							return client.MoveUser(groupDN, foundGroupDN)
+						})
+					})
 				} else {
 					ops.logger.Debug("用户取消移动组，使用现有位置：%s", foundGroupDN)
 					ops.logger.Info("已使用现有组位置：%s", foundGroupDN)
+					ops.copyDNToClipboard(foundGroupDN)
 				}
 			}, ops.window)
 		return
@@ -495,13 +1371,16 @@ func (ops *LDAPOperations) HandleGroupCheck(domain string, adminDN string, admin
 	ops.logger.Info("未找到同名组，准备创建新组：%s", groupDN)
 
 	// 创建新组
-	if err := client.CreateGroup(groupDN, groupName); err != nil {
+	if err := client.CreateGroup(groupDN, groupName, ops.groupTypeValue()); err != nil {
 		ops.logger.Error("创建组失败：%v", err)
 		dialog.ShowError(fmt.Errorf("创建组失败: %s", ldap.ParseLDAPError(err)), ops.window)
 		return
 	}
 
 	ops.logger.Info("成功创建新组：%s", groupDN)
+	ops.recordUndo("删除新建的组 "+groupDN, client, func(client *ldap.LDAPClient) error {
+		return client.DeleteEntry(groupDN)
+	})
 }
 
 // HandleCreateLdap 处理创建LDAP用户
@@ -509,25 +1388,45 @@ func (ops *LDAPOperations) HandleCreateLdap(domain string, adminDN string, admin
 	ops.logger.Debug("开始创建LDAP用户操作")
 	ops.isSSLMode = isSSL // 设置SSL模式
 
-	// 输入验证
-	if ldapDN == "" {
-		ops.logger.Error("验证失败：LDAP DN不能为空")
-		dialog.ShowError(fmt.Errorf("LDAP DN不能为空"), ops.window)
-		return
+	// 输入验证：SSL模式下必须设置密码，组DN为可选
+	required := []string{FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldLdapDN}
+	if isSSL {
+		required = append(required, FieldLdapPassword)
 	}
-	if isSSL && ldapPassword == "" {
-		ops.logger.Error("验证失败：SSL模式下密码不能为空")
-		dialog.ShowError(fmt.Errorf("SSL模式下LDAP密码不能为空"), ops.window)
+	if groupDN != "" {
+		required = append(required, FieldGroupDN)
+	}
+	if !ops.validateInputs(required...) {
 		return
 	}
 
-	// 验证管理员凭据
-	if adminDN == "" || adminPassword == "" {
-		ops.logger.Error("验证失败：管理员DN或密码为空")
-		dialog.ShowError(fmt.Errorf("管理员DN和密码不能为空"), ops.window)
-		return
+	// 预先检查密码复杂性，避免一次失败的LDAP调用
+	if ldapPassword != "" && (isSSL || !ops.userTemplate.IsActiveDirectory()) {
+		userName := ldap.ExtractUsernameFromDN(ldapDN)
+		if problems := ldap.CheckPasswordComplexity(ldapPassword, userName); len(problems) > 0 {
+			ops.logger.Warn("密码可能不满足复杂性要求：%s", strings.Join(problems, "；"))
+			dialog.ShowConfirm("密码复杂性检查",
+				"密码可能不满足复杂性要求：\n- "+strings.Join(problems, "\n- ")+"\n\n是否仍然继续创建？",
+				func(proceed bool) {
+					if proceed {
+						ops.runOperation("创建LDAP用户", func() {
+							ops.createLdap(domain, adminDN, adminPassword, ldapDN, ldapPassword, groupDN, searchDN, portEntry, isSSL)
+						})
+					} else {
+						ops.logger.Info("操作已取消，请修改密码后重试")
+					}
+				}, ops.window)
+			return
+		}
 	}
 
+	ops.runOperation("创建LDAP用户", func() {
+		ops.createLdap(domain, adminDN, adminPassword, ldapDN, ldapPassword, groupDN, searchDN, portEntry, isSSL)
+	})
+}
+
+// createLdap 执行创建LDAP用户流程（输入已验证）
+func (ops *LDAPOperations) createLdap(domain string, adminDN string, adminPassword string, ldapDN string, ldapPassword string, groupDN string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
 	port, err := portEntry.GetPort()
 	if err != nil {
 		ops.logger.Error("获取端口失败：%v", err)
@@ -545,8 +1444,9 @@ func (ops *LDAPOperations) HandleCreateLdap(domain string, adminDN string, admin
 		ops.logger,
 		ops.updateStatus,
 		isSSL,
-		ops.debugMode,
+		ops.isDebugMode(),
 	)
+	ops.applyClientSettings(client)
 	ops.logger.Debug("创建LDAP客户端，目标主机：%s", domain)
 	ops.SetClient(client) // 设置客户端实例
 
@@ -565,13 +1465,24 @@ func (ops *LDAPOperations) HandleCreateLdap(domain string, adminDN string, admin
 	}
 	ops.logger.Info("管理员认证成功")
 
-	// 从输入的DN中提取CN
-	enteredCN := strings.SplitN(ldapDN, ",", 2)[0]
-	if !strings.HasPrefix(enteredCN, "CN=") {
+	// 自动检测目录类型，检测失败时沿用所选模板
+	if _, err := ops.detectDirectoryType(client); err != nil {
+		ops.logger.Warn("检测目录类型失败，沿用模板 %s：%v", client.GetUserTemplate().Name, err)
+	}
+
+	searchDN, err = ops.resolveSearchDN(client, searchDN)
+	if err != nil {
+		ops.logger.Error("检测基准DN失败：%v", err)
+		dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+		return
+	}
+
+	// 从输入的DN中提取CN（值中的逗号等特殊字符已转义）
+	userName := ldap.FirstRDNValue(ldapDN, "CN")
+	if userName == "" {
 		ops.logger.Error("DN格式无效：%s", ldapDN)
 		return
 	}
-	userName := strings.TrimPrefix(enteredCN, "CN=")
 	ops.logger.Debug("提取用户名：%s", userName)
 
 	// 检查用户是否存在
@@ -581,13 +1492,13 @@ func (ops *LDAPOperations) HandleCreateLdap(domain string, adminDN string, admin
 		// 当DN完全相同时（不区分大小写）
 		if strings.EqualFold(strings.ToLower(foundUserDN), strings.ToLower(ldapDN)) {
 			ops.logger.Debug("用户位置相同，处理已存在用户情况")
-			ops.HandleExistingUser(foundUserDN, ldapPassword, groupDN, searchDN)
+			ops.HandleExistingUser(client, foundUserDN, ldapPassword, groupDN, searchDN)
 			return
 		}
 
 		// 用户存在但位置不同，询问是否移动
 		ops.logger.Debug("用户存在但位置不同，当前位置：%s，目标位置：%s", foundUserDN, ldapDN)
-		ops.HandleUserMove(foundUserDN, ldapDN, ldapPassword, groupDN, searchDN)
+		ops.HandleUserMove(client, foundUserDN, ldapDN, ldapPassword, groupDN, searchDN)
 		return
 	}
 
@@ -605,10 +1516,34 @@ func (ops *LDAPOperations) HandleCreateLdap(domain string, adminDN string, admin
 		return
 	}
 	ops.logger.Info("用户创建成功")
+	ops.recordUndo("删除新建的用户 "+ldapDN, client, func(client *ldap.LDAPClient) error {
+		return client.DeleteEntry(ldapDN)
+	})
+
+	ops.mu.Lock()
+	forcePasswordChange, accountExpiry := ops.forcePasswordChange, ops.accountExpiry
+	ops.mu.Unlock()
+
+	// 只有SSL模式下创建的AD用户才有可用密码，此时才需要强制修改
+	if forcePasswordChange && isSSL && client.GetUserTemplate().IsActiveDirectory() {
+		if err := client.ForcePasswordChangeAtNextLogon(ldapDN); err != nil {
+			ops.logger.Error("设置下次登录修改密码失败：%v", err)
+		} else {
+			ops.logger.Info("已要求用户下次登录时修改密码")
+		}
+	}
+
+	if !accountExpiry.IsZero() && client.GetUserTemplate().IsActiveDirectory() {
+		if err := client.SetAccountExpiry(ldapDN, accountExpiry); err != nil {
+			ops.logger.Error("设置账户有效期失败：%v", err)
+		} else {
+			ops.logger.Info("已设置账户%s", accountExpiryLabel(accountExpiry))
+		}
+	}
 
 	// 询问是否要将用户加入LDAP组
 	ops.logger.Debug("准备处理组成员关系，组DN: %s", groupDN)
-	ops.PromptForGroupMembership(ldapDN, groupDN, searchDN)
+	ops.PromptForGroupMembership(client, ldapDN, groupDN, searchDN, false)
 }
 
 // HandleTestUser 处理用户验证（支持管理员和LDAP账号）
@@ -625,30 +1560,960 @@ func (ops *LDAPOperations) HandleTestUser(domain string, bindDN string, bindPass
 		return
 	}
 
-	// 获取选定的过滤器模式
-	var filterPattern string
-	for _, f := range ldap.CommonFilters() {
-		if f.Name == ops.filterSelect.Selected() {
-			filterPattern = f.Pattern
-			break
+	ops.runOperation("用户验证", func() {
+		searchDN, err = ops.resolveSearchDN(client, searchDN)
+		if err != nil {
+			ops.logger.Error("检测基准DN失败：%v", err)
+			dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+			return
 		}
-	}
-	ops.logger.Debug("使用过滤器：%s", filterPattern)
 
-	ops.logger.Info("使用 %s 过滤器开始验证用户...", ops.filterSelect.Selected())
-	if client.TestUserAuth(testUser, testPassword, searchDN, filterPattern) {
-		ops.logger.Info("测试用户验证成功")
-	} else {
-		ops.logger.Warn("测试用户验证失败")
-	}
+		// 获取选定的过滤器模式
+		filter, _ := ops.filterSelect.SelectedFilter()
+		filterPattern := filter.Pattern
+		ops.logger.Debug("使用过滤器：%s", filterPattern)
+		if err := ldap.ValidateFilterPattern(filterPattern); err != nil {
+			ops.logger.Error("过滤器无效：%v", err)
+			dialog.ShowError(fmt.Errorf("请选择有效的过滤器: %v", err), ops.window)
+			return
+		}
+
+		ops.logger.Info("使用 %s 过滤器开始验证用户...", ops.filterSelect.Selected())
+		result := client.TestUserAuthDetailed(testUser, testPassword, searchDN, filterPattern)
+		switch {
+		case result.Success():
+			ops.logger.Info("%s", strings.Join(append([]string{"测试用户验证成功"}, result.Summary()...), "\n"))
+		case !result.Found:
+			ops.logger.Warn("%s", strings.Join(append([]string{"测试用户验证失败：未找到用户"}, result.Summary()...), "\n"))
+		default:
+			ops.logger.Warn("%s", strings.Join(append([]string{"测试用户验证失败：密码错误或账户不可用"}, result.Summary()...), "\n"))
+		}
+	})
 }
 
 // HandleAdminTestUser 处理管理员验证用户
 func (ops *LDAPOperations) HandleAdminTestUser(domain string, adminDN string, adminPassword string, testUser string, testPassword string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldTestUser, FieldTestPassword) {
+		return
+	}
 	ops.HandleTestUser(domain, adminDN, adminPassword, testUser, testPassword, searchDN, portEntry, isSSL)
 }
 
 // HandleLdapTestUser 处理LDAP账号验证用户
 func (ops *LDAPOperations) HandleLdapTestUser(domain string, ldapDN string, ldapPassword string, testUser string, testPassword string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldLdapBindName, FieldLdapPassword, FieldTestUser, FieldTestPassword) {
+		return
+	}
 	ops.HandleTestUser(domain, ldapDN, ldapPassword, testUser, testPassword, searchDN, portEntry, isSSL)
 }
+
+// HandleToggleAccount 处理启用/停用账户
+func (ops *LDAPOperations) HandleToggleAccount(domain string, adminDN string, adminPassword string, userDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始启用/停用账户操作")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldLdapDN) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	const (
+		actionEnable  = "启用"
+		actionDisable = "停用"
+	)
+	actionRadio := widget.NewRadioGroup([]string{actionEnable, actionDisable}, nil)
+	actionRadio.Horizontal = true
+	actionRadio.SetSelected(actionEnable)
+	actionRadio.Required = true
+
+	content := container.NewVBox(
+		widget.NewLabel("请选择账户状态：\n"+userDN),
+		actionRadio,
+	)
+
+	// 关闭或取消对话框时不修改账户
+	dialog.ShowCustomConfirm("启用/停用账户", "确定", "取消", content,
+		func(confirmed bool) {
+			if !confirmed {
+				ops.logger.Debug("用户取消启用/停用账户")
+				return
+			}
+			action := actionRadio.Selected
+			enable := action == actionEnable
+			ops.logger.Info("正在%s账户：%s", action, userDN)
+			ops.runOperation("启用/停用账户", func() {
+				wasEnabled, err := client.AccountEnabled(userDN)
+				if err != nil {
+					ops.logger.Error("%s账户失败：%v", action, err)
+					dialog.ShowError(err, ops.window)
+					return
+				}
+				if err := client.SetAccountEnabled(userDN, enable); err != nil {
+					ops.logger.Error("%s账户失败：%v", action, err)
+					dialog.ShowError(err, ops.window)
+					return
+				}
+				ops.logger.Info("账户已%s：%s", action, userDN)
+
+				previous := actionDisable
+				if wasEnabled {
+					previous = actionEnable
+				}
+				ops.recordUndo("将账户 "+userDN+" 恢复为"+previous, client, func(client *ldap.LDAPClient) error {
+					return client.SetAccountEnabled(userDN, wasEnabled)
+				})
+			})
+		}, ops.window)
+}
+
+// HandleAccountStatus 处理查看账户状态（停用、锁定、过期、错误密码次数等），onLocked接收账户是否被锁定
+func (ops *LDAPOperations) HandleAccountStatus(domain string, adminDN string, adminPassword string, userDN string, portEntry *CustomPortEntry, isSSL bool, onLocked func(locked bool)) {
+	ops.logger.Debug("开始查看账户状态")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldLdapDN) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("账户状态", func() {
+		status, err := client.GetAccountStatus(userDN)
+		if err != nil {
+			ops.logger.Error("查看账户状态失败：%v", err)
+			return
+		}
+		ops.logger.Info("%s", strings.Join(append([]string{"账户状态："}, status.Summary()...), "\n"))
+		if onLocked != nil {
+			onLocked(status.Locked)
+		}
+	})
+}
+
+// HandleUnlockAccount 处理解锁账户，onUnlocked在解锁成功后调用
+func (ops *LDAPOperations) HandleUnlockAccount(domain string, adminDN string, adminPassword string, userDN string, portEntry *CustomPortEntry, isSSL bool, onUnlocked func()) {
+	ops.logger.Debug("开始解锁账户操作")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldLdapDN) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	dialog.ShowConfirm("解锁账户", "确定要解锁以下账户吗？\n"+userDN, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		ops.runOperation("解锁账户", func() {
+			if err := client.UnlockAccount(userDN); err != nil {
+				ops.logger.Error("解锁账户失败：%v", err)
+				dialog.ShowError(err, ops.window)
+				return
+			}
+			ops.logger.Info("账户已解锁：%s", userDN)
+			ops.clearUndo()
+			dialog.ShowInformation("解锁账户", "账户已解锁", ops.window)
+			if onUnlocked != nil {
+				onUnlocked()
+			}
+		})
+	}, ops.window)
+}
+
+// HandleListGroupMembers 处理查看组成员
+func (ops *LDAPOperations) HandleListGroupMembers(domain string, adminDN string, adminPassword string, groupDN string, recursive bool, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始查看组成员操作，递归展开：%v", recursive)
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldGroupDN) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("查看组成员", func() {
+		var members []string
+		if recursive {
+			members, err = client.GetGroupMembersRecursive(groupDN)
+		} else {
+			members, err = client.GetGroupMembers(groupDN)
+		}
+		if err != nil {
+			ops.logger.Error("获取组成员失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+
+		for _, member := range members {
+			ops.logger.Info("  成员：%s", member)
+		}
+		ops.logger.Info("组 %s 共有 %d 个成员", groupDN, len(members))
+	})
+}
+
+// HandleServerInfo 处理查看服务器信息（RootDSE）
+func (ops *LDAPOperations) HandleServerInfo(domain string, adminDN string, adminPassword string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始查询服务器信息")
+
+	if !ops.validateInputs(FieldDomain, FieldPort) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("服务器信息", func() {
+		rootDSE, err := client.QueryRootDSE()
+		if err != nil {
+			ops.logger.Error("查询服务器信息失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+
+		// 状态区域新消息在顶部，合并为一条消息以保持顺序
+		var lines []string
+		if directoryType, err := ops.detectDirectoryType(client); err == nil {
+			lines = append(lines, "目录类型："+directoryType)
+		}
+		lines = append(lines, "服务器信息（RootDSE）：")
+		for _, name := range ldap.RootDSEAttributes() {
+			values, ok := rootDSE[name]
+			if !ok {
+				lines = append(lines, "  "+name+": (未返回)")
+				continue
+			}
+			lines = append(lines, "  "+name+":")
+			for _, v := range values {
+				lines = append(lines, "    "+v)
+			}
+		}
+		ops.logger.Info("%s", strings.Join(lines, "\n"))
+	})
+}
+
+// HandleTestAllFilters 处理测试所有过滤器，报告每个过滤器的匹配结果
+func (ops *LDAPOperations) HandleTestAllFilters(domain string, bindDN string, bindPassword string, testUser string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始测试所有过滤器")
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldTestUser) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, bindDN, bindPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("测试所有过滤器", func() {
+		searchDN, err = ops.resolveSearchDN(client, searchDN)
+		if err != nil {
+			ops.logger.Error("检测基准DN失败：%v", err)
+			dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+			return
+		}
+
+		var lines []string
+		lines = append(lines, fmt.Sprintf("过滤器测试结果（用户：%s）：", testUser))
+		for _, f := range ldap.AllFilters() {
+			if err := ldap.ValidateFilterPattern(f.Pattern); err != nil {
+				lines = append(lines, fmt.Sprintf("  %-40s | 模式无效：%v", f.Name, err))
+				continue
+			}
+			entries, err := client.FindUserEntries(testUser, searchDN, f.Pattern)
+			var result string
+			switch {
+			case err != nil:
+				result = "错误：" + ldap.ParseLDAPError(err)
+			case len(entries) == 0:
+				result = "未匹配"
+			case len(entries) == 1:
+				result = "唯一匹配 -> " + entries[0].DN
+			default:
+				result = fmt.Sprintf("多个匹配（%d 条）-> %s ...", len(entries), entries[0].DN)
+			}
+			lines = append(lines, fmt.Sprintf("  %-40s | %s", f.Name, result))
+		}
+		ops.logger.Info("%s", strings.Join(lines, "\n"))
+	})
+}
+
+// ShowAddFilterDialog 显示添加自定义过滤器对话框
+func (ops *LDAPOperations) ShowAddFilterDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("过滤器名称")
+	patternEntry := widget.NewEntry()
+	patternEntry.SetPlaceHolder("(&(objectClass=person)(uid=%s))")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("名称", nameEntry),
+		widget.NewFormItem("模式", patternEntry),
+	}
+
+	dialog.ShowForm("添加过滤器", "添加", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		name := strings.TrimSpace(nameEntry.Text)
+		pattern := strings.TrimSpace(patternEntry.Text)
+		if name == "" {
+			dialog.ShowError(fmt.Errorf("过滤器名称不能为空"), ops.window)
+			return
+		}
+		if err := ldap.ValidateFilterPattern(pattern); err != nil {
+			ops.logger.Error("过滤器校验失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+
+		ldap.AddCustomFilter(ldap.LDAPFilter{Name: name, Pattern: pattern})
+		ops.filterSelect.ReloadFilters()
+		ops.logger.Info("已添加自定义过滤器：%s = %s", name, pattern)
+
+		if ops.config == nil {
+			return
+		}
+		replaced := false
+		for i, f := range ops.config.CustomFilters {
+			if f.Name == name {
+				ops.config.CustomFilters[i].Pattern = pattern
+				replaced = true
+			}
+		}
+		if !replaced {
+			ops.config.CustomFilters = append(ops.config.CustomFilters, config.FilterConfig{Name: name, Pattern: pattern})
+		}
+		if err := ops.config.Save(); err != nil {
+			ops.logger.Error("保存配置失败：%v", err)
+			return
+		}
+		ops.logger.Debug("自定义过滤器已保存到：%s", ops.config.Path())
+	}, ops.window)
+}
+
+// HandleGeneratePassword 生成随机密码，填入密码框并复制到剪贴板
+func (ops *LDAPOperations) HandleGeneratePassword(passwordEntry *widget.Entry, userDN string) {
+	password, err := ldap.GeneratePassword(12, ldap.ExtractUsernameFromDN(userDN))
+	if err != nil {
+		ops.logger.Error("生成密码失败：%v", err)
+		dialog.ShowError(fmt.Errorf("生成密码失败: %v", err), ops.window)
+		return
+	}
+
+	passwordEntry.SetText(password)
+	ops.window.Clipboard().SetContent(password)
+	ops.logger.Info("已生成随机密码并复制到剪贴板")
+}
+
+// HandleCompareAttribute 处理属性比较，确认条目的某个属性是否为指定值
+func (ops *LDAPOperations) HandleCompareAttribute(domain string, adminDN string, adminPassword string, defaultDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始比较属性")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	dnEntry := widget.NewEntry()
+	dnEntry.SetText(defaultDN)
+	dnEntry.SetPlaceHolder("CN=user,OU=Users,DC=example,DC=com")
+	attrEntry := widget.NewEntry()
+	attrEntry.SetPlaceHolder("memberOf")
+	valueEntry := widget.NewEntry()
+	valueEntry.SetPlaceHolder("期望的属性值")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("DN", dnEntry),
+		widget.NewFormItem("属性", attrEntry),
+		widget.NewFormItem("期望值", valueEntry),
+	}
+
+	dialog.ShowForm("比较属性", "比较", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		dn := strings.TrimSpace(dnEntry.Text)
+		attr := strings.TrimSpace(attrEntry.Text)
+		value := valueEntry.Text
+		if dn == "" || attr == "" {
+			dialog.ShowError(fmt.Errorf("DN和属性名不能为空"), ops.window)
+			return
+		}
+
+		ops.runOperation("比较属性", func() {
+			matched, err := client.CompareAttribute(dn, attr, value)
+			if err != nil {
+				ops.logger.Error("比较属性失败：%v", err)
+				return
+			}
+			result := "FALSE"
+			if matched {
+				result = "TRUE"
+			}
+			ops.logger.Info("比较结果：%s（%s: %s = %s）", result, dn, attr, value)
+		})
+	}, ops.window)
+}
+
+// HandleModifyAttribute 处理任意属性修改
+func (ops *LDAPOperations) HandleModifyAttribute(domain string, adminDN string, adminPassword string, defaultDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始修改属性")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	dnEntry := widget.NewEntry()
+	dnEntry.SetText(defaultDN)
+	dnEntry.SetPlaceHolder("CN=user,OU=Users,DC=example,DC=com")
+	attrEntry := widget.NewEntry()
+	attrEntry.SetPlaceHolder("mail")
+	opSelect := widget.NewSelect(ldap.ModifyOperations(), nil)
+	opSelect.SetSelected(ldap.ModifyOperations()[0])
+	valuesEntry := widget.NewMultiLineEntry()
+	valuesEntry.SetPlaceHolder("每行一个值，Delete留空表示删除整个属性")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("DN", dnEntry),
+		widget.NewFormItem("属性", attrEntry),
+		widget.NewFormItem("操作", opSelect),
+		widget.NewFormItem("值", valuesEntry),
+	}
+
+	dialog.ShowForm("修改属性", "修改", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		dn := strings.TrimSpace(dnEntry.Text)
+		attr := strings.TrimSpace(attrEntry.Text)
+		if dn == "" || attr == "" {
+			dialog.ShowError(fmt.Errorf("DN和属性名不能为空"), ops.window)
+			return
+		}
+		op, ok := ldap.ParseModifyOperation(opSelect.Selected)
+		if !ok {
+			dialog.ShowError(fmt.Errorf("请选择修改操作"), ops.window)
+			return
+		}
+
+		var values []string
+		for _, line := range strings.Split(valuesEntry.Text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				values = append(values, line)
+			}
+		}
+		if op != ldap.ModifyDelete && len(values) == 0 {
+			dialog.ShowError(fmt.Errorf("%s操作至少需要一个值", opSelect.Selected), ops.window)
+			return
+		}
+
+		ops.runOperation("修改属性", func() {
+			// 记录修改前的值用于撤销，密码属性无法读回原值
+			var oldValues []string
+			canUndo := !ldap.IsPasswordAttribute(attr)
+			if canUndo {
+				entry, err := client.GetEntry(dn, []string{attr})
+				if err != nil {
+					ops.logger.Warn("读取 %s 原值失败，此次修改无法撤销：%v", attr, err)
+					canUndo = false
+				} else {
+					oldValues = entry.GetAttributeValues(attr)
+				}
+			}
+
+			if err := client.ModifyEntry(dn, op, attr, values); err != nil {
+				ops.logger.Error("修改属性失败：%v", err)
+				dialog.ShowError(err, ops.window)
+				return
+			}
+			ops.logger.Info("属性修改成功：%s %s %s", dn, opSelect.Selected, attr)
+
+			if !canUndo {
+				ops.clearUndo()
+				return
+			}
+			ops.recordUndo("恢复 "+dn+" 的 "+attr+" 原值", client, func(client *ldap.LDAPClient) error {
+				if len(oldValues) == 0 {
+					return client.ModifyEntry(dn, ldap.ModifyDelete, attr, nil)
+				}
+				return client.ModifyEntry(dn, ldap.ModifyReplace, attr, oldValues)
+			})
+		})
+	}, ops.window)
+}
+
+// HandleRenameEntry 处理条目重命名（只修改RDN，条目仍在原容器中）
+func (ops *LDAPOperations) HandleRenameEntry(domain string, adminDN string, adminPassword string, defaultDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始重命名条目")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	dnEntry := widget.NewEntry()
+	dnEntry.SetText(defaultDN)
+	dnEntry.SetPlaceHolder("CN=user,OU=Users,DC=example,DC=com")
+	rdnEntry := widget.NewEntry()
+	rdnEntry.SetPlaceHolder("CN=newname")
+	deleteOldRDNCheck := widget.NewCheck("删除旧RDN值", nil)
+	deleteOldRDNCheck.SetChecked(true)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("DN", dnEntry),
+		widget.NewFormItem("新RDN", rdnEntry),
+		widget.NewFormItem("", deleteOldRDNCheck),
+	}
+
+	dialog.ShowForm("重命名", "重命名", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		dn := strings.TrimSpace(dnEntry.Text)
+		newRDN := strings.TrimSpace(rdnEntry.Text)
+		if !ops.validateDN("DN", dn) {
+			return
+		}
+		if newRDN == "" {
+			dialog.ShowError(fmt.Errorf("新RDN不能为空"), ops.window)
+			return
+		}
+
+		ops.runOperation("重命名", func() {
+			if err := client.RenameEntry(dn, newRDN, deleteOldRDNCheck.Checked); err != nil {
+				ops.logger.Error("重命名失败：%v", err)
+				dialog.ShowError(err, ops.window)
+				return
+			}
+
+			oldRDN, parentDN, err := ldap.SplitDN(dn)
+			if err != nil || ldap.NormalizeDN(oldRDN) == ldap.NormalizeDN(newRDN) {
+				return
+			}
+			newDN := newRDN
+			if parentDN != "" {
+				newDN += "," + parentDN
+			}
+			ops.recordUndo("将 "+newDN+" 重命名回 "+oldRDN, client, func(client *ldap.LDAPClient) error {
+				return client.RenameEntry(newDN, oldRDN, true)
+			})
+		})
+	}, ops.window)
+}
+
+// HandleAnonymousTest 处理匿名访问测试：不使用管理员凭据，匿名绑定后尝试读取基准DN
+func (ops *LDAPOperations) HandleAnonymousTest(domain string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始测试匿名访问")
+
+	if !ops.validateInputs(FieldDomain, FieldPort) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, "", "", portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("匿名访问测试", func() {
+		baseDN, err := client.TestAnonymousAccess(searchDN)
+		if err != nil {
+			ops.logger.Warn("服务器不允许匿名访问：%v", err)
+			return
+		}
+		ops.logger.Info("服务器允许匿名访问，已匿名读取：%s", baseDN)
+	})
+}
+
+// HandleViewCertificate 处理查看服务器证书链
+func (ops *LDAPOperations) HandleViewCertificate(domain string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始查看服务器证书")
+
+	if !isSSL {
+		ops.logger.Error("验证失败：未启用SSL")
+		dialog.ShowError(fmt.Errorf("请先勾选SSL支持"), ops.window)
+		return
+	}
+	if !ops.validateInputs(FieldDomain, FieldPort) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, "", "", portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("查看证书", func() {
+		lines, err := client.InspectServerCertificate()
+		if err != nil {
+			ops.logger.Error("获取服务器证书失败：%v", err)
+			return
+		}
+		// 状态区域新消息在顶部，合并为一条消息以保持顺序
+		ops.logger.Info("%s", strings.Join(append([]string{"服务器证书链："}, lines...), "\n"))
+	})
+}
+
+// HandleWhoAmI 处理查询当前绑定身份
+func (ops *LDAPOperations) HandleWhoAmI(domain string, adminDN string, adminPassword string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始查询当前身份")
+
+	if !ops.validateInputs(FieldDomain, FieldPort) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("当前身份", func() {
+		authzID, err := client.WhoAmI()
+		if err != nil {
+			ops.logger.Error("查询当前身份失败：%v", err)
+			return
+		}
+		if authzID == "" {
+			ops.logger.Info("当前身份：匿名")
+			return
+		}
+		ops.logger.Info("当前身份：%s", authzID)
+	})
+}
+
+// HandleDiscoverServers 通过DNS SRV记录自动发现域控制器，onFound在找到服务器后调用
+func (ops *LDAPOperations) HandleDiscoverServers(domain string, onFound func(servers []string)) {
+	ops.logger.Info("开始自动发现LDAP服务器")
+
+	if !ops.validateInputs(FieldDomain) {
+		return
+	}
+
+	ops.runOperation("自动发现", func() {
+		servers, err := ldap.DiscoverLDAPServers(domain)
+		if err != nil {
+			ops.logger.Warn("自动发现失败，继续使用输入的地址 %s：%v", domain, err)
+			return
+		}
+		ops.logger.Info("%s", strings.Join(append([]string{"发现的LDAP服务器："}, servers...), "\n"))
+		if onFound != nil {
+			onFound(servers)
+		}
+	})
+}
+
+// HandleCheckMembership 处理检查用户是否属于组（包括嵌套组）
+func (ops *LDAPOperations) HandleCheckMembership(domain string, adminDN string, adminPassword string, userDN string, groupDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始检查组成员关系")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldLdapDN, FieldGroupDN) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("检查组成员关系", func() {
+		member, err := client.IsMemberOf(userDN, groupDN)
+		if err != nil {
+			ops.logger.Error("检查组成员关系失败：%v", err)
+			return
+		}
+		if member {
+			ops.logger.Info("%s 属于组 %s（含嵌套组）", userDN, groupDN)
+		} else {
+			ops.logger.Warn("%s 不属于组 %s（含嵌套组）", userDN, groupDN)
+		}
+	})
+}
+
+// HandleViewEntry 处理查看条目的所有属性
+func (ops *LDAPOperations) HandleViewEntry(domain string, adminDN string, adminPassword string, dn string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始查看条目")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldLdapDN) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("查看条目", func() {
+		entry, err := client.GetEntry(dn, nil)
+		if err != nil {
+			ops.logger.Error("查看条目失败：%v", err)
+			return
+		}
+		ops.logger.Info("%s", strings.Join(append([]string{"条目属性："}, ldap.EntryDump(entry)...), "\n"))
+	})
+}
+
+// HandleCompareEntries 处理对比两个条目的属性，例如对比能正常认证和不能认证的用户
+func (ops *LDAPOperations) HandleCompareEntries(domain string, adminDN string, adminPassword string, dn string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始对比条目")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	dnAEntry := widget.NewEntry()
+	dnAEntry.SetText(dn)
+	dnAEntry.SetPlaceHolder("正常的条目DN")
+	dnBEntry := widget.NewEntry()
+	dnBEntry.SetPlaceHolder("有问题的条目DN")
+	operationalCheck := widget.NewCheck("对比操作属性", nil)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("条目A", dnAEntry),
+		widget.NewFormItem("条目B", dnBEntry),
+		widget.NewFormItem("", operationalCheck),
+	}
+
+	dialog.ShowForm("对比条目", "对比", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		dnA := strings.TrimSpace(dnAEntry.Text)
+		dnB := strings.TrimSpace(dnBEntry.Text)
+		if dnA == "" || dnB == "" {
+			dialog.ShowError(fmt.Errorf("请输入要对比的两个条目DN"), ops.window)
+			return
+		}
+
+		ops.runOperation("对比条目", func() {
+			diff, err := client.CompareEntries(dnA, dnB, operationalCheck.Checked)
+			if err != nil {
+				ops.logger.Error("对比条目失败：%v", err)
+				return
+			}
+			title := fmt.Sprintf("条目对比：仅A %d 个属性，仅B %d 个属性，值不同 %d 个属性", len(diff.OnlyA), len(diff.OnlyB), len(diff.Different))
+			if diff.Identical() {
+				title = "条目对比：两个条目的属性完全相同"
+			}
+			ops.logger.Info("%s", strings.Join(append([]string{title}, diff.Summary()...), "\n"))
+		})
+	}, ops.window)
+}
+
+// HandleVerifySSO 验证组的SSO配置：读回groupType、description和搜索DN上的DACL，逐项对比期望值
+func (ops *LDAPOperations) HandleVerifySSO(domain string, adminDN string, adminPassword string, groupDN string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始验证SSO配置")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldGroupDN) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("验证SSO配置", func() {
+		baseDN, err := ops.resolveSearchDN(client, searchDN)
+		if err != nil {
+			ops.logger.Error("检测基准DN失败：%v", err)
+			dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+			return
+		}
+		ops.logSSOVerification(client, groupDN, baseDN)
+	})
+}
+
+// logSSOVerification 验证组的SSO配置并输出逐项结果
+func (ops *LDAPOperations) logSSOVerification(client *ldap.LDAPClient, groupDN string, searchDN string) {
+	result, err := client.VerifyGroupSSO(groupDN, searchDN, ops.groupTypeValue())
+	if err != nil {
+		ops.logger.Error("验证SSO配置失败：%v", err)
+		return
+	}
+
+	title := "SSO配置验证通过："
+	if !result.Passed() {
+		title = "SSO配置与期望不一致："
+	}
+	message := strings.Join(append([]string{title}, result.Summary()...), "\n")
+	if result.Passed() {
+		ops.logger.Info("%s", message)
+	} else {
+		ops.logger.Warn("%s", message)
+	}
+}
+
+// HandleViewPermissions 读取条目的nTSecurityDescriptor并列出DACL中的每个ACE
+func (ops *LDAPOperations) HandleViewPermissions(domain string, adminDN string, adminPassword string, dn string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始查看权限")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldLdapDN) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("查看权限", func() {
+		sd, err := client.GetSecurityDescriptor(dn)
+		if err != nil {
+			ops.logger.Error("查看权限失败：%v", err)
+			return
+		}
+		lines, err := ldap.DescribeDACL(sd)
+		if err != nil {
+			ops.logger.Error("解析安全描述符失败：%v", err)
+			return
+		}
+		ops.logger.Info("%s", strings.Join(append([]string{dn + " 的DACL："}, lines...), "\n"))
+	})
+}
+
+// HandleBenchmark 处理性能测试：使用测试账号反复绑定和搜索，统计延迟和吞吐量
+func (ops *LDAPOperations) HandleBenchmark(domain string, adminDN string, adminPassword string, testUser string, testPassword string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始性能测试")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword, FieldTestUser, FieldTestPassword) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	iterationsEntry := widget.NewEntry()
+	iterationsEntry.SetText("100")
+	concurrencyEntry := widget.NewEntry()
+	concurrencyEntry.SetText("1")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("次数", iterationsEntry),
+		widget.NewFormItem("并发数", concurrencyEntry),
+	}
+
+	dialog.ShowForm("性能测试", "开始", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		iterations, err := strconv.Atoi(strings.TrimSpace(iterationsEntry.Text))
+		if err != nil || iterations <= 0 {
+			dialog.ShowError(fmt.Errorf("次数必须是正整数"), ops.window)
+			return
+		}
+		concurrency, err := strconv.Atoi(strings.TrimSpace(concurrencyEntry.Text))
+		if err != nil || concurrency <= 0 {
+			dialog.ShowError(fmt.Errorf("并发数必须是正整数"), ops.window)
+			return
+		}
+
+		ops.runOperation("性能测试", func() {
+			baseDN, err := ops.resolveSearchDN(client, searchDN)
+			if err != nil {
+				ops.logger.Error("检测基准DN失败：%v", err)
+				dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+				return
+			}
+
+			// 先验证测试账号，获取用于绑定的DN
+			filter, _ := ops.filterSelect.SelectedFilter()
+			auth := client.TestUserAuthDetailed(testUser, testPassword, baseDN, filter.Pattern)
+			if !auth.Success() {
+				ops.logger.Error("%s", strings.Join(append([]string{"测试账号验证失败，无法进行性能测试"}, auth.Summary()...), "\n"))
+				return
+			}
+
+			ops.logger.Info("开始性能测试：%s，次数 %d，并发 %d", auth.MatchedDN, iterations, concurrency)
+			result, err := client.Benchmark(auth.MatchedDN, testPassword, iterations, concurrency)
+			if err != nil {
+				ops.logger.Error("性能测试中断：%v", err)
+				if result == nil {
+					return
+				}
+			}
+			ops.logger.Info("%s", strings.Join(append([]string{"性能测试结果："}, result.Summary()...), "\n"))
+		})
+	}, ops.window)
+}
+
+// HandleCheckReadAccess 处理测试读取权限：使用LDAP账号绑定后读取搜索DN并枚举其子条目
+func (ops *LDAPOperations) HandleCheckReadAccess(domain string, bindDN string, bindPassword string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始测试读取权限")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldLdapBindName, FieldLdapPassword, FieldSearchDN) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, bindDN, bindPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	ops.runOperation("测试读取权限", func() {
+		result, err := client.CheckReadAccess(searchDN)
+		if err != nil {
+			ops.logger.Error("测试读取权限失败：%v", err)
+			return
+		}
+		report := strings.Join(append([]string{bindDN + " 的读取权限："}, result.Summary()...), "\n")
+		if result.Readable() {
+			ops.logger.Info("%s", report)
+		} else {
+			ops.logger.Warn("%s", report)
+		}
+	})
+}
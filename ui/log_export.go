@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// ShowExportJSONLogDialog 将内存中保留的日志以JSON行格式导出到文件
+func (ops *LDAPOperations) ShowExportJSONLogDialog() {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			ops.logger.Error("选择导出文件失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		if writer == nil {
+			return // 用户取消
+		}
+		defer writer.Close()
+
+		count, err := ops.logger.WriteJSON(writer)
+		if err != nil {
+			ops.logger.Error("导出JSON日志失败：%v", err)
+			dialog.ShowError(fmt.Errorf("导出JSON日志失败: %v", err), ops.window)
+			return
+		}
+		ops.logger.Info("已导出 %d 条日志到：%s", count, writer.URI().Path())
+	}, ops.window)
+	saveDialog.SetFileName("ldaptest_log.jsonl")
+	saveDialog.Show()
+}
@@ -0,0 +1,47 @@
+package ui
+
+import "fyne.io/fyne/v2/widget"
+
+// SetMutatingButtons 设置会修改目录的按钮，只读模式下这些按钮被禁用
+func (ops *LDAPOperations) SetMutatingButtons(buttons ...*widget.Button) {
+	ops.mutatingButtons = buttons
+}
+
+// ReadOnly 返回是否处于只读模式
+func (ops *LDAPOperations) ReadOnly() bool {
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+	return ops.readOnly
+}
+
+// SetReadOnly 切换只读模式：禁用创建、移动、修改、删除和组授权按钮，
+// 之后的客户端拒绝所有写请求，连接、搜索和验证不受影响
+func (ops *LDAPOperations) SetReadOnly(enabled bool) {
+	ops.mu.Lock()
+	ops.readOnly = enabled
+	client, keepAliveClient := ops.client, ops.keepAliveClient
+	ops.mu.Unlock()
+	for _, button := range ops.mutatingButtons {
+		if enabled {
+			button.Disable()
+		} else {
+			button.Enable()
+		}
+	}
+	if client != nil {
+		client.SetReadOnly(enabled)
+	}
+	if keepAliveClient != nil {
+		keepAliveClient.SetReadOnly(enabled)
+	}
+
+	ops.undoMu.Lock()
+	ops.refreshUndoButtonLocked()
+	ops.undoMu.Unlock()
+
+	if enabled {
+		ops.logger.Info("已开启只读模式，写操作将被拒绝")
+	} else {
+		ops.logger.Info("已关闭只读模式")
+	}
+}
@@ -0,0 +1,374 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"LdapTest/ldap"
+)
+
+// maxLoggedEntries 状态区域中最多显示的搜索结果条目数
+const maxLoggedEntries = 50
+
+// 排序方向选项
+const (
+	sortAscending  = "升序"
+	sortDescending = "降序"
+)
+
+// sortAttributeOptions 排序属性下拉框中的常用属性，也可手动输入其他属性
+var sortAttributeOptions = []string{"cn", "sAMAccountName", "displayName", "mail", "whenCreated", "whenChanged"}
+
+// HandleSearchEntries 处理通用条目搜索，结果保存后可导出
+func (ops *LDAPOperations) HandleSearchEntries(domain string, adminDN string, adminPassword string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始搜索条目")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	filterEntry := widget.NewEntry()
+	filterEntry.SetText("(objectClass=*)")
+	buildFilterButton := widget.NewButton("构建...", func() {
+		ops.ShowFilterBuilderDialog(filterEntry.SetText)
+	})
+	attributesEntry := widget.NewEntry()
+	attributesEntry.SetPlaceHolder("cn,mail（留空返回全部用户属性，* 为用户属性，+ 为操作属性）")
+	lastSearch := ops.lastSearchResult()
+	if lastSearch != nil {
+		attributesEntry.SetText(strings.Join(lastSearch.Attributes, ","))
+	}
+
+	sortAttributeEntry := widget.NewSelectEntry(sortAttributeOptions)
+	sortAttributeEntry.SetPlaceHolder("留空不排序")
+	sortDirectionSelect := widget.NewSelect([]string{sortAscending, sortDescending}, nil)
+	sortDirectionSelect.SetSelected(sortAscending)
+	if lastSearch != nil && lastSearch.Sort != nil {
+		sortAttributeEntry.SetText(lastSearch.Sort.Attribute)
+		if lastSearch.Sort.Reverse {
+			sortDirectionSelect.SetSelected(sortDescending)
+		}
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("过滤器", container.NewBorder(nil, nil, nil, buildFilterButton, filterEntry)),
+		widget.NewFormItem("返回属性", attributesEntry),
+		widget.NewFormItem("排序", container.NewBorder(nil, nil, nil, sortDirectionSelect, sortAttributeEntry)),
+	}
+
+	dialog.ShowForm("搜索条目", "搜索", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		filter := strings.TrimSpace(filterEntry.Text)
+		if filter == "" {
+			dialog.ShowError(fmt.Errorf("过滤器不能为空"), ops.window)
+			return
+		}
+		attributes := splitAttributeList(attributesEntry.Text)
+		var sort *ldap.SortKey
+		if attr := strings.TrimSpace(sortAttributeEntry.Text); attr != "" {
+			sort = &ldap.SortKey{Attribute: attr, Reverse: sortDirectionSelect.Selected == sortDescending}
+		}
+
+		ops.runOperation("搜索条目", func() {
+			baseDN, err := ops.resolveSearchDN(client, searchDN)
+			if err != nil {
+				ops.logger.Error("检测基准DN失败：%v", err)
+				dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+				return
+			}
+
+			result, err := client.SearchEntries(baseDN, filter, attributes, sort)
+			if err != nil {
+				ops.logger.Error("搜索条目失败：%v", err)
+				dialog.ShowError(err, ops.window)
+				return
+			}
+			ops.mu.Lock()
+			ops.lastSearch = result
+			ops.mu.Unlock()
+			ops.logSearchResult(result)
+			if result.SortError != nil {
+				dialog.ShowInformation("排序不可用", result.SortError.Error()+"\n\n已返回未排序的结果。", ops.window)
+			}
+			if result.Truncated != nil {
+				ops.logger.Warn("%v", result.Truncated)
+				dialog.ShowInformation("搜索结果不完整", result.Truncated.Error(), ops.window)
+			}
+		})
+	}, ops.window)
+}
+
+// lastSearchResult 返回最近一次搜索结果，没有搜索过时返回nil
+func (ops *LDAPOperations) lastSearchResult() *ldap.SearchResult {
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+	return ops.lastSearch
+}
+
+// logSearchResult 将搜索结果合并为一条消息输出到状态区域
+func (ops *LDAPOperations) logSearchResult(result *ldap.SearchResult) {
+	lines := []string{fmt.Sprintf("搜索完成：%s 下匹配 %s 的条目共 %d 个", result.BaseDN, result.Filter, len(result.Entries))}
+	if result.Sort != nil {
+		lines[0] += "（按 " + result.Sort.String() + " 排序）"
+	}
+	for i, entry := range result.Entries {
+		if i == maxLoggedEntries {
+			lines = append(lines, fmt.Sprintf("...其余 %d 个条目未显示，可导出查看", len(result.Entries)-maxLoggedEntries))
+			break
+		}
+		lines = append(lines, "  "+entry.DN)
+		for _, attr := range entry.Attributes {
+			lines = append(lines, "    "+attr.Name+": "+strings.Join(ldap.DescribeAttributeValues(attr), "; "))
+		}
+	}
+	ops.logger.Info("%s", strings.Join(lines, "\n"))
+}
+
+// ShowExportCSVDialog 将最近一次搜索结果导出为CSV文件
+func (ops *LDAPOperations) ShowExportCSVDialog() {
+	result := ops.lastSearchResult()
+	if result == nil || len(result.Entries) == 0 {
+		dialog.ShowError(fmt.Errorf("没有可导出的搜索结果，请先执行搜索"), ops.window)
+		return
+	}
+
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			ops.logger.Error("选择导出文件失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		if writer == nil {
+			return // 用户取消
+		}
+		defer writer.Close()
+
+		if err := result.WriteCSV(writer); err != nil {
+			ops.logger.Error("导出CSV失败：%v", err)
+			dialog.ShowError(fmt.Errorf("导出CSV失败: %v", err), ops.window)
+			return
+		}
+		ops.logger.Info("已导出 %d 个条目到：%s", len(result.Entries), writer.URI().Path())
+	}, ops.window)
+	saveDialog.SetFileName("search_result.csv")
+	saveDialog.Show()
+}
+
+// splitAttributeList 将逗号分隔的属性列表拆分为切片，忽略空项
+func splitAttributeList(text string) []string {
+	var attributes []string
+	for _, attr := range strings.Split(text, ",") {
+		if attr = strings.TrimSpace(attr); attr != "" {
+			attributes = append(attributes, attr)
+		}
+	}
+	return attributes
+}
+
+// ShowExportLDIFDialog 选择最近一次搜索结果中的条目并导出为LDIF文件
+func (ops *LDAPOperations) ShowExportLDIFDialog() {
+	result := ops.lastSearchResult()
+	if result == nil || len(result.Entries) == 0 {
+		dialog.ShowError(fmt.Errorf("没有可导出的搜索结果，请先执行搜索"), ops.window)
+		return
+	}
+
+	const allEntries = "(全部条目)"
+	options := []string{allEntries}
+	for _, entry := range result.Entries {
+		options = append(options, entry.DN)
+	}
+	entrySelect := widget.NewSelect(options, nil)
+	entrySelect.SetSelected(allEntries)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("条目", entrySelect),
+	}
+
+	dialog.ShowForm("导出LDIF", "导出", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		entries := result.Entries
+		fileName := "search_result.ldif"
+		if index := entrySelect.SelectedIndex(); index > 0 {
+			entries = result.Entries[index-1 : index]
+			if name := ldap.ExtractUsernameFromDN(entries[0].DN); name != "" {
+				fileName = name + ".ldif"
+			}
+		}
+
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				ops.logger.Error("选择导出文件失败：%v", err)
+				dialog.ShowError(err, ops.window)
+				return
+			}
+			if writer == nil {
+				return // 用户取消
+			}
+			defer writer.Close()
+
+			if _, err := writer.Write([]byte(ldap.EntriesToLDIF(entries))); err != nil {
+				ops.logger.Error("导出LDIF失败：%v", err)
+				dialog.ShowError(fmt.Errorf("导出LDIF失败: %v", err), ops.window)
+				return
+			}
+			ops.logger.Info("已导出 %d 个条目到：%s", len(entries), writer.URI().Path())
+		}, ops.window)
+		saveDialog.SetFileName(fileName)
+		saveDialog.Show()
+	}, ops.window)
+}
+
+// HandleImportLDIF 选择LDIF文件并逐条添加其中的条目
+func (ops *LDAPOperations) HandleImportLDIF(domain string, adminDN string, adminPassword string, portEntry *CustomPortEntry, isSSL bool) {
+	ops.logger.Debug("开始导入LDIF")
+
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			ops.logger.Error("打开LDIF文件失败：%v", err)
+			dialog.ShowError(err, ops.window)
+			return
+		}
+		if reader == nil {
+			return // 用户取消
+		}
+		defer reader.Close()
+
+		requests, err := ldap.ParseLDIF(reader)
+		if err != nil {
+			ops.logger.Error("解析LDIF失败：%v", err)
+			dialog.ShowError(fmt.Errorf("解析LDIF失败: %v", err), ops.window)
+			return
+		}
+		if len(requests) == 0 {
+			ops.logger.Warn("LDIF文件中没有可导入的条目")
+			return
+		}
+		ops.logger.Info("从 %s 解析到 %d 个条目", reader.URI().Path(), len(requests))
+
+		ops.runOperation("导入LDIF", func() {
+			added, skipped, failed, err := client.AddEntries(requests)
+			if err != nil {
+				ops.logger.Error("导入LDIF中断：%v", err)
+			}
+			ops.logger.Info("导入完成：成功 %d 个，跳过 %d 个，失败 %d 个", added, skipped, failed)
+			if added > 0 {
+				ops.clearUndo()
+			}
+		})
+	}, ops.window)
+}
+
+// HandleCopyLdapsearch 将当前搜索参数生成为等效的ldapsearch命令并复制到剪贴板
+// 优先使用最近一次搜索的基准DN、过滤器和返回属性
+func (ops *LDAPOperations) HandleCopyLdapsearch(domain string, adminDN string, adminPassword string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	if !ops.validateInputs(FieldDomain, FieldPort) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	baseDN := strings.TrimSpace(searchDN)
+	filter := "(objectClass=*)"
+	var attributes []string
+	if result := ops.lastSearchResult(); result != nil {
+		baseDN, filter, attributes = result.BaseDN, result.Filter, result.Attributes
+	}
+	if baseDN == "" {
+		dialog.ShowError(fmt.Errorf("请输入搜索DN或先执行一次搜索"), ops.window)
+		return
+	}
+
+	copyCommand := func(includePassword bool) {
+		ops.window.Clipboard().SetContent(client.LdapsearchCommand(baseDN, filter, attributes, includePassword))
+		// 日志中始终隐藏密码
+		ops.logger.Info("已复制ldapsearch命令到剪贴板：\n%s", client.LdapsearchCommand(baseDN, filter, attributes, false))
+		if !includePassword && adminPassword != "" {
+			ops.logger.Info("执行前请设置环境变量：export LDAP_PW='<密码>'")
+		}
+	}
+
+	if adminPassword == "" {
+		copyCommand(false)
+		return
+	}
+	dialog.ShowConfirm("复制ldapsearch命令", "是否在命令中包含明文密码？\n选择否将使用环境变量 "+ldap.LdapsearchPasswordVar+" 代替。", copyCommand, ops.window)
+}
+
+// HandleFindByObjectID 按objectGUID或objectSid查找条目，找到后复制其DN
+func (ops *LDAPOperations) HandleFindByObjectID(domain string, adminDN string, adminPassword string, searchDN string, portEntry *CustomPortEntry, isSSL bool) {
+	if !ops.validateInputs(FieldDomain, FieldPort, FieldAdminDN, FieldAdminPassword) {
+		return
+	}
+
+	client, err := ops.createLDAPClient(domain, adminDN, adminPassword, portEntry, isSSL)
+	if err != nil {
+		dialog.ShowError(err, ops.window)
+		return
+	}
+
+	idEntry := widget.NewEntry()
+	idEntry.SetPlaceHolder("GUID（xxxxxxxx-xxxx-...）或SID（S-1-5-21-...）")
+	items := []*widget.FormItem{
+		widget.NewFormItem("GUID/SID", idEntry),
+	}
+
+	dialog.ShowForm("按GUID/SID查找", "查找", "取消", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		id := strings.TrimSpace(idEntry.Text)
+		if id == "" {
+			dialog.ShowError(fmt.Errorf("GUID/SID不能为空"), ops.window)
+			return
+		}
+
+		ops.runOperation("按GUID/SID查找", func() {
+			baseDN, err := ops.resolveSearchDN(client, searchDN)
+			if err != nil {
+				ops.logger.Error("检测基准DN失败：%v", err)
+				dialog.ShowError(fmt.Errorf("请输入搜索DN: %v", err), ops.window)
+				return
+			}
+
+			dn, err := client.FindByObjectID(id, baseDN)
+			if err != nil {
+				ops.logger.Error("按GUID/SID查找失败：%v", err)
+				return
+			}
+			ops.logger.Info("%s 对应的条目：%s", id, dn)
+			ops.copyDNToClipboard(dn)
+		})
+	}, ops.window)
+}
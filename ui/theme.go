@@ -8,20 +8,64 @@ import (
 	"fyne.io/fyne/v2/theme"
 )
 
+// 可选的字号
+const (
+	TextSizeSmall  = "小"
+	TextSizeMedium = "中"
+	TextSizeLarge  = "大"
+)
+
+// textSizes 字号名称对应的文字大小，"中"与默认主题一致
+var textSizes = map[string]float32{
+	TextSizeSmall:  12,
+	TextSizeMedium: 14,
+	TextSizeLarge:  18,
+}
+
+// TextSizeNames 返回可选的字号名称
+func TextSizeNames() []string {
+	return []string{TextSizeSmall, TextSizeMedium, TextSizeLarge}
+}
+
 // MyTheme 是应用程序的自定义主题
 type MyTheme struct {
 	fyne.Theme
+	variant      fyne.ThemeVariant // 强制使用的明暗模式
+	forceVariant bool              // 是否忽略系统设置，使用variant
+	textSize     float32           // 文字大小，0表示使用默认值
 }
 
 // NewMyTheme 创建一个新的自定义主题实例，直接返回主题对象
-func NewMyTheme() fyne.Theme {
+func NewMyTheme() *MyTheme {
 	return &MyTheme{Theme: theme.DefaultTheme()}
 }
 
+// SetVariant 固定使用深色或浅色模式，修改后需要重新调用SetTheme生效
+func (m *MyTheme) SetVariant(variant fyne.ThemeVariant) {
+	m.variant = variant
+	m.forceVariant = true
+}
+
+// SetTextSize 按名称设置文字大小，名称无效时返回false；修改后需要重新调用SetTheme生效
+func (m *MyTheme) SetTextSize(name string) bool {
+	size, ok := textSizes[name]
+	if !ok {
+		return false
+	}
+	m.textSize = size
+	return true
+}
+
 // Color 返回指定主题颜色
 func (m MyTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
-	// 修改禁用状态文字颜色为纯黑色
+	if m.forceVariant {
+		variant = m.variant
+	}
+	// 状态区和过滤器描述是禁用的输入框，禁用文字需要保持清晰：浅色模式用纯黑，深色模式用浅灰
 	if name == theme.ColorNameDisabled {
+		if variant == theme.VariantDark {
+			return &color.NRGBA{R: 220, G: 220, B: 220, A: 255}
+		}
 		return &color.NRGBA{R: 0, G: 0, B: 0, A: 255} // RGBA(0,0,0,255)
 	}
 	// 其他颜色使用默认主题设置
@@ -40,5 +84,8 @@ func (m MyTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
 
 // Size 返回指定主题尺寸
 func (m MyTheme) Size(name fyne.ThemeSizeName) float32 {
+	if name == theme.SizeNameText && m.textSize > 0 {
+		return m.textSize
+	}
 	return m.Theme.Size(name)
 }
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"LdapTest/ldap"
+)
+
+// undoAction 最近一次修改操作的逆操作
+type undoAction struct {
+	description string                              // 撤销内容描述
+	client      *ldap.LDAPClient                    // 执行原操作的客户端
+	revert      func(client *ldap.LDAPClient) error // 逆操作
+}
+
+// SetUndoButton 设置撤销按钮，没有可撤销的操作时禁用
+func (ops *LDAPOperations) SetUndoButton(button *widget.Button) {
+	ops.undoMu.Lock()
+	defer ops.undoMu.Unlock()
+	ops.undoButton = button
+	ops.refreshUndoButtonLocked()
+}
+
+// recordUndo 记录最近一次修改操作的逆操作，替换之前的记录
+func (ops *LDAPOperations) recordUndo(description string, client *ldap.LDAPClient, revert func(client *ldap.LDAPClient) error) {
+	ops.undoMu.Lock()
+	defer ops.undoMu.Unlock()
+	ops.undo = &undoAction{description: description, client: client, revert: revert}
+	ops.refreshUndoButtonLocked()
+	ops.logger.Debug("可撤销：%s", description)
+}
+
+// clearUndo 清除撤销记录
+func (ops *LDAPOperations) clearUndo() {
+	ops.undoMu.Lock()
+	defer ops.undoMu.Unlock()
+	ops.undo = nil
+	ops.refreshUndoButtonLocked()
+}
+
+// refreshUndoButtonLocked 根据是否有撤销记录启用或禁用按钮，调用方需持有undoMu
+func (ops *LDAPOperations) refreshUndoButtonLocked() {
+	if ops.undoButton == nil {
+		return
+	}
+	if ops.undo == nil || ops.ReadOnly() {
+		ops.undoButton.Disable()
+	} else {
+		ops.undoButton.Enable()
+	}
+}
+
+// HandleUndo 撤销最近一次修改操作，撤销记录使用后即清除
+func (ops *LDAPOperations) HandleUndo() {
+	ops.undoMu.Lock()
+	action := ops.undo
+	ops.undoMu.Unlock()
+	if action == nil {
+		ops.logger.Info("没有可撤销的操作")
+		return
+	}
+
+	dialog.ShowConfirm("撤销", "确定要撤销以下操作吗？\n"+action.description, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		ops.runOperation("撤销", func() {
+			// 确认期间记录可能已被替换；取走记录保证同一操作只撤销一次
+			ops.undoMu.Lock()
+			if ops.undo != action {
+				ops.undoMu.Unlock()
+				ops.logger.Warn("撤销记录已变更，请重新撤销")
+				return
+			}
+			ops.undo = nil
+			ops.refreshUndoButtonLocked()
+			ops.undoMu.Unlock()
+
+			ops.applyClientSettings(action.client)
+			ops.logger.Info("正在撤销：%s", action.description)
+			if err := action.revert(action.client); err != nil {
+				ops.logger.Error("撤销失败：%v", err)
+				dialog.ShowError(err, ops.window)
+				return
+			}
+			ops.logger.Info("撤销成功：%s", action.description)
+		})
+	}, ops.window)
+}
@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"LdapTest/ldap"
+)
+
+// 需要校验的输入字段，取值同时用作错误提示中的字段名
+const (
+	FieldDomain        = "服务器地址"
+	FieldPort          = "服务器端口"
+	FieldAdminDN       = "管理员DN"
+	FieldAdminPassword = "管理员密码"
+	FieldLdapDN        = "LDAP DN"
+	FieldLdapBindName  = "LDAP账号" // 用于绑定时的LDAP DN输入框，允许UPN和NT格式
+	FieldLdapPassword  = "LDAP密码"
+	FieldGroupDN       = "组DN"
+	FieldSearchDN      = "搜索DN"
+	FieldTestUser      = "测试用户名"
+	FieldTestPassword  = "测试密码"
+)
+
+// placeholderHosts 输入框中作为示例的主机名，不能当作真实服务器使用
+var placeholderHosts = []string{"example.com", "ldap.example.com"}
+
+// placeholderPatterns 示例值中出现的片段，输入包含这些片段时需要用户确认
+var placeholderPatterns = []string{"example.com", "dc=example,dc=com"}
+
+// placeholderFields 需要检查示例值的字段
+var placeholderFields = []string{FieldDomain, FieldSearchDN, FieldAdminDN}
+
+// isPlaceholderHost 判断服务器地址是否为示例主机名
+func isPlaceholderHost(host string) bool {
+	host = strings.TrimSpace(host)
+	for _, placeholder := range placeholderHosts {
+		if strings.EqualFold(host, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPlaceholder 判断输入是否包含示例值（忽略大小写和空格）
+func containsPlaceholder(value string) bool {
+	normalized := strings.ToLower(strings.ReplaceAll(value, " ", ""))
+	for _, pattern := range placeholderPatterns {
+		if strings.Contains(normalized, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredValidator 校验字段不为空
+func requiredValidator(field string) fyne.StringValidator {
+	return func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("%s不能为空", field)
+		}
+		return nil
+	}
+}
+
+// hostValidator 校验服务器地址不为空且不是示例地址
+func hostValidator(field string) fyne.StringValidator {
+	return func(text string) error {
+		host := strings.TrimSpace(text)
+		if host == "" {
+			return fmt.Errorf("%s不能为空", field)
+		}
+		if isPlaceholderHost(host) {
+			return fmt.Errorf("%s %s 只是示例，请输入实际的服务器地址", field, host)
+		}
+		return nil
+	}
+}
+
+// portValidator 校验端口号在1-65535之间
+func portValidator(field string) fyne.StringValidator {
+	return func(text string) error {
+		port, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%s必须是1-65535之间的数字", field)
+		}
+		return nil
+	}
+}
+
+// dnValidator 校验DN语法
+func dnValidator(field string) fyne.StringValidator {
+	return func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("%s不能为空", field)
+		}
+		if err := ldap.ValidateDN(text); err != nil {
+			return fmt.Errorf("%s: %v", field, err)
+		}
+		return nil
+	}
+}
+
+// bindNameValidator 校验绑定账号，接受DN、UPN（user@domain）和NT（DOMAIN\user）格式
+func bindNameValidator(field string) fyne.StringValidator {
+	return func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("%s不能为空", field)
+		}
+		if err := ldap.ValidateBindName(text); err != nil {
+			return fmt.Errorf("%s: %v", field, err)
+		}
+		return nil
+	}
+}
+
+// fieldValidators 各字段使用的校验函数
+var fieldValidators = map[string]func(field string) fyne.StringValidator{
+	FieldDomain:        hostValidator,
+	FieldPort:          portValidator,
+	FieldAdminDN:       bindNameValidator,
+	FieldAdminPassword: requiredValidator,
+	FieldLdapDN:        dnValidator,
+	FieldLdapBindName:  bindNameValidator,
+	FieldLdapPassword:  requiredValidator,
+	FieldGroupDN:       dnValidator,
+	FieldSearchDN:      dnValidator,
+	FieldTestUser:      requiredValidator,
+	FieldTestPassword:  requiredValidator,
+}
+
+// entry 返回字段对应的输入框，未设置时返回nil
+func (entries *UIEntries) entry(field string) *widget.Entry {
+	switch field {
+	case FieldDomain:
+		if entries.DomainEntry != nil {
+			return &entries.DomainEntry.Entry
+		}
+	case FieldPort:
+		if entries.PortEntry != nil {
+			return &entries.PortEntry.Entry
+		}
+	case FieldAdminDN:
+		return entries.AdminEntry
+	case FieldAdminPassword:
+		return entries.PasswordEntry
+	case FieldLdapDN, FieldLdapBindName:
+		return entries.LdapDNEntry
+	case FieldLdapPassword:
+		return entries.LdapPasswordEntry
+	case FieldGroupDN:
+		if entries.LdapGroupEntry != nil {
+			return &entries.LdapGroupEntry.Entry
+		}
+	case FieldSearchDN:
+		return entries.SearchDNEntry
+	case FieldTestUser:
+		return entries.TestUserEntry
+	case FieldTestPassword:
+		return entries.TestPasswordEntry
+	}
+	return nil
+}
+
+// ValidateInputs 校验必填字段，并在无效的输入框上标记错误，返回所有字段的错误
+func ValidateInputs(entries *UIEntries, required []string) error {
+	if entries == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, field := range required {
+		newValidator, ok := fieldValidators[field]
+		entry := entries.entry(field)
+		if !ok || entry == nil {
+			continue
+		}
+		// 校验后保留校验器，修改输入时会实时更新标记
+		// 同一输入框可能按不同字段校验（如LDAP DN和LDAP账号），每次按当前字段重新设置
+		entry.Validator = newValidator(field)
+		if err := entry.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateInputs 校验界面上的必填字段，无效时记录日志并提示用户
+func (ops *LDAPOperations) validateInputs(required ...string) bool {
+	if err := ValidateInputs(ops.entries, required); err != nil {
+		ops.logger.Error("验证失败：%v", strings.ReplaceAll(err.Error(), "\n", "；"))
+		dialog.ShowError(err, ops.window)
+		return false
+	}
+	return true
+}
+
+// SetUIEntries 设置界面输入框，用于统一校验和标记错误
+func (ops *LDAPOperations) SetUIEntries(entries *UIEntries) {
+	ops.entries = entries
+}
+
+// ConfirmPlaceholders 检查服务器地址、搜索DN和管理员DN是否仍包含示例值，包含时需用户确认后再执行run
+// 同一组示例值确认过一次后不再询问
+func (ops *LDAPOperations) ConfirmPlaceholders(run func()) {
+	if ops.entries == nil {
+		run()
+		return
+	}
+	// 服务器地址本身就是示例主机名时，交给输入校验直接拒绝
+	if domain := ops.entries.entry(FieldDomain); domain != nil && isPlaceholderHost(domain.Text) {
+		run()
+		return
+	}
+
+	var found []string
+	for _, field := range placeholderFields {
+		if entry := ops.entries.entry(field); entry != nil && containsPlaceholder(entry.Text) {
+			found = append(found, field+"："+entry.Text)
+		}
+	}
+	if len(found) == 0 {
+		run()
+		return
+	}
+
+	summary := strings.Join(found, "\n")
+	if summary == ops.confirmedPlaceholders {
+		run()
+		return
+	}
+	ops.logger.Warn("输入仍包含示例值：%s", strings.Join(found, "；"))
+	dialog.ShowConfirm("示例值", "以下输入仍包含示例值（example.com）：\n"+summary+"\n\n确定要继续吗？", func(proceed bool) {
+		if !proceed {
+			ops.logger.Info("已取消操作，请修改示例值")
+			return
+		}
+		ops.confirmedPlaceholders = summary
+		run()
+	}, ops.window)
+}
@@ -32,6 +32,7 @@ func (e *CustomDomainEntry) FocusLost() {
 // CustomPortEntry 是一个自定义的端口输入框
 type CustomPortEntry struct {
 	widget.Entry
+	globalCatalog bool // 是否使用全局编录端口作为默认端口
 }
 
 // NewCustomPortEntry 创建一个新的自定义端口输入框
@@ -68,7 +69,7 @@ func (e *CustomPortEntry) FocusGained() {
 	}
 
 	// 如果是默认值，则选择文本以便用户直接替换
-	if e.Text == "389" || e.Text == "636" {
+	if e.Text == "389" || e.Text == "636" || e.Text == "3268" || e.Text == "3269" {
 		// 全选文本，便于用户直接替换
 		currentText := e.Text
 		e.SetText("")
@@ -76,8 +77,16 @@ func (e *CustomPortEntry) FocusGained() {
 	}
 }
 
-// SetDefaultPort 根据SSL状态设置默认端口
+// SetDefaultPort 根据SSL状态设置默认端口，全局编录模式下使用3268/3269
 func (e *CustomPortEntry) SetDefaultPort(ssl bool) {
+	if e.globalCatalog {
+		if ssl {
+			e.SetText("3269") // 全局编录SSL端口
+		} else {
+			e.SetText("3268") // 全局编录端口
+		}
+		return
+	}
 	if ssl {
 		e.SetText("636") // SSL端口
 	} else {
@@ -85,6 +94,12 @@ func (e *CustomPortEntry) SetDefaultPort(ssl bool) {
 	}
 }
 
+// SetGlobalCatalog 切换全局编录模式并按SSL状态重设默认端口
+func (e *CustomPortEntry) SetGlobalCatalog(enabled bool, ssl bool) {
+	e.globalCatalog = enabled
+	e.SetDefaultPort(ssl)
+}
+
 // GetPort 获取端口号
 func (e *CustomPortEntry) GetPort() (int, error) {
 	if e.Text == "" {